@@ -26,6 +26,69 @@ type ICache interface {
 	Expire(ctx context.Context, key string, expire int) error
 	Exists(ctx context.Context, key string) (bool, error)
 	GetImpl() interface{}
+
+	//IncreaseBy 按指定步长递增
+	IncreaseBy(ctx context.Context, key string, value int64) (int64, error)
+	//TTL 剩余有效期(秒),未设置过期时间返回-1,key不存在返回Nil
+	TTL(ctx context.Context, key string) (int, error)
+	//SetNX key不存在时写入,写入成功返回true
+	SetNX(ctx context.Context, key string, val interface{}, expire int) (bool, error)
+	//MGet 批量获取,不存在的key值为nil
+	MGet(ctx context.Context, keys ...string) (map[string]interface{}, error)
+	MSet(ctx context.Context, vals map[string]interface{}) error
+
+	SAdd(ctx context.Context, key string, members ...interface{}) (int64, error)
+	SRem(ctx context.Context, key string, members ...interface{}) (int64, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key string, member interface{}) (bool, error)
+	SCard(ctx context.Context, key string) (int64, error)
+
+	ZAdd(ctx context.Context, key string, members ...Z) (int64, error)
+	ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error)
+	ZRem(ctx context.Context, key string, members ...interface{}) (int64, error)
+	//ZScore 获取成员分数,成员不存在返回Nil
+	ZScore(ctx context.Context, key, member string) (float64, error)
+	//ZRank 按分数升序的排名(从0开始),成员不存在返回Nil
+	ZRank(ctx context.Context, key, member string) (int64, error)
+	//ZRange 按分数升序返回[start,stop]区间的成员,负数表示倒数
+	ZRange(ctx context.Context, key string, start, stop int64) ([]Z, error)
+	//ZRevRange 按分数降序返回[start,stop]区间的成员,负数表示倒数
+	ZRevRange(ctx context.Context, key string, start, stop int64) ([]Z, error)
+	ZCard(ctx context.Context, key string) (int64, error)
+
+	LPush(ctx context.Context, key string, vals ...interface{}) (int64, error)
+	RPush(ctx context.Context, key string, vals ...interface{}) (int64, error)
+	//LPop 弹出列表头部元素,列表为空返回Nil
+	LPop(ctx context.Context, key string) (string, error)
+	//RPop 弹出列表尾部元素,列表为空返回Nil
+	RPop(ctx context.Context, key string) (string, error)
+	LRange(ctx context.Context, key string, start, stop int64) ([]string, error)
+	LLen(ctx context.Context, key string) (int64, error)
+
+	//Pipeline 批量提交fn中的写操作,fn返回错误时不提交
+	Pipeline(ctx context.Context, fn func(pipe Pipeliner) error) error
+}
+
+// Z 有序集合成员
+type Z struct {
+	Score  float64
+	Member string
+}
+
+// Pipeliner 批量写操作,命令在Pipeline回调返回后一次性提交
+type Pipeliner interface {
+	Set(key string, val interface{}, expire int)
+	Del(key string)
+	Expire(key string, expire int)
+	IncreaseBy(key string, value int64)
+	HashSet(hk, key string, val string)
+	HashDel(hk, key string)
+	SAdd(key string, members ...interface{})
+	SRem(key string, members ...interface{})
+	ZAdd(key string, members ...Z)
+	ZRem(key string, members ...interface{})
+	LPush(key string, vals ...interface{})
+	RPush(key string, vals ...interface{})
 }
 
 // cacheResover 定义配置文件转换方法
@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+
+	"github.com/zhiyunliu/glue/encoding"
+	_ "github.com/zhiyunliu/glue/encoding/binding"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	//DefaultCodec 默认序列化方式
+	DefaultCodec = "json"
+	//DefaultNegativeTTL 默认空值缓存时间(秒)
+	DefaultNegativeTTL = 30
+	//DefaultJitter 默认过期时间随机浮动比例
+	DefaultJitter = 0.1
+)
+
+// nilValue 空值占位,loader返回Nil时写入缓存,避免不存在的数据反复穿透到数据源
+const nilValue = "\x00glue.cache.nil\x00"
+
+// Loader 缓存未命中时加载数据,返回Nil表示数据不存在
+type Loader[T any] func(ctx context.Context) (T, error)
+
+// TypedOption 类型化缓存配置选项
+type TypedOption func(*typedOptions)
+
+type typedOptions struct {
+	codec       encoding.Codec
+	negativeTTL int
+	jitter      float64
+}
+
+// WithCodec 指定序列化方式,名称对应encoding中注册的codec(json,protobuf,msgpack...)
+func WithCodec(name string) TypedOption {
+	return func(o *typedOptions) {
+		codec := encoding.GetCodec(name)
+		if codec == nil {
+			panic(fmt.Errorf("cache: 未注册的codec:%s", name))
+		}
+		o.codec = codec
+	}
+}
+
+// WithNegativeTTL 指定空值缓存时间(秒),<=0时不缓存空值
+func WithNegativeTTL(ttl int) TypedOption {
+	return func(o *typedOptions) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithJitter 指定过期时间随机浮动比例,避免同一批数据同时过期
+func WithJitter(ratio float64) TypedOption {
+	return func(o *typedOptions) {
+		o.jitter = ratio
+	}
+}
+
+// Typed 在ICache之上提供类型化读写,
+// 缓存未命中时通过singleflight合并同一key的并发加载
+type Typed[T any] struct {
+	cache ICache
+	opts  *typedOptions
+	group singleflight.Group
+}
+
+// NewTyped 构建类型化缓存
+func NewTyped[T any](cache ICache, opts ...TypedOption) *Typed[T] {
+	tOpts := &typedOptions{
+		codec:       encoding.GetCodec(DefaultCodec),
+		negativeTTL: DefaultNegativeTTL,
+		jitter:      DefaultJitter,
+	}
+	for i := range opts {
+		opts[i](tOpts)
+	}
+	return &Typed[T]{cache: cache, opts: tOpts}
+}
+
+// Get 获取数据,不存在或为空值缓存时返回Nil
+func (t *Typed[T]) Get(ctx context.Context, key string) (val T, err error) {
+	data, err := t.cache.Get(ctx, key)
+	if err != nil {
+		return val, err
+	}
+	if data == nilValue {
+		return val, Nil
+	}
+	return t.decode(data)
+}
+
+// Set 写入数据,ttl单位秒,<=0时不过期
+func (t *Typed[T]) Set(ctx context.Context, key string, val T, ttl int) error {
+	data, err := t.opts.codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return t.cache.Set(ctx, key, data, t.jitterTTL(ttl))
+}
+
+// Del 删除数据
+func (t *Typed[T]) Del(ctx context.Context, key string) error {
+	return t.cache.Del(ctx, key)
+}
+
+// GetOrLoad 优先读取缓存,未命中时调用loader加载并写入缓存;
+// 同一key的并发加载只执行一次,loader使用首个调用者的ctx;
+// loader返回Nil时写入空值缓存,其他错误不缓存
+func (t *Typed[T]) GetOrLoad(ctx context.Context, key string, ttl int, loader Loader[T]) (T, error) {
+	if data, err := t.cache.Get(ctx, key); err == nil {
+		if data == nilValue {
+			var val T
+			return val, Nil
+		}
+		//反序列化失败时视为未命中,重新加载覆盖
+		if val, err := t.decode(data); err == nil {
+			return val, nil
+		}
+	}
+	result, err, _ := t.group.Do(key, func() (interface{}, error) {
+		val, err := loader(ctx)
+		if errors.Is(err, Nil) {
+			if t.opts.negativeTTL > 0 {
+				t.cache.Set(ctx, key, nilValue, t.jitterTTL(t.opts.negativeTTL))
+			}
+			return val, err
+		}
+		if err != nil {
+			return val, err
+		}
+		//缓存写入失败不影响返回加载的数据
+		t.Set(ctx, key, val, ttl)
+		return val, nil
+	})
+	val, _ := result.(T)
+	return val, err
+}
+
+func (t *Typed[T]) decode(data string) (val T, err error) {
+	target := interface{}(&val)
+	//指针类型(如protobuf消息)需先创建实例再反序列化
+	if rt := reflect.TypeOf(val); rt != nil && rt.Kind() == reflect.Ptr {
+		rv := reflect.New(rt.Elem())
+		val = rv.Interface().(T)
+		target = rv.Interface()
+	}
+	err = t.opts.codec.Unmarshal([]byte(data), target)
+	return
+}
+
+func (t *Typed[T]) jitterTTL(ttl int) int {
+	if ttl <= 0 || t.opts.jitter <= 0 {
+		return ttl
+	}
+	delta := int(float64(ttl) * t.opts.jitter)
+	if delta <= 0 {
+		return ttl
+	}
+	return ttl + rand.Intn(delta+1)
+}
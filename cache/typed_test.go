@@ -0,0 +1,120 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zhiyunliu/glue/cache"
+	"github.com/zhiyunliu/glue/contrib/cache/memory"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type user struct {
+	ID   int64  `json:"id" codec:"id"`
+	Name string `json:"name" codec:"name"`
+}
+
+func TestTypedGetOrLoadSingleflight(t *testing.T) {
+	ctx := context.Background()
+	typed := cache.NewTyped[*user](memory.New(nil))
+
+	var calls int32
+	start := make(chan struct{})
+	loader := func(ctx context.Context) (*user, error) {
+		atomic.AddInt32(&calls, 1)
+		<-start
+		return &user{ID: 1, Name: "a"}, nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := typed.GetOrLoad(ctx, "user:1", 60, loader)
+			if err != nil || u.Name != "a" {
+				t.Errorf("GetOrLoad = %v, %v", u, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(start)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("loader calls = %d, want 1", calls)
+	}
+	u, err := typed.Get(ctx, "user:1")
+	if err != nil || u.ID != 1 {
+		t.Fatalf("Get = %v, %v", u, err)
+	}
+}
+
+func TestTypedNegativeCache(t *testing.T) {
+	ctx := context.Background()
+	typed := cache.NewTyped[user](memory.New(nil))
+
+	calls := 0
+	loader := func(ctx context.Context) (user, error) {
+		calls++
+		return user{}, cache.Nil
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := typed.GetOrLoad(ctx, "user:2", 60, loader); err != cache.Nil {
+			t.Fatalf("GetOrLoad err = %v, want cache.Nil", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("loader calls = %d, want 1", calls)
+	}
+
+	typed = cache.NewTyped[user](memory.New(nil), cache.WithNegativeTTL(0))
+	calls = 0
+	typed.GetOrLoad(ctx, "user:2", 60, loader)
+	typed.GetOrLoad(ctx, "user:2", 60, loader)
+	if calls != 2 {
+		t.Fatalf("loader calls without negative cache = %d, want 2", calls)
+	}
+
+	//loader包装的Nil同样写入空值缓存
+	typed = cache.NewTyped[user](memory.New(nil))
+	calls = 0
+	wrapped := func(ctx context.Context) (user, error) {
+		calls++
+		return user{}, fmt.Errorf("user:3 not found:%w", cache.Nil)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := typed.GetOrLoad(ctx, "user:3", 60, wrapped); !errors.Is(err, cache.Nil) {
+			t.Fatalf("GetOrLoad err = %v, want cache.Nil", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("loader calls with wrapped Nil = %d, want 1", calls)
+	}
+}
+
+func TestTypedCodec(t *testing.T) {
+	ctx := context.Background()
+	c := memory.New(nil)
+
+	packed := cache.NewTyped[user](c, cache.WithCodec("msgpack"))
+	if err := packed.Set(ctx, "m", user{ID: 3, Name: "c"}, 60); err != nil {
+		t.Fatal(err)
+	}
+	if u, err := packed.Get(ctx, "m"); err != nil || u.Name != "c" {
+		t.Fatalf("msgpack Get = %v, %v", u, err)
+	}
+
+	pb := cache.NewTyped[*wrapperspb.StringValue](c, cache.WithCodec("protobuf"))
+	if err := pb.Set(ctx, "p", wrapperspb.String("v"), 60); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := pb.Get(ctx, "p"); err != nil || v.GetValue() != "v" {
+		t.Fatalf("protobuf Get = %v, %v", v, err)
+	}
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/urfave/cli"
+	"github.com/zhiyunliu/glue/dlocker"
+	"github.com/zhiyunliu/glue/global"
+	"github.com/zhiyunliu/glue/standard"
+	"github.com/zhiyunliu/glue/xdb"
+	"github.com/zhiyunliu/glue/xdb/migrate"
+)
+
+func init() {
+	RegisterFunc(func(cfg *Options) cli.Command {
+		flags := append(getFlags(cfg),
+			cli.StringFlag{
+				Name:  "db,d",
+				Usage: `-数据库配置名称,对应dbs.<name>`,
+				Value: "default",
+			},
+			cli.StringFlag{
+				Name:  "dir",
+				Usage: `-迁移脚本目录,存在<dir>/<db>子目录时优先使用`,
+				Value: "migrations",
+			},
+		)
+		stepsFlag := func(value int) cli.Flag {
+			return cli.IntFlag{
+				Name:  "steps,n",
+				Usage: `-执行的版本数,0表示全部`,
+				Value: value,
+			}
+		}
+		return cli.Command{
+			Name:  "migrate",
+			Usage: "数据库迁移，执行版本化的SQL脚本(up|down|status|redo)",
+			Subcommands: []cli.Command{
+				{
+					Name:   "up",
+					Usage:  "执行未执行的版本",
+					Flags:  append(flags[:len(flags):len(flags)], stepsFlag(0)),
+					Action: doMigrateUp,
+				},
+				{
+					Name:   "down",
+					Usage:  "回滚已执行的版本,默认回滚最后一个版本",
+					Flags:  append(flags[:len(flags):len(flags)], stepsFlag(1)),
+					Action: doMigrateDown,
+				},
+				{
+					Name:   "status",
+					Usage:  "查询所有版本的执行状态",
+					Flags:  flags,
+					Action: doMigrateStatus,
+				},
+				{
+					Name:   "redo",
+					Usage:  "回滚最后一个版本并重新执行",
+					Flags:  flags,
+					Action: doMigrateRedo,
+				},
+			},
+		}
+	})
+}
+
+func doMigrateUp(c *cli.Context) (err error) {
+	migrator, dbName, err := getMigrator(c)
+	if err != nil {
+		return buildCmdResult(dbName, "Migrate up", err)
+	}
+	migrations, err := migrator.Up(context.Background(), c.Int("steps"))
+	printMigrations("up", migrations)
+	return buildCmdResult(dbName, "Migrate up", err)
+}
+
+func doMigrateDown(c *cli.Context) (err error) {
+	migrator, dbName, err := getMigrator(c)
+	if err != nil {
+		return buildCmdResult(dbName, "Migrate down", err)
+	}
+	migrations, err := migrator.Down(context.Background(), c.Int("steps"))
+	printMigrations("down", migrations)
+	return buildCmdResult(dbName, "Migrate down", err)
+}
+
+func doMigrateRedo(c *cli.Context) (err error) {
+	migrator, dbName, err := getMigrator(c)
+	if err != nil {
+		return buildCmdResult(dbName, "Migrate redo", err)
+	}
+	migration, err := migrator.Redo(context.Background())
+	if migration != nil {
+		printMigrations("redo", []*migrate.Migration{migration})
+	}
+	return buildCmdResult(dbName, "Migrate redo", err)
+}
+
+func doMigrateStatus(c *cli.Context) (err error) {
+	migrator, dbName, err := getMigrator(c)
+	if err != nil {
+		return buildCmdResult(dbName, "Migrate status", err)
+	}
+	list, err := migrator.Status(context.Background())
+	for _, item := range list {
+		status := "pending"
+		if item.Applied {
+			status = "applied " + item.AppliedAt
+		}
+		fmt.Printf("%d_%s\t%s\n", item.Version, item.Name, status)
+	}
+	return buildCmdResult(dbName, "Migrate status", err)
+}
+
+// getMigrator 根据命令参数构建迁移对象,配置了dlocker时使用分布式锁
+func getMigrator(c *cli.Context) (migrator *migrate.Migrator, dbName string, err error) {
+	dbName = c.String("db")
+	srvApp := GetSrvApp(c)
+	if err = srvApp.initApp(); err != nil {
+		return
+	}
+	if err = srvApp.loadConfig(); err != nil {
+		return
+	}
+
+	fsys := srvApp.options.migrations
+	if fsys == nil {
+		fsys = os.DirFS(".")
+	}
+	dir := c.String("dir")
+	if info, serr := fs.Stat(fsys, path.Join(dir, dbName)); serr == nil && info.IsDir() {
+		dir = path.Join(dir, dbName)
+	}
+
+	db := standard.GetInstance(xdb.DbTypeNode).(xdb.StandardDB).GetDB(dbName)
+	opts := []migrate.Option{}
+	if srvApp.options.Config.Value(dlocker.TypeNode).String() != "" {
+		key := fmt.Sprintf("%s:migrate:%s", global.AppName, dbName)
+		locker := standard.GetInstance(dlocker.TypeNode).(dlocker.StandardLocker).GetDLocker().Build(key)
+		opts = append(opts, migrate.WithLocker(locker))
+	}
+	migrator, err = migrate.New(db, fsys, dir, opts...)
+	return
+}
+
+func printMigrations(action string, migrations []*migrate.Migration) {
+	for _, item := range migrations {
+		fmt.Printf("%s %d_%s\n", action, item.Version, item.Name)
+	}
+}
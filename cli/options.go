@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"io/fs"
 	"net/url"
 	"time"
 
@@ -24,12 +25,14 @@ type Options struct {
 	Servers          []transport.Server
 	StartingHooks    []func(ctx context.Context) error
 	StartedHooks     []func(ctx context.Context) error
+	StoppingHooks    []func(ctx context.Context) error
 
 	logOpts       []log.ConfigOption
 	setting       *appSetting
 	configSources []config.Source
 	cmdConfigFile string
 	logPath       string
+	migrations    fs.FS
 }
 
 // Option 配置选项
@@ -113,6 +116,13 @@ func StartedHook(hook func(context.Context) error) Option {
 	}
 }
 
+// StoppingHook 服务停止前(注销之后、关闭server之前)执行
+func StoppingHook(hook func(context.Context) error) Option {
+	return func(o *Options) {
+		o.StoppingHooks = append(o.StoppingHooks, hook)
+	}
+}
+
 func RegistrarTimeout(timeout int64) Option {
 	return func(o *Options) {
 		o.RegistrarTimeout = time.Second * time.Duration(timeout)
@@ -125,6 +135,13 @@ func Command(cmd cli.Command) Option {
 	}
 }
 
+// Migrations 设置migrate命令使用的迁移脚本文件系统(如embed.FS),未设置时从当前目录读取
+func Migrations(fsys fs.FS) Option {
+	return func(o *Options) {
+		o.migrations = fsys
+	}
+}
+
 type AppMode string
 
 const (
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"context"
+
 	"github.com/kardianos/service"
 	"github.com/zhiyunliu/glue/log"
 )
@@ -11,11 +13,21 @@ func (p *ServiceApp) Stop(s service.Service) (err error) {
 	if err != nil {
 		return err
 	}
+	p.stoppingHooks(p.svcCtx)
 	p.stopServers()
 	p.closeLogger()
 	return nil
 }
 
+func (p *ServiceApp) stoppingHooks(ctx context.Context) {
+	hooks := p.options.StoppingHooks
+	for i := range hooks {
+		if err := hooks[i](ctx); err != nil {
+			log.Errorf("serviceApp close:%s stopping hook error:%+v", p.cliCtx.App.Name, err)
+		}
+	}
+}
+
 func (p *ServiceApp) stopServers() {
 	log.Infof("serviceApp close:%s stop servers", p.cliCtx.App.Name)
 	for i := range p.options.Servers {
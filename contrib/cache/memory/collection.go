@@ -0,0 +1,357 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/zhiyunliu/glue/cache"
+)
+
+func (m *Memory) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	strs, err := formatValues(members)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindSet, true)
+	if err != nil {
+		return 0, err
+	}
+	var added int64
+	for _, member := range strs {
+		if _, ok := e.set[member]; !ok {
+			e.set[member] = struct{}{}
+			added++
+		}
+	}
+	return added, nil
+}
+
+func (m *Memory) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	strs, err := formatValues(members)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindSet, false)
+	if e == nil {
+		return 0, err
+	}
+	var removed int64
+	for _, member := range strs {
+		if _, ok := e.set[member]; ok {
+			delete(e.set, member)
+			removed++
+		}
+	}
+	if len(e.set) == 0 {
+		m.remove(key)
+	}
+	return removed, nil
+}
+
+func (m *Memory) SMembers(ctx context.Context, key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindSet, false)
+	if e == nil {
+		return []string{}, err
+	}
+	members := make([]string, 0, len(e.set))
+	for member := range e.set {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (m *Memory) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	str, err := formatValue(member)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindSet, false)
+	if e == nil {
+		return false, err
+	}
+	_, ok := e.set[str]
+	return ok, nil
+}
+
+func (m *Memory) SCard(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindSet, false)
+	if e == nil {
+		return 0, err
+	}
+	return int64(len(e.set)), nil
+}
+
+// ZAdd 添加或更新成员分数,返回新增成员数
+func (m *Memory) ZAdd(ctx context.Context, key string, members ...cache.Z) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindZSet, true)
+	if err != nil {
+		return 0, err
+	}
+	var added int64
+	for _, z := range members {
+		if _, ok := e.zset[z.Member]; !ok {
+			added++
+		}
+		e.zset[z.Member] = z.Score
+	}
+	return added, nil
+}
+
+func (m *Memory) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindZSet, true)
+	if err != nil {
+		return 0, err
+	}
+	e.zset[member] += increment
+	return e.zset[member], nil
+}
+
+func (m *Memory) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	strs, err := formatValues(members)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindZSet, false)
+	if e == nil {
+		return 0, err
+	}
+	var removed int64
+	for _, member := range strs {
+		if _, ok := e.zset[member]; ok {
+			delete(e.zset, member)
+			removed++
+		}
+	}
+	if len(e.zset) == 0 {
+		m.remove(key)
+	}
+	return removed, nil
+}
+
+func (m *Memory) ZScore(ctx context.Context, key, member string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindZSet, false)
+	if e == nil {
+		if err == nil {
+			err = cache.Nil
+		}
+		return 0, err
+	}
+	score, ok := e.zset[member]
+	if !ok {
+		return 0, cache.Nil
+	}
+	return score, nil
+}
+
+func (m *Memory) ZRank(ctx context.Context, key, member string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindZSet, false)
+	if e == nil {
+		if err == nil {
+			err = cache.Nil
+		}
+		return 0, err
+	}
+	if _, ok := e.zset[member]; !ok {
+		return 0, cache.Nil
+	}
+	for i, z := range sortedZ(e.zset) {
+		if z.Member == member {
+			return int64(i), nil
+		}
+	}
+	return 0, cache.Nil
+}
+
+func (m *Memory) ZRange(ctx context.Context, key string, start, stop int64) ([]cache.Z, error) {
+	return m.zrange(key, start, stop, false)
+}
+
+func (m *Memory) ZRevRange(ctx context.Context, key string, start, stop int64) ([]cache.Z, error) {
+	return m.zrange(key, start, stop, true)
+}
+
+func (m *Memory) ZCard(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindZSet, false)
+	if e == nil {
+		return 0, err
+	}
+	return int64(len(e.zset)), nil
+}
+
+// LPush 依次插入列表头部,返回列表长度
+func (m *Memory) LPush(ctx context.Context, key string, vals ...interface{}) (int64, error) {
+	return m.push(key, vals, true)
+}
+
+// RPush 依次追加到列表尾部,返回列表长度
+func (m *Memory) RPush(ctx context.Context, key string, vals ...interface{}) (int64, error) {
+	return m.push(key, vals, false)
+}
+
+func (m *Memory) LPop(ctx context.Context, key string) (string, error) {
+	return m.pop(key, true)
+}
+
+func (m *Memory) RPop(ctx context.Context, key string) (string, error) {
+	return m.pop(key, false)
+}
+
+func (m *Memory) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindList, false)
+	if e == nil {
+		return []string{}, err
+	}
+	start, stop, ok := normalizeRange(start, stop, int64(len(e.list)))
+	if !ok {
+		return []string{}, nil
+	}
+	result := make([]string, stop-start+1)
+	copy(result, e.list[start:stop+1])
+	return result, nil
+}
+
+func (m *Memory) LLen(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindList, false)
+	if e == nil {
+		return 0, err
+	}
+	return int64(len(e.list)), nil
+}
+
+func (m *Memory) zrange(key string, start, stop int64, rev bool) ([]cache.Z, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindZSet, false)
+	if e == nil {
+		return []cache.Z{}, err
+	}
+	items := sortedZ(e.zset)
+	if rev {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	start, stop, ok := normalizeRange(start, stop, int64(len(items)))
+	if !ok {
+		return []cache.Z{}, nil
+	}
+	return items[start : stop+1], nil
+}
+
+func (m *Memory) push(key string, vals []interface{}, head bool) (int64, error) {
+	strs, err := formatValues(vals)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindList, true)
+	if err != nil {
+		return 0, err
+	}
+	if head {
+		items := make([]string, 0, len(strs)+len(e.list))
+		for i := len(strs) - 1; i >= 0; i-- {
+			items = append(items, strs[i])
+		}
+		e.list = append(items, e.list...)
+	} else {
+		e.list = append(e.list, strs...)
+	}
+	return int64(len(e.list)), nil
+}
+
+func (m *Memory) pop(key string, head bool) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.getKind(key, kindList, false)
+	if e == nil {
+		if err == nil {
+			err = cache.Nil
+		}
+		return "", err
+	}
+	var val string
+	if head {
+		val, e.list = e.list[0], e.list[1:]
+	} else {
+		val, e.list = e.list[len(e.list)-1], e.list[:len(e.list)-1]
+	}
+	if len(e.list) == 0 {
+		m.remove(key)
+	}
+	return val, nil
+}
+
+// sortedZ 按分数升序排列,分数相同时按成员字典序
+func sortedZ(zset map[string]float64) []cache.Z {
+	items := make([]cache.Z, 0, len(zset))
+	for member, score := range zset {
+		items = append(items, cache.Z{Score: score, Member: member})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score < items[j].Score
+		}
+		return items[i].Member < items[j].Member
+	})
+	return items
+}
+
+// normalizeRange 与redis一致处理负数下标及越界
+func normalizeRange(start, stop, n int64) (int64, int64, bool) {
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop || start >= n {
+		return 0, 0, false
+	}
+	return start, stop, true
+}
+
+func formatValues(vals []interface{}) ([]string, error) {
+	strs := make([]string, len(vals))
+	for i := range vals {
+		str, err := formatValue(vals[i])
+		if err != nil {
+			return nil, err
+		}
+		strs[i] = str
+	}
+	return strs, nil
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/zhiyunliu/glue/cache"
+)
+
+func TestMemorySortedSet(t *testing.T) {
+	ctx := context.Background()
+	m := New(nil)
+
+	if n, _ := m.ZAdd(ctx, "z", cache.Z{Score: 2, Member: "b"}, cache.Z{Score: 1, Member: "a"}, cache.Z{Score: 2, Member: "c"}); n != 3 {
+		t.Fatalf("ZAdd = %d, want 3", n)
+	}
+	if n, _ := m.ZAdd(ctx, "z", cache.Z{Score: 5, Member: "a"}); n != 0 {
+		t.Fatalf("ZAdd existing = %d, want 0", n)
+	}
+	items, _ := m.ZRange(ctx, "z", 0, -1)
+	want := []cache.Z{{Score: 2, Member: "b"}, {Score: 2, Member: "c"}, {Score: 5, Member: "a"}}
+	if !reflect.DeepEqual(items, want) {
+		t.Fatalf("ZRange = %v, want %v", items, want)
+	}
+	items, _ = m.ZRevRange(ctx, "z", -2, -1)
+	want = []cache.Z{{Score: 2, Member: "c"}, {Score: 2, Member: "b"}}
+	if !reflect.DeepEqual(items, want) {
+		t.Fatalf("ZRevRange = %v, want %v", items, want)
+	}
+	if items, _ = m.ZRange(ctx, "z", 5, 10); len(items) != 0 {
+		t.Fatalf("ZRange out of range = %v", items)
+	}
+	if rank, _ := m.ZRank(ctx, "z", "a"); rank != 2 {
+		t.Fatalf("ZRank = %d, want 2", rank)
+	}
+	m.ZRem(ctx, "z", "a", "b", "c")
+	if ok, _ := m.Exists(ctx, "z"); ok {
+		t.Fatal("empty zset should be removed")
+	}
+}
+
+func TestMemoryList(t *testing.T) {
+	ctx := context.Background()
+	m := New(nil)
+
+	m.LPush(ctx, "l", "a", "b")
+	m.RPush(ctx, "l", "c")
+	vals, _ := m.LRange(ctx, "l", 0, -1)
+	if !reflect.DeepEqual(vals, []string{"b", "a", "c"}) {
+		t.Fatalf("LRange = %v", vals)
+	}
+	if _, err := m.SAdd(ctx, "l", "x"); err != errWrongType {
+		t.Fatalf("SAdd on list err = %v, want wrong type", err)
+	}
+	m.LPop(ctx, "l")
+	m.LPop(ctx, "l")
+	m.LPop(ctx, "l")
+	if _, err := m.RPop(ctx, "l"); err != cache.Nil {
+		t.Fatalf("RPop empty err = %v, want cache.Nil", err)
+	}
+}
+
+func TestMemoryBatch(t *testing.T) {
+	ctx := context.Background()
+	m := New(nil)
+
+	m.HashSet(ctx, "h", "f", "v")
+	m.MSet(ctx, map[string]interface{}{"a": 1, "b": "2"})
+	vals, _ := m.MGet(ctx, "a", "b", "h", "c")
+	if vals["a"] != "1" || vals["b"] != "2" || vals["h"] != nil || vals["c"] != nil {
+		t.Fatalf("MGet = %v", vals)
+	}
+	if ttl, _ := m.TTL(ctx, "a"); ttl != -1 {
+		t.Fatalf("TTL without expire = %d, want -1", ttl)
+	}
+	if ok, _ := m.SetNX(ctx, "a", "x", 10); ok {
+		t.Fatal("SetNX existing key should return false")
+	}
+
+	err := m.Pipeline(ctx, func(pipe cache.Pipeliner) error {
+		pipe.IncreaseBy("a", 9)
+		pipe.Expire("b", 30)
+		pipe.SAdd("s", "x")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := m.Get(ctx, "a"); v != "10" {
+		t.Fatalf("Get after pipeline = %q, want 10", v)
+	}
+	if ttl, _ := m.TTL(ctx, "b"); ttl != 30 {
+		t.Fatalf("TTL after pipeline = %d, want 30", ttl)
+	}
+	if ok, _ := m.SIsMember(ctx, "s", "x"); !ok {
+		t.Fatal("SIsMember after pipeline should be true")
+	}
+}
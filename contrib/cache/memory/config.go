@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"github.com/zhiyunliu/glue/cache"
+	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/golibs/xtypes"
+)
+
+// Config 内存缓存配置
+type Config struct {
+	//MaxEntries 最大缓存条数,超过后按淘汰策略移除数据
+	MaxEntries int `json:"max_entries"`
+	//Eviction 淘汰策略 lru|lfu
+	Eviction string `json:"eviction"`
+}
+
+func getConfig(setting config.Config, opts ...cache.Option) (cfg *Config, err error) {
+	cfg = &Config{MaxEntries: DefaultMaxEntries, Eviction: EvictionLRU}
+	if err = setting.ScanTo(cfg); err != nil {
+		return
+	}
+	cacheOpts := &cache.Options{}
+	for i := range opts {
+		opts[i](cacheOpts)
+	}
+	var cfgData xtypes.XMap = cacheOpts.CfgData
+	if _, ok := cfgData["max_entries"]; ok {
+		maxEntries, err := cfgData.GetInt64("max_entries")
+		if err != nil {
+			return nil, err
+		}
+		cfg.MaxEntries = int(maxEntries)
+	}
+	if _, ok := cfgData["eviction"]; ok {
+		cfg.Eviction = cfgData.GetString("eviction")
+	}
+	return
+}
@@ -0,0 +1,13 @@
+package memory
+
+const (
+	Proto = "memory"
+
+	//EvictionLRU 淘汰最久未访问的数据
+	EvictionLRU = "lru"
+	//EvictionLFU 淘汰访问次数最少的数据
+	EvictionLFU = "lfu"
+
+	//DefaultMaxEntries 默认最大缓存条数
+	DefaultMaxEntries = 10000
+)
@@ -0,0 +1,493 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zhiyunliu/glue/cache"
+	"github.com/zhiyunliu/glue/config"
+)
+
+var (
+	errWrongType  = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errNotInteger = errors.New("ERR value is not an integer or out of range")
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindHash
+	kindSet
+	kindZSet
+	kindList
+)
+
+type entry struct {
+	key      string
+	kind     kind
+	str      string
+	hash     map[string]string
+	set      map[string]struct{}
+	zset     map[string]float64
+	list     []string
+	expireAt time.Time
+	elem     *list.Element
+	freq     int
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// Memory 进程内缓存,数据条数超过上限时按lru/lfu策略淘汰
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	eviction   string
+	items      map[string]*entry
+	policy     policy
+}
+
+// New 构建内存缓存
+func New(cfg *Config) *Memory {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Memory{
+		maxEntries: maxEntries,
+		eviction:   cfg.Eviction,
+		items:      map[string]*entry{},
+		policy:     newPolicy(cfg.Eviction),
+	}
+}
+
+func (m *Memory) Name() string {
+	return Proto
+}
+
+// Get from key
+func (m *Memory) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.get(key)
+	if e == nil {
+		return "", cache.Nil
+	}
+	if e.kind != kindString {
+		return "", errWrongType
+	}
+	return e.str, nil
+}
+
+// Set value with key and expire time
+func (m *Memory) Set(ctx context.Context, key string, val interface{}, expire int) error {
+	str, err := formatValue(val)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setString(key, str, expire)
+	return nil
+}
+
+// Del delete key
+func (m *Memory) Del(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(key)
+	return nil
+}
+
+// HashGet from key
+func (m *Memory) HashGet(ctx context.Context, hk, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, err := m.getHash(hk, false)
+	if err != nil {
+		return "", err
+	}
+	v, ok := hash[key]
+	if !ok {
+		return "", cache.Nil
+	}
+	return v, nil
+}
+
+// HashSet 设置hash字段,新增字段时返回true
+func (m *Memory) HashSet(ctx context.Context, hk, key string, val string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, err := m.getHash(hk, true)
+	if err != nil {
+		return false, err
+	}
+	_, ok := hash[key]
+	hash[key] = val
+	return !ok, nil
+}
+
+// HashDel delete key in specify hashtable
+func (m *Memory) HashDel(ctx context.Context, hk, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, err := m.getHash(hk, false)
+	if err != nil {
+		return err
+	}
+	delete(hash, key)
+	if len(hash) == 0 {
+		m.remove(hk)
+	}
+	return nil
+}
+
+// HashMGet 批量获取hash字段,不存在的字段值为nil
+func (m *Memory) HashMGet(ctx context.Context, hk string, key ...string) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, err := m.getHash(hk, false)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	result := make(map[string]interface{}, len(key))
+	for i := range key {
+		if v, ok := hash[key[i]]; ok {
+			result[key[i]] = v
+		} else {
+			result[key[i]] = nil
+		}
+	}
+	return result, nil
+}
+
+func (m *Memory) HashSetAll(ctx context.Context, hk string, val map[string]interface{}) (bool, error) {
+	fields := make(map[string]string, len(val))
+	for k, v := range val {
+		str, err := formatValue(v)
+		if err != nil {
+			return false, err
+		}
+		fields[k] = str
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, err := m.getHash(hk, true)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range fields {
+		hash[k] = v
+	}
+	return true, nil
+}
+
+func (m *Memory) HashExists(ctx context.Context, hk, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, err := m.getHash(hk, false)
+	if err != nil {
+		return false, err
+	}
+	_, ok := hash[key]
+	return ok, nil
+}
+
+// Increase
+func (m *Memory) Increase(ctx context.Context, key string) (int64, error) {
+	return m.incrBy(key, 1)
+}
+
+func (m *Memory) Decrease(ctx context.Context, key string) (int64, error) {
+	return m.incrBy(key, -1)
+}
+
+// Expire 设置过期时间,expire<=0时与redis一致直接删除数据
+func (m *Memory) Expire(ctx context.Context, key string, expire int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.get(key)
+	if e == nil {
+		return nil
+	}
+	if expire <= 0 {
+		m.remove(key)
+		return nil
+	}
+	e.expireAt = time.Now().Add(time.Duration(expire) * time.Second)
+	return nil
+}
+
+// Exists
+func (m *Memory) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.get(key) != nil, nil
+}
+
+// IncreaseBy 按指定步长递增
+func (m *Memory) IncreaseBy(ctx context.Context, key string, value int64) (int64, error) {
+	return m.incrBy(key, value)
+}
+
+// TTL 剩余有效期(秒),未设置过期时间返回-1,key不存在返回Nil
+func (m *Memory) TTL(ctx context.Context, key string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.get(key)
+	if e == nil {
+		return 0, cache.Nil
+	}
+	if e.expireAt.IsZero() {
+		return -1, nil
+	}
+	return int((time.Until(e.expireAt) + time.Second/2) / time.Second), nil
+}
+
+// SetNX key不存在时写入
+func (m *Memory) SetNX(ctx context.Context, key string, val interface{}, expire int) (bool, error) {
+	str, err := formatValue(val)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.get(key) != nil {
+		return false, nil
+	}
+	m.setString(key, str, expire)
+	return true, nil
+}
+
+// MGet 批量获取,不存在或非字符串类型的key值为nil
+func (m *Memory) MGet(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make(map[string]interface{}, len(keys))
+	for i := range keys {
+		result[keys[i]] = nil
+		if e := m.get(keys[i]); e != nil && e.kind == kindString {
+			result[keys[i]] = e.str
+		}
+	}
+	return result, nil
+}
+
+func (m *Memory) MSet(ctx context.Context, vals map[string]interface{}) error {
+	strs := make(map[string]string, len(vals))
+	for k, v := range vals {
+		str, err := formatValue(v)
+		if err != nil {
+			return err
+		}
+		strs[k] = str
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, v := range strs {
+		m.setString(k, v, 0)
+	}
+	return nil
+}
+
+// GetImpl 暴露原生对象
+func (m *Memory) GetImpl() interface{} {
+	return m
+}
+
+// Len 当前缓存条数(包含已过期但尚未清理的数据)
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.items)
+}
+
+// Flush 清空所有数据
+func (m *Memory) Flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = map[string]*entry{}
+	m.policy = newPolicy(m.eviction)
+}
+
+// setString 覆盖写入字符串,原有数据及过期时间一并清除
+func (m *Memory) setString(key, str string, expire int) {
+	m.remove(key)
+	e := m.put(key)
+	e.str = str
+	if expire > 0 {
+		e.expireAt = time.Now().Add(time.Duration(expire) * time.Second)
+	}
+}
+
+func (m *Memory) incrBy(key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.get(key)
+	if e == nil {
+		e = m.put(key)
+		e.str = "0"
+	}
+	if e.kind != kindString {
+		return 0, errWrongType
+	}
+	v, err := strconv.ParseInt(e.str, 10, 64)
+	if err != nil {
+		return 0, errNotInteger
+	}
+	v += delta
+	e.str = strconv.FormatInt(v, 10)
+	return v, nil
+}
+
+// getHash 获取hash数据,create为true时不存在则新建
+func (m *Memory) getHash(hk string, create bool) (map[string]string, error) {
+	e, err := m.getKind(hk, kindHash, create)
+	if e == nil {
+		return nil, err
+	}
+	return e.hash, nil
+}
+
+// getKind 获取指定类型的数据,create为true时不存在则新建,类型不一致返回errWrongType
+func (m *Memory) getKind(key string, k kind, create bool) (*entry, error) {
+	e := m.get(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		e = m.put(key)
+		e.kind = k
+		switch k {
+		case kindHash:
+			e.hash = map[string]string{}
+		case kindSet:
+			e.set = map[string]struct{}{}
+		case kindZSet:
+			e.zset = map[string]float64{}
+		}
+	}
+	if e.kind != k {
+		return nil, errWrongType
+	}
+	return e, nil
+}
+
+// get 获取未过期的数据并更新访问记录
+func (m *Memory) get(key string) *entry {
+	e, ok := m.items[key]
+	if !ok {
+		return nil
+	}
+	if e.expired(time.Now()) {
+		m.remove(key)
+		return nil
+	}
+	m.policy.touch(e)
+	return e
+}
+
+// put 新增数据,超过上限时淘汰数据
+func (m *Memory) put(key string) *entry {
+	for len(m.items) >= m.maxEntries {
+		victim := m.policy.victim()
+		if victim == nil {
+			break
+		}
+		m.remove(victim.key)
+	}
+	e := &entry{key: key}
+	m.items[key] = e
+	m.policy.add(e)
+	return e
+}
+
+func (m *Memory) remove(key string) {
+	e, ok := m.items[key]
+	if !ok {
+		return
+	}
+	delete(m.items, key)
+	m.policy.remove(e)
+}
+
+// formatValue 与redis客户端保持一致的值转换规则
+func formatValue(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", fmt.Errorf("memory: can't marshal %T (implement encoding.BinaryMarshaler)", val)
+	}
+}
+
+type memoryResolver struct {
+}
+
+func (s *memoryResolver) Name() string {
+	return Proto
+}
+
+func (s *memoryResolver) Resolve(setting config.Config, opts ...cache.Option) (cache.ICache, error) {
+	cfg, err := getConfig(setting, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg), nil
+}
+
+func init() {
+	cache.Register(&memoryResolver{})
+}
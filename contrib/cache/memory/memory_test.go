@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zhiyunliu/glue/cache"
+)
+
+func TestMemoryString(t *testing.T) {
+	ctx := context.Background()
+	m := New(&Config{})
+
+	if _, err := m.Get(ctx, "k"); err != cache.Nil {
+		t.Fatalf("Get missing err = %v, want cache.Nil", err)
+	}
+	if err := m.Set(ctx, "k", 12, 0); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := m.Get(ctx, "k"); v != "12" {
+		t.Fatalf("Get = %q, want 12", v)
+	}
+	if err := m.Set(ctx, "b", true, 0); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := m.Get(ctx, "b"); v != "1" {
+		t.Fatalf("Get bool = %q, want 1", v)
+	}
+	if err := m.Set(ctx, "x", struct{}{}, 0); err == nil {
+		t.Fatal("Set struct should fail")
+	}
+	m.Del(ctx, "k")
+	if ok, _ := m.Exists(ctx, "k"); ok {
+		t.Fatal("key should be deleted")
+	}
+}
+
+func TestMemoryCounter(t *testing.T) {
+	ctx := context.Background()
+	m := New(&Config{})
+
+	if v, _ := m.Increase(ctx, "c"); v != 1 {
+		t.Fatalf("Increase = %d, want 1", v)
+	}
+	m.Increase(ctx, "c")
+	if v, _ := m.Decrease(ctx, "c"); v != 1 {
+		t.Fatalf("Decrease = %d, want 1", v)
+	}
+	m.Set(ctx, "s", "abc", 0)
+	if _, err := m.Increase(ctx, "s"); err != errNotInteger {
+		t.Fatalf("Increase non-integer err = %v", err)
+	}
+}
+
+func TestMemoryHash(t *testing.T) {
+	ctx := context.Background()
+	m := New(&Config{})
+
+	if ok, _ := m.HashSet(ctx, "h", "a", "1"); !ok {
+		t.Fatal("HashSet new field should return true")
+	}
+	if ok, _ := m.HashSet(ctx, "h", "a", "2"); ok {
+		t.Fatal("HashSet existing field should return false")
+	}
+	m.HashSetAll(ctx, "h", map[string]interface{}{"b": 3})
+	vals, err := m.HashMGet(ctx, "h", "a", "b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vals["a"] != "2" || vals["b"] != "3" || vals["c"] != nil {
+		t.Fatalf("HashMGet = %v", vals)
+	}
+	if _, err := m.HashGet(ctx, "h", "c"); err != cache.Nil {
+		t.Fatalf("HashGet missing err = %v", err)
+	}
+	if _, err := m.Get(ctx, "h"); err != errWrongType {
+		t.Fatalf("Get hash err = %v, want wrong type", err)
+	}
+	m.HashDel(ctx, "h", "a")
+	m.HashDel(ctx, "h", "b")
+	if ok, _ := m.Exists(ctx, "h"); ok {
+		t.Fatal("empty hash should be removed")
+	}
+}
+
+func TestMemoryExpire(t *testing.T) {
+	ctx := context.Background()
+	m := New(&Config{})
+
+	m.Set(ctx, "k", "v", 1)
+	m.items["k"].expireAt = time.Now().Add(-time.Millisecond)
+	if _, err := m.Get(ctx, "k"); err != cache.Nil {
+		t.Fatalf("expired Get err = %v", err)
+	}
+	if m.Len() != 0 {
+		t.Fatalf("expired key should be removed, len = %d", m.Len())
+	}
+
+	m.Set(ctx, "k", "v", 0)
+	m.Expire(ctx, "k", 10)
+	if m.items["k"].expireAt.IsZero() {
+		t.Fatal("Expire should set ttl")
+	}
+	m.Expire(ctx, "k", 0)
+	if ok, _ := m.Exists(ctx, "k"); ok {
+		t.Fatal("Expire 0 should delete key")
+	}
+}
+
+func TestMemoryEviction(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		eviction string
+		evicted  string
+	}{
+		//a最早写入,但被访问过,lru淘汰最久未访问的b
+		{eviction: EvictionLRU, evicted: "b"},
+		//c最晚写入且未被访问,lfu淘汰访问次数最少的c
+		{eviction: EvictionLFU, evicted: "c"},
+	}
+	for _, tt := range tests {
+		m := New(&Config{MaxEntries: 3, Eviction: tt.eviction})
+		m.Set(ctx, "a", 1, 0)
+		m.Set(ctx, "b", 2, 0)
+		m.Get(ctx, "b")
+		m.Get(ctx, "a")
+		m.Get(ctx, "a")
+		m.Set(ctx, "c", 3, 0)
+		m.Get(ctx, "b")
+		m.Get(ctx, "a")
+		if tt.eviction == EvictionLRU {
+			//最终访问顺序:c,b,a
+			m.Get(ctx, "c")
+			m.Get(ctx, "a")
+		}
+		m.Set(ctx, "d", 4, 0)
+
+		if m.Len() != 3 {
+			t.Fatalf("%s: len = %d, want 3", tt.eviction, m.Len())
+		}
+		for _, k := range []string{"a", "b", "c", "d"} {
+			ok, _ := m.Exists(ctx, k)
+			if ok == (k == tt.evicted) {
+				t.Fatalf("%s: key %s exists = %v, evicted want %s", tt.eviction, k, ok, tt.evicted)
+			}
+		}
+	}
+}
+
+func TestMemoryLFUMinFreq(t *testing.T) {
+	ctx := context.Background()
+	m := New(&Config{MaxEntries: 2, Eviction: EvictionLFU})
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprint(i)
+		m.Set(ctx, key, i, 0)
+		m.Get(ctx, key)
+		if m.Len() > 2 {
+			t.Fatalf("len = %d, want <= 2", m.Len())
+		}
+	}
+}
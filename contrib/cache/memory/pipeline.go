@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/zhiyunliu/glue/cache"
+)
+
+// Pipeline 依次执行fn中记录的写操作,返回第一个错误
+func (m *Memory) Pipeline(ctx context.Context, fn func(pipe cache.Pipeliner) error) error {
+	pipe := &pipeline{}
+	if err := fn(pipe); err != nil {
+		return err
+	}
+	var firstErr error
+	for _, cmd := range pipe.cmds {
+		if err := cmd(ctx, m); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+type pipeline struct {
+	cmds []func(ctx context.Context, m *Memory) error
+}
+
+func (p *pipeline) add(cmd func(ctx context.Context, m *Memory) error) {
+	p.cmds = append(p.cmds, cmd)
+}
+
+func (p *pipeline) Set(key string, val interface{}, expire int) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		return m.Set(ctx, key, val, expire)
+	})
+}
+
+func (p *pipeline) Del(key string) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		return m.Del(ctx, key)
+	})
+}
+
+func (p *pipeline) Expire(key string, expire int) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		return m.Expire(ctx, key, expire)
+	})
+}
+
+func (p *pipeline) IncreaseBy(key string, value int64) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		_, err := m.IncreaseBy(ctx, key, value)
+		return err
+	})
+}
+
+func (p *pipeline) HashSet(hk, key string, val string) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		_, err := m.HashSet(ctx, hk, key, val)
+		return err
+	})
+}
+
+func (p *pipeline) HashDel(hk, key string) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		return m.HashDel(ctx, hk, key)
+	})
+}
+
+func (p *pipeline) SAdd(key string, members ...interface{}) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		_, err := m.SAdd(ctx, key, members...)
+		return err
+	})
+}
+
+func (p *pipeline) SRem(key string, members ...interface{}) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		_, err := m.SRem(ctx, key, members...)
+		return err
+	})
+}
+
+func (p *pipeline) ZAdd(key string, members ...cache.Z) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		_, err := m.ZAdd(ctx, key, members...)
+		return err
+	})
+}
+
+func (p *pipeline) ZRem(key string, members ...interface{}) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		_, err := m.ZRem(ctx, key, members...)
+		return err
+	})
+}
+
+func (p *pipeline) LPush(key string, vals ...interface{}) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		_, err := m.LPush(ctx, key, vals...)
+		return err
+	})
+}
+
+func (p *pipeline) RPush(key string, vals ...interface{}) {
+	p.add(func(ctx context.Context, m *Memory) error {
+		_, err := m.RPush(ctx, key, vals...)
+		return err
+	})
+}
@@ -0,0 +1,98 @@
+package memory
+
+import "container/list"
+
+// policy 淘汰策略,所有方法在持有Memory锁时调用
+type policy interface {
+	add(e *entry)
+	touch(e *entry)
+	remove(e *entry)
+	victim() *entry
+}
+
+func newPolicy(eviction string) policy {
+	if eviction == EvictionLFU {
+		return &lfuPolicy{buckets: map[int]*list.List{}}
+	}
+	return &lruPolicy{items: list.New()}
+}
+
+// lruPolicy 最近访问的数据放在链表头部,淘汰链表尾部的数据
+type lruPolicy struct {
+	items *list.List
+}
+
+func (p *lruPolicy) add(e *entry) {
+	e.elem = p.items.PushFront(e)
+}
+
+func (p *lruPolicy) touch(e *entry) {
+	p.items.MoveToFront(e.elem)
+}
+
+func (p *lruPolicy) remove(e *entry) {
+	p.items.Remove(e.elem)
+}
+
+func (p *lruPolicy) victim() *entry {
+	if back := p.items.Back(); back != nil {
+		return back.Value.(*entry)
+	}
+	return nil
+}
+
+// lfuPolicy 按访问次数分桶,淘汰访问次数最少的桶中最久未访问的数据
+type lfuPolicy struct {
+	buckets map[int]*list.List
+	minFreq int
+}
+
+func (p *lfuPolicy) add(e *entry) {
+	e.freq = 1
+	p.minFreq = 1
+	p.push(e)
+}
+
+func (p *lfuPolicy) touch(e *entry) {
+	p.remove(e)
+	if p.minFreq == e.freq && p.buckets[e.freq] == nil {
+		p.minFreq++
+	}
+	e.freq++
+	p.push(e)
+}
+
+func (p *lfuPolicy) remove(e *entry) {
+	bucket := p.buckets[e.freq]
+	bucket.Remove(e.elem)
+	if bucket.Len() == 0 {
+		delete(p.buckets, e.freq)
+	}
+}
+
+func (p *lfuPolicy) victim() *entry {
+	if len(p.buckets) == 0 {
+		return nil
+	}
+	bucket, ok := p.buckets[p.minFreq]
+	if !ok {
+		//删除数据后minFreq对应的桶可能为空,重新查找最小访问次数
+		p.minFreq = 0
+		for freq := range p.buckets {
+			if p.minFreq == 0 || freq < p.minFreq {
+				p.minFreq = freq
+			}
+		}
+		bucket = p.buckets[p.minFreq]
+	}
+	return bucket.Back().Value.(*entry)
+}
+
+func (p *lfuPolicy) push(e *entry) {
+	bucket, ok := p.buckets[e.freq]
+	if !ok {
+		bucket = list.New()
+		p.buckets[e.freq] = bucket
+	}
+	e.elem = bucket.PushFront(e)
+}
@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"context"
+
+	rds "github.com/go-redis/redis/v7"
+	"github.com/zhiyunliu/glue/cache"
+)
+
+func (r *Redis) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return r.client.SAdd(key, members...).Result()
+}
+
+func (r *Redis) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return r.client.SRem(key, members...).Result()
+}
+
+func (r *Redis) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(key).Result()
+}
+
+func (r *Redis) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	return r.client.SIsMember(key, member).Result()
+}
+
+func (r *Redis) SCard(ctx context.Context, key string) (int64, error) {
+	return r.client.SCard(key).Result()
+}
+
+// ZAdd 添加或更新成员分数,返回新增成员数
+func (r *Redis) ZAdd(ctx context.Context, key string, members ...cache.Z) (int64, error) {
+	return r.client.ZAdd(key, toRedisZ(members)...).Result()
+}
+
+func (r *Redis) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return r.client.ZIncrBy(key, increment, member).Result()
+}
+
+func (r *Redis) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return r.client.ZRem(key, members...).Result()
+}
+
+func (r *Redis) ZScore(ctx context.Context, key, member string) (float64, error) {
+	v, err := r.client.ZScore(key, member).Result()
+	if err == rds.Nil {
+		return v, cache.Nil
+	}
+	return v, err
+}
+
+func (r *Redis) ZRank(ctx context.Context, key, member string) (int64, error) {
+	v, err := r.client.ZRank(key, member).Result()
+	if err == rds.Nil {
+		return v, cache.Nil
+	}
+	return v, err
+}
+
+func (r *Redis) ZRange(ctx context.Context, key string, start, stop int64) ([]cache.Z, error) {
+	vals, err := r.client.ZRangeWithScores(key, start, stop).Result()
+	return fromRedisZ(vals), err
+}
+
+func (r *Redis) ZRevRange(ctx context.Context, key string, start, stop int64) ([]cache.Z, error) {
+	vals, err := r.client.ZRevRangeWithScores(key, start, stop).Result()
+	return fromRedisZ(vals), err
+}
+
+func (r *Redis) ZCard(ctx context.Context, key string) (int64, error) {
+	return r.client.ZCard(key).Result()
+}
+
+func (r *Redis) LPush(ctx context.Context, key string, vals ...interface{}) (int64, error) {
+	return r.client.LPush(key, vals...).Result()
+}
+
+func (r *Redis) RPush(ctx context.Context, key string, vals ...interface{}) (int64, error) {
+	return r.client.RPush(key, vals...).Result()
+}
+
+func (r *Redis) LPop(ctx context.Context, key string) (string, error) {
+	v, err := r.client.LPop(key).Result()
+	if err == rds.Nil {
+		return v, cache.Nil
+	}
+	return v, err
+}
+
+func (r *Redis) RPop(ctx context.Context, key string) (string, error) {
+	v, err := r.client.RPop(key).Result()
+	if err == rds.Nil {
+		return v, cache.Nil
+	}
+	return v, err
+}
+
+func (r *Redis) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return r.client.LRange(key, start, stop).Result()
+}
+
+func (r *Redis) LLen(ctx context.Context, key string) (int64, error) {
+	return r.client.LLen(key).Result()
+}
+
+func toRedisZ(members []cache.Z) []*rds.Z {
+	items := make([]*rds.Z, len(members))
+	for i := range members {
+		items[i] = &rds.Z{Score: members[i].Score, Member: members[i].Member}
+	}
+	return items
+}
+
+func fromRedisZ(vals []rds.Z) []cache.Z {
+	items := make([]cache.Z, len(vals))
+	for i := range vals {
+		member, _ := vals[i].Member.(string)
+		items[i] = cache.Z{Score: vals[i].Score, Member: member}
+	}
+	return items
+}
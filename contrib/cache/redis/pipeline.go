@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	rds "github.com/go-redis/redis/v7"
+	"github.com/zhiyunliu/glue/cache"
+)
+
+// Pipeline 将fn中的写操作通过redis pipeline一次性提交
+func (r *Redis) Pipeline(ctx context.Context, fn func(pipe cache.Pipeliner) error) error {
+	pipe := r.client.Pipeline()
+	defer pipe.Close()
+	if err := fn(&pipeline{pipe: pipe}); err != nil {
+		return err
+	}
+	_, err := pipe.Exec()
+	return err
+}
+
+type pipeline struct {
+	pipe rds.Pipeliner
+}
+
+func (p *pipeline) Set(key string, val interface{}, expire int) {
+	p.pipe.Set(key, val, time.Duration(expire)*time.Second)
+}
+
+func (p *pipeline) Del(key string) {
+	p.pipe.Del(key)
+}
+
+func (p *pipeline) Expire(key string, expire int) {
+	p.pipe.Expire(key, time.Duration(expire)*time.Second)
+}
+
+func (p *pipeline) IncreaseBy(key string, value int64) {
+	p.pipe.IncrBy(key, value)
+}
+
+func (p *pipeline) HashSet(hk, key string, val string) {
+	p.pipe.HSet(hk, key, val)
+}
+
+func (p *pipeline) HashDel(hk, key string) {
+	p.pipe.HDel(hk, key)
+}
+
+func (p *pipeline) SAdd(key string, members ...interface{}) {
+	p.pipe.SAdd(key, members...)
+}
+
+func (p *pipeline) SRem(key string, members ...interface{}) {
+	p.pipe.SRem(key, members...)
+}
+
+func (p *pipeline) ZAdd(key string, members ...cache.Z) {
+	p.pipe.ZAdd(key, toRedisZ(members)...)
+}
+
+func (p *pipeline) ZRem(key string, members ...interface{}) {
+	p.pipe.ZRem(key, members...)
+}
+
+func (p *pipeline) LPush(key string, vals ...interface{}) {
+	p.pipe.LPush(key, vals...)
+}
+
+func (p *pipeline) RPush(key string, vals ...interface{}) {
+	p.pipe.RPush(key, vals...)
+}
@@ -118,6 +118,53 @@ func (r *Redis) Exists(ctx context.Context, key string) (bool, error) {
 	return v > 0, err
 }
 
+// IncreaseBy 按指定步长递增
+func (r *Redis) IncreaseBy(ctx context.Context, key string, value int64) (int64, error) {
+	return r.client.IncrBy(key, value).Result()
+}
+
+// TTL 剩余有效期(秒),未设置过期时间返回-1,key不存在返回Nil
+func (r *Redis) TTL(ctx context.Context, key string) (int, error) {
+	v, err := r.client.TTL(key).Result()
+	if err != nil {
+		return 0, err
+	}
+	switch v {
+	case -2:
+		return 0, cache.Nil
+	case -1:
+		return -1, nil
+	}
+	return int(v / time.Second), nil
+}
+
+// SetNX key不存在时写入
+func (r *Redis) SetNX(ctx context.Context, key string, val interface{}, expire int) (bool, error) {
+	return r.client.SetNX(key, val, time.Duration(expire)*time.Second).Result()
+}
+
+// MGet 批量获取,不存在的key值为nil
+func (r *Redis) MGet(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	vals, err := r.client.MGet(keys...).Result()
+	if len(vals) > 0 {
+		for i := range keys {
+			result[keys[i]] = vals[i]
+		}
+	}
+	return result, err
+}
+
+func (r *Redis) MSet(ctx context.Context, vals map[string]interface{}) error {
+	if len(vals) == 0 {
+		return nil
+	}
+	return r.client.MSet(vals).Err()
+}
+
 // GetImpl 暴露原生client
 func (r *Redis) GetImpl() interface{} {
 	return r.client
@@ -130,6 +177,11 @@ func (s *redisResolver) Name() string {
 	return Proto
 }
 func (s *redisResolver) Resolve(config config.Config, opts ...cache.Option) (cache.ICache, error) {
+	return NewByConfig(config, opts...)
+}
+
+// NewByConfig 根据缓存配置构建redis缓存,供其他缓存实现复用
+func NewByConfig(config config.Config, opts ...cache.Option) (*Redis, error) {
 	client, err := getRedisClient(config, opts...)
 	if err != nil {
 		return nil, err
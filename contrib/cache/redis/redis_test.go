@@ -0,0 +1,146 @@
+package redis
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/zhiyunliu/glue/cache"
+)
+
+func TestRedisStrings(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRedis(t)
+
+	if err := r.Set(ctx, "k", "v", 60); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := r.TTL(ctx, "k"); err != nil || ttl != 60 {
+		t.Fatalf("TTL = %d, %v, want 60", ttl, err)
+	}
+	if _, err := r.TTL(ctx, "missing"); err != cache.Nil {
+		t.Fatalf("TTL missing err = %v, want cache.Nil", err)
+	}
+	if ok, _ := r.SetNX(ctx, "k", "v2", 0); ok {
+		t.Fatal("SetNX existing key should return false")
+	}
+	if ok, _ := r.SetNX(ctx, "nx", "v", 10); !ok {
+		t.Fatal("SetNX new key should return true")
+	}
+	if v, _ := r.IncreaseBy(ctx, "n", 5); v != 5 {
+		t.Fatalf("IncreaseBy = %d, want 5", v)
+	}
+	if err := r.MSet(ctx, map[string]interface{}{"a": "1", "b": 2}); err != nil {
+		t.Fatal(err)
+	}
+	vals, err := r.MGet(ctx, "a", "b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vals["a"] != "1" || vals["b"] != "2" || vals["c"] != nil {
+		t.Fatalf("MGet = %v", vals)
+	}
+}
+
+func TestRedisSets(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRedis(t)
+
+	if n, _ := r.SAdd(ctx, "s", "a", "b", "a"); n != 2 {
+		t.Fatalf("SAdd = %d, want 2", n)
+	}
+	if ok, _ := r.SIsMember(ctx, "s", "b"); !ok {
+		t.Fatal("SIsMember should be true")
+	}
+	r.SRem(ctx, "s", "b")
+	members, _ := r.SMembers(ctx, "s")
+	if !reflect.DeepEqual(members, []string{"a"}) {
+		t.Fatalf("SMembers = %v", members)
+	}
+	if n, _ := r.SCard(ctx, "s"); n != 1 {
+		t.Fatalf("SCard = %d, want 1", n)
+	}
+}
+
+func TestRedisSortedSets(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRedis(t)
+
+	r.ZAdd(ctx, "z", cache.Z{Score: 3, Member: "c"}, cache.Z{Score: 1, Member: "a"}, cache.Z{Score: 2, Member: "b"})
+	if v, _ := r.ZIncrBy(ctx, "z", 2.5, "a"); v != 3.5 {
+		t.Fatalf("ZIncrBy = %v, want 3.5", v)
+	}
+	items, err := r.ZRevRange(ctx, "z", 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, []cache.Z{{Score: 3.5, Member: "a"}, {Score: 3, Member: "c"}}) {
+		t.Fatalf("ZRevRange = %v", items)
+	}
+	if rank, _ := r.ZRank(ctx, "z", "b"); rank != 0 {
+		t.Fatalf("ZRank = %d, want 0", rank)
+	}
+	if _, err := r.ZScore(ctx, "z", "x"); err != cache.Nil {
+		t.Fatalf("ZScore missing err = %v, want cache.Nil", err)
+	}
+	r.ZRem(ctx, "z", "b")
+	if n, _ := r.ZCard(ctx, "z"); n != 2 {
+		t.Fatalf("ZCard = %d, want 2", n)
+	}
+}
+
+func TestRedisLists(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRedis(t)
+
+	r.RPush(ctx, "l", "b", "c")
+	r.LPush(ctx, "l", "a")
+	vals, _ := r.LRange(ctx, "l", 0, -1)
+	if !reflect.DeepEqual(vals, []string{"a", "b", "c"}) {
+		t.Fatalf("LRange = %v", vals)
+	}
+	if v, _ := r.RPop(ctx, "l"); v != "c" {
+		t.Fatalf("RPop = %q, want c", v)
+	}
+	if v, _ := r.LPop(ctx, "l"); v != "a" {
+		t.Fatalf("LPop = %q, want a", v)
+	}
+	if n, _ := r.LLen(ctx, "l"); n != 1 {
+		t.Fatalf("LLen = %d, want 1", n)
+	}
+	r.LPop(ctx, "l")
+	if _, err := r.LPop(ctx, "l"); err != cache.Nil {
+		t.Fatalf("LPop empty err = %v, want cache.Nil", err)
+	}
+}
+
+func TestRedisPipeline(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRedis(t)
+
+	err := r.Pipeline(ctx, func(pipe cache.Pipeliner) error {
+		pipe.Set("p1", "v", 0)
+		pipe.IncreaseBy("p2", 3)
+		pipe.HashSet("ph", "f", "v")
+		pipe.SAdd("ps", "a", "b")
+		pipe.ZAdd("pz", cache.Z{Score: 1, Member: "a"})
+		pipe.RPush("pl", "a")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, _ := r.MGet(ctx, "p1", "p2")
+	if vals["p1"] != "v" || vals["p2"] != "3" {
+		t.Fatalf("MGet after pipeline = %v", vals)
+	}
+	members, _ := r.SMembers(ctx, "ps")
+	sort.Strings(members)
+	if !reflect.DeepEqual(members, []string{"a", "b"}) {
+		t.Fatalf("SMembers after pipeline = %v", members)
+	}
+	if v, _ := r.HashGet(ctx, "ph", "f"); v != "v" {
+		t.Fatalf("HashGet after pipeline = %q", v)
+	}
+}
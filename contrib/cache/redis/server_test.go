@@ -0,0 +1,337 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zhiyunliu/glue/cache"
+	"github.com/zhiyunliu/glue/contrib/cache/memory"
+	"github.com/zhiyunliu/glue/contrib/redis"
+)
+
+// standIn 测试用的本地redis替身,按RESP协议解析命令并转发到memory缓存
+type standIn struct {
+	listener net.Listener
+	store    *memory.Memory
+}
+
+func newStandIn(t *testing.T) *standIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &standIn{listener: l, store: memory.New(nil)}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+// newTestRedis 构建连接到本地替身的redis缓存
+func newTestRedis(t *testing.T) *Redis {
+	s := newStandIn(t)
+	client, err := redis.NewByOpts("test", redis.WithAddrs(s.listener.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return &Redis{client: client}
+}
+
+func (s *standIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *standIn) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		s.exec(reply{w}, args)
+		//pipeline中的命令连续到达,缓冲区读完后再统一回写
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected line:%s", line)
+	}
+	n, _ := strconv.Atoi(line[1:])
+	args := make([]string, n)
+	for i := range args {
+		line, err = readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(line[1:])
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// reply 按RESP协议回写结果
+type reply struct {
+	*bufio.Writer
+}
+
+func (s *standIn) exec(w reply, args []string) {
+	ctx := context.Background()
+	m := s.store
+	cmd, args := strings.ToLower(args[0]), args[1:]
+	switch cmd {
+	case "ping":
+		w.WriteString("+PONG\r\n")
+	case "get":
+		w.bulk(m.Get(ctx, args[0]))
+	case "set":
+		expire, nx := 0, false
+		for i := 2; i < len(args); i++ {
+			switch strings.ToLower(args[i]) {
+			case "ex":
+				expire, _ = strconv.Atoi(args[i+1])
+				i++
+			case "nx":
+				nx = true
+			}
+		}
+		if nx {
+			ok, err := m.SetNX(ctx, args[0], args[1], expire)
+			if err == nil && !ok {
+				err = cache.Nil
+			}
+			w.status(err)
+			return
+		}
+		w.status(m.Set(ctx, args[0], args[1], expire))
+	case "setnx":
+		w.bool(m.SetNX(ctx, args[0], args[1], 0))
+	case "del":
+		var n int64
+		for _, k := range args {
+			if ok, _ := m.Exists(ctx, k); ok {
+				m.Del(ctx, k)
+				n++
+			}
+		}
+		w.integer(n, nil)
+	case "exists":
+		w.bool(m.Exists(ctx, args[0]))
+	case "expire":
+		ok, _ := m.Exists(ctx, args[0])
+		expire, _ := strconv.Atoi(args[1])
+		err := m.Expire(ctx, args[0], expire)
+		w.bool(ok, err)
+	case "ttl":
+		ttl, err := m.TTL(ctx, args[0])
+		if err == cache.Nil {
+			ttl, err = -2, nil
+		}
+		w.integer(int64(ttl), err)
+	case "incr":
+		w.integer(m.Increase(ctx, args[0]))
+	case "decr":
+		w.integer(m.Decrease(ctx, args[0]))
+	case "incrby":
+		v, _ := strconv.ParseInt(args[1], 10, 64)
+		w.integer(m.IncreaseBy(ctx, args[0], v))
+	case "mget":
+		vals, _ := m.MGet(ctx, args...)
+		w.WriteString(fmt.Sprintf("*%d\r\n", len(args)))
+		for _, k := range args {
+			w.nullable(vals[k])
+		}
+	case "mset":
+		vals := map[string]interface{}{}
+		for i := 0; i+1 < len(args); i += 2 {
+			vals[args[i]] = args[i+1]
+		}
+		w.status(m.MSet(ctx, vals))
+	case "hget":
+		w.bulk(m.HashGet(ctx, args[0], args[1]))
+	case "hset":
+		w.bool(m.HashSet(ctx, args[0], args[1], args[2]))
+	case "hdel":
+		ok, _ := m.HashExists(ctx, args[0], args[1])
+		w.bool(ok, m.HashDel(ctx, args[0], args[1]))
+	case "hexists":
+		w.bool(m.HashExists(ctx, args[0], args[1]))
+	case "hmset":
+		vals := map[string]interface{}{}
+		for i := 1; i+1 < len(args); i += 2 {
+			vals[args[i]] = args[i+1]
+		}
+		_, err := m.HashSetAll(ctx, args[0], vals)
+		w.status(err)
+	case "hmget":
+		vals, _ := m.HashMGet(ctx, args[0], args[1:]...)
+		w.WriteString(fmt.Sprintf("*%d\r\n", len(args)-1))
+		for _, k := range args[1:] {
+			w.nullable(vals[k])
+		}
+	case "sadd":
+		w.integer(m.SAdd(ctx, args[0], toInterfaces(args[1:])...))
+	case "srem":
+		w.integer(m.SRem(ctx, args[0], toInterfaces(args[1:])...))
+	case "smembers":
+		w.array(m.SMembers(ctx, args[0]))
+	case "sismember":
+		w.bool(m.SIsMember(ctx, args[0], args[1]))
+	case "scard":
+		w.integer(m.SCard(ctx, args[0]))
+	case "zadd":
+		members := make([]cache.Z, 0, len(args)/2)
+		for i := 1; i+1 < len(args); i += 2 {
+			score, _ := strconv.ParseFloat(args[i], 64)
+			members = append(members, cache.Z{Score: score, Member: args[i+1]})
+		}
+		w.integer(m.ZAdd(ctx, args[0], members...))
+	case "zincrby":
+		incr, _ := strconv.ParseFloat(args[1], 64)
+		v, err := m.ZIncrBy(ctx, args[0], incr, args[2])
+		w.bulk(strconv.FormatFloat(v, 'f', -1, 64), err)
+	case "zrem":
+		w.integer(m.ZRem(ctx, args[0], toInterfaces(args[1:])...))
+	case "zscore":
+		v, err := m.ZScore(ctx, args[0], args[1])
+		w.bulk(strconv.FormatFloat(v, 'f', -1, 64), err)
+	case "zrank":
+		w.integer(m.ZRank(ctx, args[0], args[1]))
+	case "zrange", "zrevrange":
+		start, _ := strconv.ParseInt(args[1], 10, 64)
+		stop, _ := strconv.ParseInt(args[2], 10, 64)
+		var items []cache.Z
+		if cmd == "zrange" {
+			items, _ = m.ZRange(ctx, args[0], start, stop)
+		} else {
+			items, _ = m.ZRevRange(ctx, args[0], start, stop)
+		}
+		vals := make([]string, 0, len(items)*2)
+		for _, z := range items {
+			vals = append(vals, z.Member, strconv.FormatFloat(z.Score, 'f', -1, 64))
+		}
+		w.array(vals, nil)
+	case "zcard":
+		w.integer(m.ZCard(ctx, args[0]))
+	case "lpush":
+		w.integer(m.LPush(ctx, args[0], toInterfaces(args[1:])...))
+	case "rpush":
+		w.integer(m.RPush(ctx, args[0], toInterfaces(args[1:])...))
+	case "lpop":
+		w.bulk(m.LPop(ctx, args[0]))
+	case "rpop":
+		w.bulk(m.RPop(ctx, args[0]))
+	case "lrange":
+		start, _ := strconv.ParseInt(args[1], 10, 64)
+		stop, _ := strconv.ParseInt(args[2], 10, 64)
+		w.array(m.LRange(ctx, args[0], start, stop))
+	case "llen":
+		w.integer(m.LLen(ctx, args[0]))
+	default:
+		w.WriteString("-ERR unknown command '" + cmd + "'\r\n")
+	}
+}
+
+func toInterfaces(args []string) []interface{} {
+	vals := make([]interface{}, len(args))
+	for i := range args {
+		vals[i] = args[i]
+	}
+	return vals
+}
+
+func (w reply) fail(err error) {
+	w.WriteString("-" + err.Error() + "\r\n")
+}
+
+func (w reply) status(err error) {
+	switch err {
+	case nil:
+		w.WriteString("+OK\r\n")
+	case cache.Nil:
+		w.WriteString("$-1\r\n")
+	default:
+		w.fail(err)
+	}
+}
+
+func (w reply) integer(v int64, err error) {
+	switch err {
+	case nil:
+		w.WriteString(fmt.Sprintf(":%d\r\n", v))
+	case cache.Nil:
+		w.WriteString("$-1\r\n")
+	default:
+		w.fail(err)
+	}
+}
+
+func (w reply) bool(v bool, err error) {
+	if v {
+		w.integer(1, err)
+		return
+	}
+	w.integer(0, err)
+}
+
+func (w reply) bulk(v string, err error) {
+	switch err {
+	case nil:
+		w.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(v), v))
+	case cache.Nil:
+		w.WriteString("$-1\r\n")
+	default:
+		w.fail(err)
+	}
+}
+
+func (w reply) nullable(v interface{}) {
+	if v == nil {
+		w.WriteString("$-1\r\n")
+		return
+	}
+	w.bulk(v.(string), nil)
+}
+
+func (w reply) array(vals []string, err error) {
+	if err != nil {
+		w.fail(err)
+		return
+	}
+	w.WriteString(fmt.Sprintf("*%d\r\n", len(vals)))
+	for _, v := range vals {
+		w.bulk(v, nil)
+	}
+}
@@ -0,0 +1,49 @@
+package tiered
+
+import (
+	rds "github.com/go-redis/redis/v7"
+	"github.com/zhiyunliu/glue/contrib/redis"
+	"github.com/zhiyunliu/glue/log"
+)
+
+// Bus 跨实例的缓存失效通知
+type Bus interface {
+	//Publish 通知所有实例删除本地缓存中的key
+	Publish(key string) error
+	//Subscribe 接收失效通知;key为空表示通知通道已重连,需清空本地缓存
+	Subscribe(handler func(key string)) error
+}
+
+type redisBus struct {
+	client  *redis.Client
+	channel string
+}
+
+func newRedisBus(client *redis.Client, channel string) *redisBus {
+	return &redisBus{client: client, channel: channel}
+}
+
+func (b *redisBus) Publish(key string) error {
+	return b.client.Publish(b.channel, key).Err()
+}
+
+func (b *redisBus) Subscribe(handler func(key string)) error {
+	pubsub := b.client.Subscribe(b.channel)
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return err
+	}
+	go func() {
+		for msg := range pubsub.ChannelWithSubscriptions(100) {
+			switch v := msg.(type) {
+			case *rds.Subscription:
+				//首次订阅确认已由Receive消费,此处均为断线重连,期间可能丢失通知需清空本地缓存
+				log.Warnf("cache.tiered:重新订阅失效通知:%s", b.channel)
+				handler("")
+			case *rds.Message:
+				handler(v.Payload)
+			}
+		}
+	}()
+	return nil
+}
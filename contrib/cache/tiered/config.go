@@ -0,0 +1,32 @@
+package tiered
+
+import (
+	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/glue/contrib/cache/memory"
+)
+
+// LocalConfig 本地缓存配置
+type LocalConfig struct {
+	memory.Config
+	//TTL 本地缓存有效期(秒)
+	TTL int `json:"ttl"`
+}
+
+// Config 二级缓存配置
+type Config struct {
+	//Channel 跨实例失效通知使用的redis频道
+	Channel string      `json:"channel"`
+	Local   LocalConfig `json:"local"`
+}
+
+func getConfig(setting config.Config) (cfg *Config, err error) {
+	cfg = &Config{
+		Channel: DefaultChannel,
+		Local: LocalConfig{
+			Config: memory.Config{MaxEntries: memory.DefaultMaxEntries, Eviction: memory.EvictionLRU},
+			TTL:    DefaultLocalTTL,
+		},
+	}
+	err = setting.ScanTo(cfg)
+	return
+}
@@ -0,0 +1,10 @@
+package tiered
+
+const (
+	Proto = "tiered"
+
+	//DefaultChannel 默认的失效通知频道
+	DefaultChannel = "glue:cache:tiered:invalidate"
+	//DefaultLocalTTL 本地缓存默认有效期(秒),用于兜底丢失的失效通知
+	DefaultLocalTTL = 60
+)
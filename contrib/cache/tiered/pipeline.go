@@ -0,0 +1,41 @@
+package tiered
+
+import (
+	"github.com/zhiyunliu/glue/cache"
+)
+
+// keyRecorder 记录pipeline中会影响本地缓存的key,集合类操作不缓存到本地无需记录
+type keyRecorder struct {
+	cache.Pipeliner
+	keys []string
+}
+
+func (p *keyRecorder) Set(key string, val interface{}, expire int) {
+	p.keys = append(p.keys, key)
+	p.Pipeliner.Set(key, val, expire)
+}
+
+func (p *keyRecorder) Del(key string) {
+	p.keys = append(p.keys, key)
+	p.Pipeliner.Del(key)
+}
+
+func (p *keyRecorder) Expire(key string, expire int) {
+	p.keys = append(p.keys, key)
+	p.Pipeliner.Expire(key, expire)
+}
+
+func (p *keyRecorder) IncreaseBy(key string, value int64) {
+	p.keys = append(p.keys, key)
+	p.Pipeliner.IncreaseBy(key, value)
+}
+
+func (p *keyRecorder) HashSet(hk, key string, val string) {
+	p.keys = append(p.keys, hk)
+	p.Pipeliner.HashSet(hk, key, val)
+}
+
+func (p *keyRecorder) HashDel(hk, key string) {
+	p.keys = append(p.keys, hk)
+	p.Pipeliner.HashDel(hk, key)
+}
@@ -0,0 +1,375 @@
+package tiered
+
+import (
+	"context"
+
+	"github.com/zhiyunliu/glue/cache"
+	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/glue/contrib/cache/memory"
+	credis "github.com/zhiyunliu/glue/contrib/cache/redis"
+	"github.com/zhiyunliu/glue/contrib/redis"
+	"github.com/zhiyunliu/glue/log"
+)
+
+// Tiered 二级缓存,本地缓存在前,远端缓存(redis)在后;
+// 写操作先写远端,再删除本地数据并通过Bus通知其他实例
+type Tiered struct {
+	remote   cache.ICache
+	local    *memory.Memory
+	localTTL int
+	bus      Bus
+}
+
+// New 构建二级缓存,bus为nil时仅清理当前实例的本地缓存
+func New(remote cache.ICache, local *memory.Memory, localTTL int, bus Bus) (*Tiered, error) {
+	t := &Tiered{
+		remote:   remote,
+		local:    local,
+		localTTL: localTTL,
+		bus:      bus,
+	}
+	if bus != nil {
+		if err := bus.Subscribe(t.onInvalidate); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *Tiered) Name() string {
+	return Proto
+}
+
+// Get from key
+func (t *Tiered) Get(ctx context.Context, key string) (string, error) {
+	if v, err := t.local.Get(ctx, key); err == nil {
+		return v, nil
+	}
+	v, err := t.remote.Get(ctx, key)
+	if err != nil {
+		return v, err
+	}
+	t.local.Set(ctx, key, v, t.localTTL)
+	return v, nil
+}
+
+// Set value with key and expire time
+func (t *Tiered) Set(ctx context.Context, key string, val interface{}, expire int) error {
+	err := t.remote.Set(ctx, key, val, expire)
+	t.invalidate(key)
+	return err
+}
+
+// Del delete key
+func (t *Tiered) Del(ctx context.Context, key string) error {
+	err := t.remote.Del(ctx, key)
+	t.invalidate(key)
+	return err
+}
+
+// HashGet from key
+func (t *Tiered) HashGet(ctx context.Context, hk, key string) (string, error) {
+	if v, err := t.local.HashGet(ctx, hk, key); err == nil {
+		return v, nil
+	}
+	v, err := t.remote.HashGet(ctx, hk, key)
+	if err != nil {
+		return v, err
+	}
+	t.fillHash(ctx, hk, map[string]interface{}{key: v})
+	return v, nil
+}
+
+func (t *Tiered) HashSet(ctx context.Context, hk, key string, val string) (bool, error) {
+	v, err := t.remote.HashSet(ctx, hk, key, val)
+	t.invalidate(hk)
+	return v, err
+}
+
+// HashDel delete key in specify hashtable
+func (t *Tiered) HashDel(ctx context.Context, hk, key string) error {
+	err := t.remote.HashDel(ctx, hk, key)
+	t.invalidate(hk)
+	return err
+}
+
+// HashMGet 本地缓存缺少任一字段时整体从远端读取
+func (t *Tiered) HashMGet(ctx context.Context, hk string, key ...string) (map[string]interface{}, error) {
+	if vals, err := t.local.HashMGet(ctx, hk, key...); err == nil && len(vals) > 0 {
+		hit := true
+		for i := range key {
+			if vals[key[i]] == nil {
+				hit = false
+				break
+			}
+		}
+		if hit {
+			return vals, nil
+		}
+	}
+	vals, err := t.remote.HashMGet(ctx, hk, key...)
+	if err != nil {
+		return vals, err
+	}
+	fields := make(map[string]interface{}, len(vals))
+	for k, v := range vals {
+		if v != nil {
+			fields[k] = v
+		}
+	}
+	t.fillHash(ctx, hk, fields)
+	return vals, nil
+}
+
+func (t *Tiered) HashSetAll(ctx context.Context, hk string, val map[string]interface{}) (bool, error) {
+	v, err := t.remote.HashSetAll(ctx, hk, val)
+	t.invalidate(hk)
+	return v, err
+}
+
+// HashExists 本地缓存只保存部分字段,未命中时以远端为准
+func (t *Tiered) HashExists(ctx context.Context, hk, key string) (bool, error) {
+	if ok, err := t.local.HashExists(ctx, hk, key); err == nil && ok {
+		return true, nil
+	}
+	return t.remote.HashExists(ctx, hk, key)
+}
+
+// Increase
+func (t *Tiered) Increase(ctx context.Context, key string) (int64, error) {
+	v, err := t.remote.Increase(ctx, key)
+	t.invalidate(key)
+	return v, err
+}
+
+func (t *Tiered) Decrease(ctx context.Context, key string) (int64, error) {
+	v, err := t.remote.Decrease(ctx, key)
+	t.invalidate(key)
+	return v, err
+}
+
+// Set ttl
+func (t *Tiered) Expire(ctx context.Context, key string, expire int) error {
+	err := t.remote.Expire(ctx, key, expire)
+	t.invalidate(key)
+	return err
+}
+
+// Exists 本地未命中时以远端为准
+func (t *Tiered) Exists(ctx context.Context, key string) (bool, error) {
+	if ok, _ := t.local.Exists(ctx, key); ok {
+		return true, nil
+	}
+	return t.remote.Exists(ctx, key)
+}
+
+// IncreaseBy 按指定步长递增
+func (t *Tiered) IncreaseBy(ctx context.Context, key string, value int64) (int64, error) {
+	v, err := t.remote.IncreaseBy(ctx, key, value)
+	t.invalidate(key)
+	return v, err
+}
+
+// TTL 以远端为准
+func (t *Tiered) TTL(ctx context.Context, key string) (int, error) {
+	return t.remote.TTL(ctx, key)
+}
+
+// SetNX key不存在时写入
+func (t *Tiered) SetNX(ctx context.Context, key string, val interface{}, expire int) (bool, error) {
+	ok, err := t.remote.SetNX(ctx, key, val, expire)
+	if ok {
+		t.invalidate(key)
+	}
+	return ok, err
+}
+
+// MGet 本地未命中的key从远端批量读取并写入本地
+func (t *Tiered) MGet(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	result, err := t.local.MGet(ctx, keys...)
+	if err != nil {
+		result = make(map[string]interface{}, len(keys))
+	}
+	missing := make([]string, 0, len(keys))
+	for i := range keys {
+		if result[keys[i]] == nil {
+			missing = append(missing, keys[i])
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	vals, err := t.remote.MGet(ctx, missing...)
+	if err != nil {
+		return vals, err
+	}
+	for k, v := range vals {
+		result[k] = v
+		if v != nil {
+			t.local.Set(ctx, k, v, t.localTTL)
+		}
+	}
+	return result, nil
+}
+
+func (t *Tiered) MSet(ctx context.Context, vals map[string]interface{}) error {
+	err := t.remote.MSet(ctx, vals)
+	for k := range vals {
+		t.invalidate(k)
+	}
+	return err
+}
+
+// SAdd 集合、有序集合及列表不缓存到本地,直接操作远端
+func (t *Tiered) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return t.remote.SAdd(ctx, key, members...)
+}
+
+func (t *Tiered) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return t.remote.SRem(ctx, key, members...)
+}
+
+func (t *Tiered) SMembers(ctx context.Context, key string) ([]string, error) {
+	return t.remote.SMembers(ctx, key)
+}
+
+func (t *Tiered) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	return t.remote.SIsMember(ctx, key, member)
+}
+
+func (t *Tiered) SCard(ctx context.Context, key string) (int64, error) {
+	return t.remote.SCard(ctx, key)
+}
+
+func (t *Tiered) ZAdd(ctx context.Context, key string, members ...cache.Z) (int64, error) {
+	return t.remote.ZAdd(ctx, key, members...)
+}
+
+func (t *Tiered) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return t.remote.ZIncrBy(ctx, key, increment, member)
+}
+
+func (t *Tiered) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return t.remote.ZRem(ctx, key, members...)
+}
+
+func (t *Tiered) ZScore(ctx context.Context, key, member string) (float64, error) {
+	return t.remote.ZScore(ctx, key, member)
+}
+
+func (t *Tiered) ZRank(ctx context.Context, key, member string) (int64, error) {
+	return t.remote.ZRank(ctx, key, member)
+}
+
+func (t *Tiered) ZRange(ctx context.Context, key string, start, stop int64) ([]cache.Z, error) {
+	return t.remote.ZRange(ctx, key, start, stop)
+}
+
+func (t *Tiered) ZRevRange(ctx context.Context, key string, start, stop int64) ([]cache.Z, error) {
+	return t.remote.ZRevRange(ctx, key, start, stop)
+}
+
+func (t *Tiered) ZCard(ctx context.Context, key string) (int64, error) {
+	return t.remote.ZCard(ctx, key)
+}
+
+func (t *Tiered) LPush(ctx context.Context, key string, vals ...interface{}) (int64, error) {
+	return t.remote.LPush(ctx, key, vals...)
+}
+
+func (t *Tiered) RPush(ctx context.Context, key string, vals ...interface{}) (int64, error) {
+	return t.remote.RPush(ctx, key, vals...)
+}
+
+func (t *Tiered) LPop(ctx context.Context, key string) (string, error) {
+	return t.remote.LPop(ctx, key)
+}
+
+func (t *Tiered) RPop(ctx context.Context, key string) (string, error) {
+	return t.remote.RPop(ctx, key)
+}
+
+func (t *Tiered) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return t.remote.LRange(ctx, key, start, stop)
+}
+
+func (t *Tiered) LLen(ctx context.Context, key string) (int64, error) {
+	return t.remote.LLen(ctx, key)
+}
+
+// Pipeline 提交到远端后失效pipeline中涉及的key
+func (t *Tiered) Pipeline(ctx context.Context, fn func(pipe cache.Pipeliner) error) error {
+	var keys []string
+	err := t.remote.Pipeline(ctx, func(pipe cache.Pipeliner) error {
+		recorder := &keyRecorder{Pipeliner: pipe}
+		err := fn(recorder)
+		keys = recorder.keys
+		return err
+	})
+	for i := range keys {
+		t.invalidate(keys[i])
+	}
+	return err
+}
+
+// GetImpl 暴露远端缓存的原生对象
+func (t *Tiered) GetImpl() interface{} {
+	return t.remote.GetImpl()
+}
+
+// fillHash 将远端读取的hash字段写入本地,新建的hash设置本地有效期
+func (t *Tiered) fillHash(ctx context.Context, hk string, fields map[string]interface{}) {
+	if len(fields) == 0 {
+		return
+	}
+	exists, _ := t.local.Exists(ctx, hk)
+	if _, err := t.local.HashSetAll(ctx, hk, fields); err != nil {
+		t.local.Del(ctx, hk)
+		return
+	}
+	if !exists {
+		t.local.Expire(ctx, hk, t.localTTL)
+	}
+}
+
+func (t *Tiered) invalidate(key string) {
+	t.local.Del(context.Background(), key)
+	if t.bus == nil {
+		return
+	}
+	if err := t.bus.Publish(key); err != nil {
+		log.Errorf("cache.tiered:发布失效通知失败:%s,error:%+v", key, err)
+	}
+}
+
+func (t *Tiered) onInvalidate(key string) {
+	if key == "" {
+		t.local.Flush()
+		return
+	}
+	t.local.Del(context.Background(), key)
+}
+
+type tieredResolver struct {
+}
+
+func (s *tieredResolver) Name() string {
+	return Proto
+}
+
+func (s *tieredResolver) Resolve(setting config.Config, opts ...cache.Option) (cache.ICache, error) {
+	cfg, err := getConfig(setting)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := credis.NewByConfig(setting, opts...)
+	if err != nil {
+		return nil, err
+	}
+	client := remote.GetImpl().(*redis.Client)
+	return New(remote, memory.New(&cfg.Local.Config), cfg.Local.TTL, newRedisBus(client, cfg.Channel))
+}
+
+func init() {
+	cache.Register(&tieredResolver{})
+}
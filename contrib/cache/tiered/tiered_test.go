@@ -0,0 +1,150 @@
+package tiered
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zhiyunliu/glue/cache"
+	"github.com/zhiyunliu/glue/contrib/cache/memory"
+)
+
+// memBus 进程内模拟的失效通知
+type memBus struct {
+	handlers []func(key string)
+}
+
+func (b *memBus) Publish(key string) error {
+	for _, h := range b.handlers {
+		h(key)
+	}
+	return nil
+}
+
+func (b *memBus) Subscribe(handler func(key string)) error {
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
+
+func newTestTiered(t *testing.T, remote *memory.Memory, bus Bus) (*Tiered, *memory.Memory) {
+	local := memory.New(&memory.Config{})
+	c, err := New(remote, local, 60, bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, local
+}
+
+func TestTieredReadThrough(t *testing.T) {
+	ctx := context.Background()
+	remote := memory.New(&memory.Config{})
+	c, local := newTestTiered(t, remote, nil)
+
+	remote.Set(ctx, "k", "v1", 0)
+	if v, _ := c.Get(ctx, "k"); v != "v1" {
+		t.Fatalf("Get = %q, want v1", v)
+	}
+	if v, _ := local.Get(ctx, "k"); v != "v1" {
+		t.Fatal("Get should fill local cache")
+	}
+
+	//远端被直接修改时,本地缓存仍返回旧值直到失效
+	remote.Set(ctx, "k", "v2", 0)
+	if v, _ := c.Get(ctx, "k"); v != "v1" {
+		t.Fatalf("Get = %q, want local v1", v)
+	}
+	c.Set(ctx, "k", "v3", 0)
+	if v, _ := c.Get(ctx, "k"); v != "v3" {
+		t.Fatalf("Get after Set = %q, want v3", v)
+	}
+}
+
+func TestTieredHash(t *testing.T) {
+	ctx := context.Background()
+	remote := memory.New(&memory.Config{})
+	c, local := newTestTiered(t, remote, nil)
+
+	remote.HashSetAll(ctx, "h", map[string]interface{}{"a": "1", "b": "2"})
+	if v, _ := c.HashGet(ctx, "h", "a"); v != "1" {
+		t.Fatalf("HashGet = %q, want 1", v)
+	}
+	if ok, _ := local.HashExists(ctx, "h", "b"); ok {
+		t.Fatal("only requested field should be cached")
+	}
+	vals, _ := c.HashMGet(ctx, "h", "a", "b", "c")
+	if vals["a"] != "1" || vals["b"] != "2" || vals["c"] != nil {
+		t.Fatalf("HashMGet = %v", vals)
+	}
+	if v, _ := local.HashGet(ctx, "h", "b"); v != "2" {
+		t.Fatal("HashMGet should fill local cache")
+	}
+	c.HashSet(ctx, "h", "a", "9")
+	if ok, _ := local.Exists(ctx, "h"); ok {
+		t.Fatal("HashSet should invalidate local hash")
+	}
+	if v, _ := c.HashGet(ctx, "h", "a"); v != "9" {
+		t.Fatalf("HashGet after HashSet = %q, want 9", v)
+	}
+}
+
+func TestTieredCrossInstanceInvalidate(t *testing.T) {
+	ctx := context.Background()
+	remote := memory.New(&memory.Config{})
+	bus := &memBus{}
+	c1, _ := newTestTiered(t, remote, bus)
+	c2, local2 := newTestTiered(t, remote, bus)
+
+	c1.Set(ctx, "k", "v1", 0)
+	if v, _ := c2.Get(ctx, "k"); v != "v1" {
+		t.Fatalf("c2 Get = %q, want v1", v)
+	}
+	c1.Set(ctx, "k", "v2", 0)
+	if ok, _ := local2.Exists(ctx, "k"); ok {
+		t.Fatal("c2 local cache should be invalidated")
+	}
+	if v, _ := c2.Get(ctx, "k"); v != "v2" {
+		t.Fatalf("c2 Get = %q, want v2", v)
+	}
+	if v, _ := c1.Increase(ctx, "n"); v != 1 {
+		t.Fatalf("Increase = %d, want 1", v)
+	}
+
+	//重连通知清空本地缓存
+	bus.Publish("")
+	if local2.Len() != 0 {
+		t.Fatalf("local len = %d, want 0", local2.Len())
+	}
+}
+
+func TestTieredBatch(t *testing.T) {
+	ctx := context.Background()
+	remote := memory.New(&memory.Config{})
+	c, local := newTestTiered(t, remote, nil)
+
+	local.Set(ctx, "a", "stale", 0)
+	remote.MSet(ctx, map[string]interface{}{"b": "2"})
+	vals, _ := c.MGet(ctx, "a", "b", "c")
+	if vals["a"] != "stale" || vals["b"] != "2" || vals["c"] != nil {
+		t.Fatalf("MGet = %v", vals)
+	}
+	if v, _ := local.Get(ctx, "b"); v != "2" {
+		t.Fatal("MGet should fill local cache")
+	}
+
+	err := c.Pipeline(ctx, func(pipe cache.Pipeliner) error {
+		pipe.Set("a", "1", 0)
+		pipe.SAdd("s", "x")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := local.Exists(ctx, "a"); ok {
+		t.Fatal("Pipeline should invalidate local key")
+	}
+	if v, _ := c.Get(ctx, "a"); v != "1" {
+		t.Fatalf("Get after pipeline = %q, want 1", v)
+	}
+	if n, _ := c.SCard(ctx, "s"); n != 1 {
+		t.Fatalf("SCard = %d, want 1", n)
+	}
+}
@@ -0,0 +1,5 @@
+package memory
+
+const (
+	Proto = "memory"
+)
@@ -0,0 +1,247 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/zhiyunliu/glue/dlocker"
+	"github.com/zhiyunliu/golibs/xrandom"
+)
+
+type lockMode int
+
+const (
+	//modeWrite 互斥锁/写锁
+	modeWrite lockMode = iota
+	//modeRead 读锁
+	modeRead
+)
+
+// Lock 进程内锁
+type Lock struct {
+	store   *Memory
+	key     string
+	owner   string
+	mode    lockMode
+	opts    *dlocker.Options
+	token   atomic.Int64
+	renewer *dlocker.Renewer
+}
+
+func newLock(store *Memory, key string, mode lockMode, opts *dlocker.Options) *Lock {
+	owner := opts.Data
+	if owner == "" {
+		owner = xrandom.Str(16)
+	}
+	return &Lock{
+		store:   store,
+		key:     key,
+		owner:   owner,
+		mode:    mode,
+		opts:    opts,
+		renewer: dlocker.NewRenewer(),
+	}
+}
+
+// Acquire 加锁,单位：秒
+func (l *Lock) Acquire(expire int) (bool, error) {
+	return l.acquire(expire, false)
+}
+
+// AcquireCtx 阻塞等待直到获取锁或ctx结束
+func (l *Lock) AcquireCtx(ctx context.Context, expire int) (int64, error) {
+	err := dlocker.WaitAcquire(ctx, l.opts, func() (bool, error) {
+		return l.acquire(expire, true)
+	})
+	if err != nil {
+		if l.isFair() {
+			l.leaveQueue()
+		}
+		return 0, err
+	}
+	return l.Token(), nil
+}
+
+// Token 最近一次获取锁时的fencing token
+func (l *Lock) Token() int64 {
+	return l.token.Load()
+}
+
+// Release 释放锁,可重入锁在释放次数与获取次数一致时才真正释放
+func (l *Lock) Release() (bool, error) {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	st := l.store.state(l.key)
+	now := time.Now()
+
+	if l.mode == modeRead {
+		h := st.readers[l.owner]
+		if h == nil || !now.Before(h.expireAt) {
+			return false, nil
+		}
+		if h.holds--; h.holds > 0 {
+			return true, nil
+		}
+		delete(st.readers, l.owner)
+	} else {
+		if st.owner != l.owner || !st.writeHeld(now) {
+			return false, nil
+		}
+		if st.holds--; st.holds > 0 {
+			return true, nil
+		}
+		st.owner = ""
+	}
+	l.renewer.Stop()
+	return true, nil
+}
+
+// Renewal 续期,单位：秒
+func (l *Lock) Renewal(expire int) error {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	st := l.store.state(l.key)
+	now := time.Now()
+	expireAt := now.Add(time.Duration(expire) * time.Second)
+	if l.mode == modeRead {
+		h := st.readers[l.owner]
+		if h == nil || !now.Before(h.expireAt) {
+			return dlocker.ErrNotHeld
+		}
+		h.expireAt = expireAt
+		return nil
+	}
+	if st.owner != l.owner || !st.writeHeld(now) {
+		return dlocker.ErrNotHeld
+	}
+	st.expireAt = expireAt
+	return nil
+}
+
+// Holder 当前写锁持有者
+func (l *Lock) Holder() (string, error) {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	st := l.store.state(l.key)
+	if !st.writeHeld(time.Now()) {
+		return "", nil
+	}
+	return st.owner, nil
+}
+
+func (l *Lock) acquire(expire int, wait bool) (bool, error) {
+	if expire <= 0 {
+		return false, fmt.Errorf("expire 参数必须大于0")
+	}
+	l.store.mu.Lock()
+	st := l.store.state(l.key)
+	now := time.Now()
+	expireAt := now.Add(time.Duration(expire) * time.Second)
+	var ok bool
+	if l.mode == modeRead {
+		ok = l.acquireRead(st, now, expireAt)
+	} else {
+		ok = l.acquireWrite(st, now, expireAt, wait)
+	}
+	if ok {
+		l.token.Store(st.token)
+	}
+	l.store.mu.Unlock()
+
+	if ok && l.opts.AutoRenewal {
+		l.renewer.Start(expire, l.Renewal)
+	}
+	return ok, nil
+}
+
+func (l *Lock) acquireRead(st *lockState, now, expireAt time.Time) bool {
+	if st.writeHeld(now) && st.owner != l.owner {
+		return false
+	}
+	h := st.readers[l.owner]
+	if h == nil || !now.Before(h.expireAt) {
+		h = &holder{}
+		st.readers[l.owner] = h
+	}
+	h.holds++
+	h.expireAt = expireAt
+	return true
+}
+
+func (l *Lock) acquireWrite(st *lockState, now, expireAt time.Time, wait bool) bool {
+	if st.owner == l.owner && st.writeHeld(now) {
+		if l.opts.Reentrant {
+			st.holds++
+		}
+		st.expireAt = expireAt
+		return true
+	}
+	if l.isFair() && !l.checkQueue(st, now, wait) {
+		return false
+	}
+	if st.writeHeld(now) || st.readHeld(now) {
+		return false
+	}
+	st.owner = l.owner
+	st.holds = 1
+	st.expireAt = expireAt
+	st.token++
+	if l.isFair() {
+		l.removeWaiter(st)
+	}
+	return true
+}
+
+// checkQueue 清理超时的等待者,wait为true时排队;队列为空或当前持有者位于队首时返回true
+func (l *Lock) checkQueue(st *lockState, now time.Time, wait bool) bool {
+	queue := st.queue[:0]
+	var self *waiter
+	for _, w := range st.queue {
+		if now.After(w.deadline) {
+			continue
+		}
+		if w.owner == l.owner {
+			self = w
+		}
+		queue = append(queue, w)
+	}
+	if wait {
+		if self == nil {
+			self = &waiter{owner: l.owner}
+			queue = append(queue, self)
+		}
+		self.deadline = now.Add(l.queueTTL())
+	}
+	st.queue = queue
+	return len(queue) == 0 || queue[0].owner == l.owner
+}
+
+func (l *Lock) removeWaiter(st *lockState) {
+	for i, w := range st.queue {
+		if w.owner == l.owner {
+			st.queue = append(st.queue[:i], st.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (l *Lock) leaveQueue() {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	l.removeWaiter(l.store.state(l.key))
+}
+
+func (l *Lock) isFair() bool {
+	return l.opts.Fair && l.mode == modeWrite
+}
+
+// queueTTL 等待者在两次重试之间需保持排队位置,超过该时间未重试视为放弃
+func (l *Lock) queueTTL() time.Duration {
+	maxBackoff := l.opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = dlocker.DefaultMaxBackoff
+	}
+	return 3 * maxBackoff
+}
@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhiyunliu/glue/dlocker"
+)
+
+func TestLockFencingAndReentrant(t *testing.T) {
+	m := New()
+	a := m.Build("k", dlocker.WithData("a"), dlocker.WithReentrant())
+	b := m.Build("k", dlocker.WithData("b"))
+
+	if ok, _ := a.Acquire(10); !ok || a.Token() != 1 {
+		t.Fatalf("a.Acquire = %v, token = %d", ok, a.Token())
+	}
+	if ok, _ := a.Acquire(10); !ok || a.Token() != 1 {
+		t.Fatalf("reentrant Acquire = %v, token = %d", ok, a.Token())
+	}
+	if ok, _ := b.Acquire(10); ok {
+		t.Fatal("b should not acquire lock held by a")
+	}
+	a.Release()
+	if ok, _ := b.Acquire(10); ok {
+		t.Fatal("lock should be held until all reentrant holds are released")
+	}
+	a.Release()
+	if ok, _ := b.Acquire(10); !ok || b.Token() != 2 {
+		t.Fatalf("b.Acquire = %v, token = %d, want token 2", ok, b.Token())
+	}
+	if ok, _ := a.Release(); ok {
+		t.Fatal("a should not release lock held by b")
+	}
+}
+
+func TestLockExpire(t *testing.T) {
+	m := New()
+	a := m.Build("k", dlocker.WithData("a"))
+	b := m.Build("k", dlocker.WithData("b"))
+
+	a.Acquire(10)
+	m.locks["k"].expireAt = time.Now().Add(-time.Millisecond)
+	if ok, _ := b.Acquire(10); !ok {
+		t.Fatal("expired lock should be acquired")
+	}
+}
+
+func TestLockRenewalAndHolder(t *testing.T) {
+	m := New()
+	a := m.Build("k", dlocker.WithData("a"))
+	holder := a.(dlocker.HolderReader)
+
+	if err := a.Renewal(10); err != dlocker.ErrNotHeld {
+		t.Fatalf("Renewal before acquire = %v, want ErrNotHeld", err)
+	}
+	a.Acquire(10)
+	if err := a.Renewal(10); err != nil {
+		t.Fatalf("Renewal = %v", err)
+	}
+	if h, _ := holder.Holder(); h != "a" {
+		t.Fatalf("Holder = %q, want a", h)
+	}
+	m.locks["k"].expireAt = time.Now().Add(-time.Millisecond)
+	if h, _ := holder.Holder(); h != "" {
+		t.Fatalf("Holder of expired lock = %q, want empty", h)
+	}
+	if err := a.Renewal(10); err != dlocker.ErrNotHeld {
+		t.Fatalf("Renewal of expired lock = %v, want ErrNotHeld", err)
+	}
+}
+
+func TestLockAcquireCtx(t *testing.T) {
+	m := New()
+	opts := []dlocker.Option{dlocker.WithBackoff(time.Millisecond, 5*time.Millisecond)}
+	a := m.Build("k", opts...)
+	b := m.Build("k", opts...)
+
+	a.Acquire(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.AcquireCtx(ctx, 10); err != context.DeadlineExceeded {
+		t.Fatalf("AcquireCtx err = %v, want deadline exceeded", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		a.Release()
+	}()
+	token, err := b.AcquireCtx(context.Background(), 10)
+	if err != nil || token != 2 {
+		t.Fatalf("AcquireCtx = %d, %v, want 2", token, err)
+	}
+}
+
+func TestLockFair(t *testing.T) {
+	m := New()
+	backoff := dlocker.WithBackoff(time.Millisecond, 10*time.Millisecond)
+	holder := m.Build("k", dlocker.WithData("holder"), dlocker.WithFair(), backoff)
+	holder.Acquire(10)
+
+	var mu sync.Mutex
+	var order []string
+	wg := sync.WaitGroup{}
+	for _, owner := range []string{"w1", "w2", "w3"} {
+		locker := m.Build("k", dlocker.WithData(owner), dlocker.WithFair(), backoff)
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			if _, err := locker.AcquireCtx(context.Background(), 10); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, owner)
+			mu.Unlock()
+			time.Sleep(2 * time.Millisecond)
+			locker.Release()
+		}(owner)
+		//保证排队顺序
+		time.Sleep(5 * time.Millisecond)
+	}
+	//非阻塞获取不能插队
+	if ok, _ := m.Build("k", dlocker.WithData("other"), dlocker.WithFair()).Acquire(10); ok {
+		t.Fatal("non-blocking Acquire should not jump the queue")
+	}
+	holder.Release()
+	wg.Wait()
+	if len(order) != 3 || order[0] != "w1" || order[1] != "w2" || order[2] != "w3" {
+		t.Fatalf("acquire order = %v, want [w1 w2 w3]", order)
+	}
+}
+
+func TestRWLock(t *testing.T) {
+	m := New()
+	rw1 := m.BuildRW("k", dlocker.WithData("a"))
+	rw2 := m.BuildRW("k", dlocker.WithData("b"))
+
+	if ok, _ := rw1.RLocker().Acquire(10); !ok {
+		t.Fatal("read lock should be acquired")
+	}
+	if ok, _ := rw2.RLocker().Acquire(10); !ok {
+		t.Fatal("read locks should be shared")
+	}
+	if ok, _ := rw2.WLocker().Acquire(10); ok {
+		t.Fatal("write lock should wait for readers")
+	}
+	if ok, _ := m.Build("k").Acquire(10); ok {
+		t.Fatal("exclusive lock should conflict with readers")
+	}
+	rw1.RLocker().Release()
+	rw2.RLocker().Release()
+	if ok, _ := rw2.WLocker().Acquire(10); !ok {
+		t.Fatal("write lock should be acquired after readers release")
+	}
+	if ok, _ := rw1.RLocker().Acquire(10); ok {
+		t.Fatal("read lock should wait for writer")
+	}
+	if ok, _ := rw2.RLocker().Acquire(10); !ok {
+		t.Fatal("writer should be able to downgrade to read lock")
+	}
+}
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/glue/dlocker"
+)
+
+// Memory 进程内锁,适用于单实例部署及测试
+type Memory struct {
+	mu    sync.Mutex
+	locks map[string]*lockState
+}
+
+// New 构建进程内锁
+func New() *Memory {
+	return &Memory{locks: map[string]*lockState{}}
+}
+
+// Build 构建锁
+func (m *Memory) Build(key string, opts ...dlocker.Option) dlocker.DLocker {
+	return newLock(m, key, modeWrite, dlocker.NewOptions(opts...))
+}
+
+// BuildRW 构建读写锁,读锁与写锁使用相同的持有者标识;
+// 持有写锁时可获取读锁(降级),持有读锁时不能获取写锁
+func (m *Memory) BuildRW(key string, opts ...dlocker.Option) dlocker.RWLocker {
+	opt := dlocker.NewOptions(opts...)
+	write := newLock(m, key, modeWrite, opt)
+	readOpt := *opt
+	readOpt.Data = write.owner
+	return &rwLocker{
+		read:  newLock(m, key, modeRead, &readOpt),
+		write: write,
+	}
+}
+
+type rwLocker struct {
+	read  *Lock
+	write *Lock
+}
+
+func (l *rwLocker) RLocker() dlocker.DLocker {
+	return l.read
+}
+
+func (l *rwLocker) WLocker() dlocker.DLocker {
+	return l.write
+}
+
+type holder struct {
+	holds    int
+	expireAt time.Time
+}
+
+type waiter struct {
+	owner    string
+	deadline time.Time
+}
+
+// lockState 单个key的锁状态,fencing token在key的整个生命周期内单调递增
+type lockState struct {
+	owner    string
+	holds    int
+	expireAt time.Time
+	token    int64
+	readers  map[string]*holder
+	queue    []*waiter
+}
+
+func (s *lockState) writeHeld(now time.Time) bool {
+	return s.owner != "" && now.Before(s.expireAt)
+}
+
+func (s *lockState) readHeld(now time.Time) bool {
+	for owner, r := range s.readers {
+		if now.Before(r.expireAt) {
+			return true
+		}
+		delete(s.readers, owner)
+	}
+	return false
+}
+
+// state 获取key的锁状态,调用方需持有锁
+func (m *Memory) state(key string) *lockState {
+	st, ok := m.locks[key]
+	if !ok {
+		st = &lockState{readers: map[string]*holder{}}
+		m.locks[key] = st
+	}
+	return st
+}
+
+type memoryResolver struct {
+}
+
+func (s *memoryResolver) Name() string {
+	return Proto
+}
+
+func (s *memoryResolver) Resolve(configName string, setting config.Config) (dlocker.DLockerBuilder, error) {
+	return New(), nil
+}
+
+func init() {
+	dlocker.Register(&memoryResolver{})
+}
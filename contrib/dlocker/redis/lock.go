@@ -1,58 +1,48 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"strconv"
-	"strings"
 	"sync/atomic"
 	"time"
 
 	goredis "github.com/go-redis/redis/v7"
 	"github.com/zhiyunliu/glue/dlocker"
 	"github.com/zhiyunliu/golibs/xrandom"
-	"golang.org/x/sync/errgroup"
 )
 
 const (
-	lockCommand = `if redis.call("GET", KEYS[1]) == ARGV[1] then
-    redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
-    return "OK"
-else
-    return redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2])
-end`
-	delCommand = `if redis.call("GET", KEYS[1]) == ARGV[1] then
-    return redis.call("DEL", KEYS[1])
-else
-    return 0
-end`
-
-	leaseCommand = `if redis.call("GET", KEYS[1]) == ARGV[1] then
-    return redis.call("PEXPIRE", KEYS[1], ARGV[2])
-else
-    return 0
-end`
-
 	//randomLen = 16
 	// 默认超时时间，防止死锁
 	tolerance int = 500 // milliseconds
 )
 
+type lockMode int
+
+const (
+	//modeWrite 互斥锁/写锁
+	modeWrite lockMode = iota
+	//modeRead 读锁
+	modeRead
+)
+
 // A Lock is a redis lock.
 type Lock struct {
 	// redis客户端
 	client *Redis
-	// 锁key
-	key string
+	// 锁key及附属key
+	keys []string
 	// 锁value，防止锁被别人获取到
-	rndVal      string
-	opts        *dlocker.Options
-	state       atomic.Bool
-	releaseChan chan struct{}
-	group       errgroup.Group
+	rndVal  string
+	mode    lockMode
+	opts    *dlocker.Options
+	token   atomic.Int64
+	renewer *dlocker.Renewer
 }
 
 // NewLock returns a Lock.
-func newLock(client *Redis, key string, opts *dlocker.Options) *Lock {
+func newLock(client *Redis, key string, mode lockMode, opts *dlocker.Options) *Lock {
 	var rndval string
 	if opts.Data != "" {
 		rndval = opts.Data
@@ -60,12 +50,12 @@ func newLock(client *Redis, key string, opts *dlocker.Options) *Lock {
 		rndval = xrandom.Str(16)
 	}
 	return &Lock{
-		client:      client,
-		key:         key,
-		rndVal:      rndval,
-		opts:        opts,
-		releaseChan: make(chan struct{}, 1),
-		group:       errgroup.Group{},
+		client:  client,
+		keys:    lockKeys(key),
+		rndVal:  rndval,
+		mode:    mode,
+		opts:    opts,
+		renewer: dlocker.NewRenewer(),
 	}
 }
 
@@ -73,36 +63,70 @@ func newLock(client *Redis, key string, opts *dlocker.Options) *Lock {
 // 单位：秒
 // 加锁
 func (rl *Lock) Acquire(expire int) (bool, error) {
+	return rl.acquire(expire, false)
+}
+
+// AcquireCtx 阻塞等待直到获取锁或ctx结束
+// 公平锁在等待期间占据队列位置,ctx结束时主动退出队列
+func (rl *Lock) AcquireCtx(ctx context.Context, expire int) (int64, error) {
+	err := dlocker.WaitAcquire(ctx, rl.opts, func() (bool, error) {
+		return rl.acquire(expire, true)
+	})
+	if err != nil {
+		if rl.isFair() {
+			rl.client.Eval(leaveQueueCommand, rl.keys, []string{rl.rndVal})
+		}
+		return 0, err
+	}
+	return rl.Token(), nil
+}
+
+// Token 最近一次获取锁时的fencing token
+func (rl *Lock) Token() int64 {
+	return rl.token.Load()
+}
+
+func (rl *Lock) acquire(expire int, wait bool) (bool, error) {
 	if expire <= 0 {
 		return false, fmt.Errorf("expire 参数必须大于0")
 	}
 	// 获取过期时间
 	// 默认锁过期时间为500ms，防止死锁
-	resp, err := rl.client.Eval(lockCommand, []string{rl.key}, []string{
-		rl.rndVal, strconv.Itoa(expire*1000 + tolerance), //换算成毫秒
-	})
+	ttl := strconv.Itoa(expire*1000 + tolerance) //换算成毫秒
+	var resp interface{}
+	var err error
+	if rl.mode == modeRead {
+		resp, err = rl.client.Eval(readAcquireCommand, rl.keys, []string{rl.rndVal, ttl})
+	} else {
+		resp, err = rl.client.Eval(acquireCommand, rl.keys, []string{
+			rl.rndVal, ttl, boolArg(rl.opts.Reentrant), rl.fairArg(wait), strconv.FormatInt(rl.queueTTL(), 10),
+		})
+	}
 	if err == goredis.Nil {
 		return false, nil
 	} else if err != nil {
-		return false, fmt.Errorf("error on acquiring lock for %s, %s", rl.key, err.Error())
-	} else if resp == nil {
-		return false, nil
+		return false, fmt.Errorf("error on acquiring lock for %s, %s", rl.keys[0], err.Error())
 	}
 
-	reply, ok := resp.(string)
-	if ok && strings.EqualFold(reply, "OK") {
-		if rl.opts.AutoRenewal {
-			rl.autoRenewalCallback(expire)
-		}
-		return true, nil
+	token, ok := resp.(int64)
+	if !ok {
+		return false, nil
+	}
+	rl.token.Store(token)
+	if rl.opts.AutoRenewal {
+		rl.renewer.Start(expire, rl.Renewal)
 	}
-	return false, nil
+	return true, nil
 }
 
 // Release releases the lock.
-// 释放锁
+// 释放锁,可重入锁在释放次数与获取次数一致时才真正释放
 func (rl *Lock) Release() (bool, error) {
-	resp, err := rl.client.Eval(delCommand, []string{rl.key}, []string{rl.rndVal})
+	cmd := releaseCommand
+	if rl.mode == modeRead {
+		cmd = readReleaseCommand
+	}
+	resp, err := rl.client.Eval(cmd, rl.keys, []string{rl.rndVal})
 	if err != nil {
 		return false, err
 	}
@@ -112,30 +136,21 @@ func (rl *Lock) Release() (bool, error) {
 		return false, nil
 	}
 
-	succ := reply == 1
-	if succ && rl.opts.AutoRenewal {
-		old := rl.state.Load()
-		if !old {
-			return succ, nil
-		}
-		//确认没有变动
-		if !rl.state.CompareAndSwap(old, false) {
-			return succ, nil
-		}
-
-		select {
-		case rl.releaseChan <- struct{}{}:
-		default:
-		}
+	if reply == 1 {
+		rl.renewer.Stop()
 	}
 
-	return succ, nil
+	return reply > 0, nil
 }
 
 // 单位：秒
 // 续约
 func (rl *Lock) Renewal(expire int) error {
-	resp, err := rl.client.Eval(leaseCommand, []string{rl.key}, []string{
+	cmd := leaseCommand
+	if rl.mode == modeRead {
+		cmd = readLeaseCommand
+	}
+	resp, err := rl.client.Eval(cmd, rl.keys, []string{
 		rl.rndVal,
 		strconv.Itoa(expire*1000 + tolerance),
 	})
@@ -143,36 +158,53 @@ func (rl *Lock) Renewal(expire int) error {
 		return fmt.Errorf("expire %+v,err:%+v", resp, err)
 	}
 
-	_, ok := resp.(int64)
+	reply, ok := resp.(int64)
 	if !ok {
 		return fmt.Errorf("expire %+v", resp)
 	}
-
+	if reply == 0 {
+		return dlocker.ErrNotHeld
+	}
 	return nil
 }
 
-func (rl *Lock) autoRenewalCallback(expire int) error {
-	old := rl.state.Load()
-	//原值已经在锁定中
-	if old {
-		return nil
-	}
-
-	//确认没有变动
-	if !rl.state.CompareAndSwap(old, true) {
-		return nil
-	}
-	rl.group.Go(func() error {
-		ticker := time.NewTicker(time.Second * time.Duration(expire))
-		for {
-			select {
-			case <-ticker.C:
-				rl.Renewal(expire)
-			case <-rl.releaseChan:
-				rl.state.Store(false)
-				return nil
-			}
-		}
-	})
-	return nil
+// Holder 当前写锁持有者
+func (rl *Lock) Holder() (string, error) {
+	val, err := rl.client.Get(rl.keys[0])
+	if err == goredis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
+func (rl *Lock) isFair() bool {
+	return rl.opts.Fair && rl.mode == modeWrite
+}
+
+// fairArg 非阻塞获取只检查队列,不占据排队位置
+func (rl *Lock) fairArg(wait bool) string {
+	switch {
+	case !rl.isFair():
+		return "0"
+	case wait:
+		return "2"
+	default:
+		return "1"
+	}
+}
+
+// queueTTL 等待者在两次重试之间需保持排队位置,超过该时间未重试视为放弃
+func (rl *Lock) queueTTL() int64 {
+	maxBackoff := rl.opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = dlocker.DefaultMaxBackoff
+	}
+	return int64(3*maxBackoff/time.Millisecond) + int64(tolerance)
+}
+
+func boolArg(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
 }
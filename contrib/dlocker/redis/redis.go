@@ -18,12 +18,33 @@ func (r *Redis) GetImpl() interface{} {
 
 // Build 构建锁
 func (r *Redis) Build(key string, opts ...dlocker.Option) dlocker.DLocker {
-	opt := &dlocker.Options{}
-	for i := range opts {
-		opts[i](opt)
+	return newLock(r, key, modeWrite, dlocker.NewOptions(opts...))
+}
+
+// BuildRW 构建读写锁,读锁与写锁使用相同的持有者标识;
+// 持有写锁时可获取读锁(降级),持有读锁时不能获取写锁
+func (r *Redis) BuildRW(key string, opts ...dlocker.Option) dlocker.RWLocker {
+	opt := dlocker.NewOptions(opts...)
+	write := newLock(r, key, modeWrite, opt)
+	readOpt := *opt
+	readOpt.Data = write.rndVal
+	return &rwLocker{
+		read:  newLock(r, key, modeRead, &readOpt),
+		write: write,
 	}
+}
+
+type rwLocker struct {
+	read  *Lock
+	write *Lock
+}
 
-	return newLock(r, key, opt)
+func (l *rwLocker) RLocker() dlocker.DLocker {
+	return l.read
+}
+
+func (l *rwLocker) WLocker() dlocker.DLocker {
+	return l.write
 }
 
 // Eval 执行脚本
@@ -37,6 +58,11 @@ func (r *Redis) Eval(cmd string, keys []string, vals []string) (obj interface{},
 	return r.client.Eval(cmd, keys, args...).Result()
 }
 
+// Get 读取key的值
+func (r *Redis) Get(key string) (string, error) {
+	return r.client.Get(key).Result()
+}
+
 type redisResolver struct {
 }
 
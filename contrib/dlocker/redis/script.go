@@ -0,0 +1,127 @@
+package redis
+
+import "strings"
+
+// 锁相关的key:
+//
+//	KEYS[1] 锁key,值为持有者,与旧版本格式一致
+//	KEYS[2] fencing计数器,每次新获取锁时递增,不过期
+//	KEYS[3] 重入次数
+//	KEYS[4] 读锁持有者 hash(持有者->次数)
+//	KEYS[5] 公平锁等待队列 zset(持有者->排队序号)
+//	KEYS[6] 公平锁等待者存活时间 hash(持有者->截止时间毫秒)
+//	KEYS[7] 公平锁排队序号
+const (
+	//ARGV: 持有者,过期毫秒,是否可重入,公平模式(0:否 1:仅检查队列 2:排队),等待者存活毫秒
+	acquireCommand = `redis.replicate_commands()
+local owner, ttl = ARGV[1], ARGV[2]
+local v = redis.call("GET", KEYS[1])
+if v == owner then
+    if ARGV[3] == "1" then
+        redis.call("INCR", KEYS[3])
+    end
+    redis.call("PEXPIRE", KEYS[1], ttl)
+    redis.call("PEXPIRE", KEYS[3], ttl)
+    return tonumber(redis.call("GET", KEYS[2]) or "0")
+end
+if ARGV[4] ~= "0" then
+    local t = redis.call("TIME")
+    local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+    for _, w in ipairs(redis.call("ZRANGE", KEYS[5], 0, -1)) do
+        local deadline = redis.call("HGET", KEYS[6], w)
+        if not deadline or tonumber(deadline) < now then
+            redis.call("ZREM", KEYS[5], w)
+            redis.call("HDEL", KEYS[6], w)
+        end
+    end
+    if ARGV[4] == "2" then
+        if not redis.call("ZSCORE", KEYS[5], owner) then
+            redis.call("ZADD", KEYS[5], redis.call("INCR", KEYS[7]), owner)
+        end
+        redis.call("HSET", KEYS[6], owner, now + tonumber(ARGV[5]))
+        redis.call("PEXPIRE", KEYS[5], ARGV[5] * 2)
+        redis.call("PEXPIRE", KEYS[6], ARGV[5] * 2)
+        redis.call("PEXPIRE", KEYS[7], ARGV[5] * 2)
+    end
+    local head = redis.call("ZRANGE", KEYS[5], 0, 0)[1]
+    if head and head ~= owner then
+        return false
+    end
+end
+if v or redis.call("HLEN", KEYS[4]) > 0 then
+    return false
+end
+redis.call("SET", KEYS[1], owner, "PX", ttl)
+redis.call("SET", KEYS[3], 1, "PX", ttl)
+if ARGV[4] ~= "0" then
+    redis.call("ZREM", KEYS[5], owner)
+    redis.call("HDEL", KEYS[6], owner)
+end
+return redis.call("INCR", KEYS[2])`
+
+	//ARGV: 持有者; 返回 0:未持有 1:已释放 2:重入次数减一仍持有
+	releaseCommand = `if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+    return 0
+end
+if tonumber(redis.call("DECR", KEYS[3])) > 0 then
+    return 2
+end
+redis.call("DEL", KEYS[1], KEYS[3])
+return 1`
+
+	//ARGV: 持有者,过期毫秒
+	leaseCommand = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+    redis.call("PEXPIRE", KEYS[3], ARGV[2])
+    return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+else
+    return 0
+end`
+
+	//ARGV: 持有者
+	leaveQueueCommand = `redis.call("ZREM", KEYS[5], ARGV[1])
+return redis.call("HDEL", KEYS[6], ARGV[1])`
+
+	//ARGV: 持有者,过期毫秒; 写锁被其他持有者占用时失败,返回当前fencing token
+	readAcquireCommand = `local v = redis.call("GET", KEYS[1])
+if v and v ~= ARGV[1] then
+    return false
+end
+redis.call("HINCRBY", KEYS[4], ARGV[1], 1)
+redis.call("PEXPIRE", KEYS[4], ARGV[2])
+return tonumber(redis.call("GET", KEYS[2]) or "0")`
+
+	//ARGV: 持有者
+	readReleaseCommand = `local c = redis.call("HINCRBY", KEYS[4], ARGV[1], -1)
+if c > 0 then
+    return 2
+end
+redis.call("HDEL", KEYS[4], ARGV[1])
+if c < 0 then
+    return 0
+end
+return 1`
+
+	//ARGV: 持有者,过期毫秒; 读锁整体续期
+	readLeaseCommand = `if redis.call("HEXISTS", KEYS[4], ARGV[1]) == 1 then
+    return redis.call("PEXPIRE", KEYS[4], ARGV[2])
+else
+    return 0
+end`
+)
+
+// lockKeys 生成脚本使用的key,附属key与锁key使用相同的hash tag,保证集群模式下位于同一slot
+func lockKeys(key string) []string {
+	prefix := key
+	if !strings.Contains(key, "{") {
+		prefix = "{" + key + "}"
+	}
+	return []string{
+		key,
+		prefix + ":fencing",
+		prefix + ":holds",
+		prefix + ":readers",
+		prefix + ":queue",
+		prefix + ":alive",
+		prefix + ":seq",
+	}
+}
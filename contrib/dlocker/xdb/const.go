@@ -0,0 +1,11 @@
+package xdb
+
+const (
+	Proto = "xdb"
+
+	//DefaultTable 默认的锁表
+	DefaultTable = "glue_dlocker"
+
+	// 默认超时时间，防止死锁
+	tolerance int64 = 500 // milliseconds
+)
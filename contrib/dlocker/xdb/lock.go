@@ -0,0 +1,194 @@
+package xdb
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/zhiyunliu/glue/dlocker"
+	"github.com/zhiyunliu/glue/xdb"
+	"github.com/zhiyunliu/golibs/xrandom"
+)
+
+type lockMode int
+
+const (
+	//modeWrite 互斥锁/写锁
+	modeWrite lockMode = iota
+	//modeRead 读锁
+	modeRead
+)
+
+// Lock 数据库锁
+type Lock struct {
+	store   *XDB
+	key     string
+	owner   string
+	mode    lockMode
+	opts    *dlocker.Options
+	token   atomic.Int64
+	renewer *dlocker.Renewer
+	//readHolds 当前实例持有的读锁数,读锁在表中只记录总数
+	readHolds atomic.Int64
+}
+
+func newLock(store *XDB, key string, mode lockMode, opts *dlocker.Options) *Lock {
+	owner := opts.Data
+	if owner == "" {
+		owner = xrandom.Str(16)
+	}
+	return &Lock{
+		store:   store,
+		key:     key,
+		owner:   owner,
+		mode:    mode,
+		opts:    opts,
+		renewer: dlocker.NewRenewer(),
+	}
+}
+
+// Acquire 加锁,单位：秒
+func (l *Lock) Acquire(expire int) (bool, error) {
+	if expire <= 0 {
+		return false, fmt.Errorf("expire 参数必须大于0")
+	}
+	if err := l.store.ensureRow(l.key, l.owner); err != nil {
+		return false, err
+	}
+	var ok bool
+	var err error
+	if l.mode == modeRead {
+		ok, err = l.acquireRead(expire)
+	} else {
+		ok, err = l.acquireWrite(expire)
+	}
+	if err != nil || !ok {
+		return false, err
+	}
+	token, err := l.store.token(l.key)
+	if err != nil {
+		return false, err
+	}
+	l.token.Store(token)
+	if l.opts.AutoRenewal {
+		l.renewer.Start(expire, l.Renewal)
+	}
+	return true, nil
+}
+
+// AcquireCtx 阻塞等待直到获取锁或ctx结束
+func (l *Lock) AcquireCtx(ctx context.Context, expire int) (int64, error) {
+	err := dlocker.WaitAcquire(ctx, l.opts, func() (bool, error) {
+		return l.Acquire(expire)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return l.Token(), nil
+}
+
+// Token 最近一次获取锁时的fencing token
+func (l *Lock) Token() int64 {
+	return l.token.Load()
+}
+
+// Release 释放锁,可重入锁在释放次数与获取次数一致时才真正释放
+func (l *Lock) Release() (bool, error) {
+	if l.mode == modeRead {
+		return l.releaseRead()
+	}
+	args := l.args(0)
+	n, err := l.store.exec(l.store.stmts.releaseHold, args)
+	if err != nil || n > 0 {
+		return n > 0, err
+	}
+	n, err = l.store.exec(l.store.stmts.release, args)
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		l.renewer.Stop()
+	}
+	return n > 0, nil
+}
+
+// Renewal 续期,单位：秒
+func (l *Lock) Renewal(expire int) error {
+	sql := l.store.stmts.renewal
+	if l.mode == modeRead {
+		sql = l.store.stmts.readRenewal
+	}
+	if l.mode == modeRead && l.readHolds.Load() <= 0 {
+		return dlocker.ErrNotHeld
+	}
+	n, err := l.store.exec(sql, l.args(expire))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return dlocker.ErrNotHeld
+	}
+	return nil
+}
+
+// Holder 当前写锁持有者
+func (l *Lock) Holder() (string, error) {
+	val, err := l.store.db.Scalar(xdb.WithReadPrimary(context.Background()), l.store.stmts.holder, map[string]any{
+		"key": l.key,
+		"now": time.Now().UnixMilli(),
+	})
+	if err != nil || val == nil {
+		return "", err
+	}
+	return toString(val), nil
+}
+
+func (l *Lock) acquireWrite(expire int) (bool, error) {
+	args := l.args(expire)
+	args["inc"] = 0
+	if l.opts.Reentrant {
+		args["inc"] = 1
+	}
+	n, err := l.store.exec(l.store.stmts.reentry, args)
+	if err != nil || n > 0 {
+		return n > 0, err
+	}
+	n, err = l.store.exec(l.store.stmts.acquire, args)
+	return n > 0, err
+}
+
+func (l *Lock) acquireRead(expire int) (bool, error) {
+	n, err := l.store.exec(l.store.stmts.readAcquire, l.args(expire))
+	if err != nil || n == 0 {
+		return false, err
+	}
+	l.readHolds.Add(1)
+	return true, nil
+}
+
+func (l *Lock) releaseRead() (bool, error) {
+	if l.readHolds.Add(-1) < 0 {
+		l.readHolds.Add(1)
+		return false, nil
+	}
+	n, err := l.store.exec(l.store.stmts.readRelease, l.args(0))
+	if err != nil {
+		return false, err
+	}
+	if l.readHolds.Load() == 0 {
+		l.renewer.Stop()
+	}
+	return n > 0, nil
+}
+
+// args 语句参数,过期时间额外加上容差防止续期不及时
+func (l *Lock) args(expire int) map[string]any {
+	now := time.Now().UnixMilli()
+	return map[string]any{
+		"key":       l.key,
+		"owner":     l.owner,
+		"now":       now,
+		"expire_at": now + int64(expire)*1000 + tolerance,
+	}
+}
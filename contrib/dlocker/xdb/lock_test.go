@@ -0,0 +1,154 @@
+package xdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
+	_ "github.com/zhiyunliu/glue/contrib/xdb/sqlite"
+	"github.com/zhiyunliu/glue/dlocker"
+)
+
+func newTestStore(t *testing.T) *XDB {
+	setting := contribxdb.NewConfig("dlocker_test")
+	setting.Cfg.Conn = filepath.Join(t.TempDir(), "dlocker.db")
+	dbobj, err := contribxdb.NewDB("sqlite", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dbobj.Close() })
+	store, err := New(dbobj, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//锁表已存在时可重复初始化
+	if _, err = New(dbobj, ""); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestLockFencingAndReentrant(t *testing.T) {
+	store := newTestStore(t)
+	a := store.Build("glue:cron:locker:a", dlocker.WithData("a"), dlocker.WithReentrant())
+	b := store.Build("glue:cron:locker:a", dlocker.WithData("b"))
+
+	if ok, err := a.Acquire(10); !ok || err != nil || a.Token() != 1 {
+		t.Fatalf("a.Acquire = %v, %v, token = %d", ok, err, a.Token())
+	}
+	if ok, _ := a.Acquire(10); !ok || a.Token() != 1 {
+		t.Fatalf("reentrant Acquire = %v, token = %d", ok, a.Token())
+	}
+	if ok, _ := b.Acquire(10); ok {
+		t.Fatal("b should not acquire lock held by a")
+	}
+	if ok, _ := a.Release(); !ok {
+		t.Fatal("a.Release should succeed")
+	}
+	if ok, _ := b.Acquire(10); ok {
+		t.Fatal("lock should be held until all reentrant holds are released")
+	}
+	a.Release()
+	if ok, _ := b.Acquire(10); !ok || b.Token() != 2 {
+		t.Fatalf("b.Acquire = %v, token = %d, want token 2", ok, b.Token())
+	}
+	if ok, _ := a.Release(); ok {
+		t.Fatal("a should not release lock held by b")
+	}
+	if err := b.Renewal(10); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockExpire(t *testing.T) {
+	store := newTestStore(t)
+	a := store.Build("k", dlocker.WithData("a"))
+	b := store.Build("k", dlocker.WithData("b"))
+
+	a.Acquire(10)
+	if _, err := store.exec("update glue_dlocker set expire_at=1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := b.Acquire(10); !ok || b.Token() != 2 {
+		t.Fatalf("expired lock should be acquired, token = %d", b.Token())
+	}
+}
+
+func TestLockRenewalAndHolder(t *testing.T) {
+	store := newTestStore(t)
+	a := store.Build("k", dlocker.WithData("a"))
+	holder := a.(dlocker.HolderReader)
+
+	if err := a.Renewal(10); err != dlocker.ErrNotHeld {
+		t.Fatalf("Renewal before acquire = %v, want ErrNotHeld", err)
+	}
+	if h, err := holder.Holder(); err != nil || h != "" {
+		t.Fatalf("Holder = %q, %v, want empty", h, err)
+	}
+	a.Acquire(10)
+	if err := a.Renewal(10); err != nil {
+		t.Fatalf("Renewal = %v", err)
+	}
+	if h, _ := holder.Holder(); h != "a" {
+		t.Fatalf("Holder = %q, want a", h)
+	}
+	a.Release()
+	if err := a.Renewal(10); err != dlocker.ErrNotHeld {
+		t.Fatalf("Renewal after release = %v, want ErrNotHeld", err)
+	}
+}
+
+func TestLockAcquireCtx(t *testing.T) {
+	store := newTestStore(t)
+	opts := []dlocker.Option{dlocker.WithBackoff(time.Millisecond, 5*time.Millisecond)}
+	a := store.Build("k", opts...)
+	b := store.Build("k", opts...)
+
+	a.Acquire(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.AcquireCtx(ctx, 10); err != context.DeadlineExceeded {
+		t.Fatalf("AcquireCtx err = %v, want deadline exceeded", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		a.Release()
+	}()
+	token, err := b.AcquireCtx(context.Background(), 10)
+	if err != nil || token != 2 {
+		t.Fatalf("AcquireCtx = %d, %v, want 2", token, err)
+	}
+}
+
+func TestRWLock(t *testing.T) {
+	store := newTestStore(t)
+	rw1 := store.BuildRW("k", dlocker.WithData("a"))
+	rw2 := store.BuildRW("k", dlocker.WithData("b"))
+
+	if ok, err := rw1.RLocker().Acquire(10); !ok || err != nil {
+		t.Fatalf("read lock should be acquired, err = %v", err)
+	}
+	if ok, _ := rw2.RLocker().Acquire(10); !ok {
+		t.Fatal("read locks should be shared")
+	}
+	if ok, _ := rw2.WLocker().Acquire(10); ok {
+		t.Fatal("write lock should wait for readers")
+	}
+	rw1.RLocker().Release()
+	if ok, _ := rw2.WLocker().Acquire(10); ok {
+		t.Fatal("write lock should wait for remaining reader")
+	}
+	rw2.RLocker().Release()
+	if ok, _ := rw2.WLocker().Acquire(10); !ok {
+		t.Fatal("write lock should be acquired after readers release")
+	}
+	if ok, _ := rw1.RLocker().Acquire(10); ok {
+		t.Fatal("read lock should wait for writer")
+	}
+	if ok, _ := rw2.RLocker().Acquire(10); !ok {
+		t.Fatal("writer should be able to downgrade to read lock")
+	}
+}
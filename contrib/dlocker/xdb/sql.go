@@ -0,0 +1,71 @@
+package xdb
+
+import "fmt"
+
+// 锁表每个key一行,数据不删除以保证fencing token单调递增:
+//
+//	expire_at       写锁过期时间(毫秒),小于当前时间表示未持有,owner保留最后持有者
+//	readers         读锁持有数,read_expire_at为所有读锁中最晚的过期时间
+//	revision        每次更新递增,保证mysql等按实际变更计算影响行数的数据库也能返回正确的行数
+//
+// 字段类型及语句使用各数据库通用的写法
+const (
+	createTableSQL = "create table %s(lock_key varchar(255) not null primary key,owner varchar(255) not null,holds int not null,token numeric(19) not null,expire_at numeric(19) not null,readers int not null,read_expire_at numeric(19) not null,revision numeric(19) not null)"
+
+	tokenSQL = "select token from %s where lock_key=@{key}"
+
+	holderSQL = "select owner from %s where lock_key=@{key} and expire_at>=@{now}"
+
+	insertSQL = "insert into %s(lock_key,owner,holds,token,expire_at,readers,read_expire_at,revision) values(@{key},@{owner},0,0,0,0,0,0)"
+
+	reentrySQL = "update %s set holds=holds+@{inc},expire_at=@{expire_at},revision=revision+1 where lock_key=@{key} and owner=@{owner} and expire_at>=@{now}"
+
+	acquireSQL = "update %s set owner=@{owner},holds=1,token=token+1,expire_at=@{expire_at},revision=revision+1 where lock_key=@{key} and expire_at<@{now} and read_expire_at<@{now}"
+
+	releaseHoldSQL = "update %s set holds=holds-1,revision=revision+1 where lock_key=@{key} and owner=@{owner} and expire_at>=@{now} and holds>1"
+
+	releaseSQL = "update %s set holds=0,expire_at=0,revision=revision+1 where lock_key=@{key} and owner=@{owner} and expire_at>=@{now}"
+
+	renewalSQL = "update %s set expire_at=@{expire_at},revision=revision+1 where lock_key=@{key} and owner=@{owner} and expire_at>=@{now}"
+
+	//mysql按赋值顺序使用已更新的值,readers的计算不能依赖read_expire_at的新值
+	readAcquireSQL = "update %s set readers=(case when read_expire_at<@{now} then 1 else readers+1 end),read_expire_at=(case when read_expire_at<@{expire_at} then @{expire_at} else read_expire_at end),revision=revision+1 where lock_key=@{key} and (expire_at<@{now} or owner=@{owner})"
+
+	//read_expire_at需在readers之前赋值,使用readers的原值判断
+	readReleaseSQL = "update %s set read_expire_at=(case when readers<=1 then 0 else read_expire_at end),readers=readers-1,revision=revision+1 where lock_key=@{key} and readers>0 and read_expire_at>=@{now}"
+
+	readRenewalSQL = "update %s set read_expire_at=(case when read_expire_at<@{expire_at} then @{expire_at} else read_expire_at end),revision=revision+1 where lock_key=@{key} and readers>0 and read_expire_at>=@{now}"
+)
+
+// statements 按表名生成的语句
+type statements struct {
+	createTable string
+	token       string
+	holder      string
+	insert      string
+	reentry     string
+	acquire     string
+	releaseHold string
+	release     string
+	renewal     string
+	readAcquire string
+	readRelease string
+	readRenewal string
+}
+
+func newStatements(table string) *statements {
+	return &statements{
+		createTable: fmt.Sprintf(createTableSQL, table),
+		token:       fmt.Sprintf(tokenSQL, table),
+		holder:      fmt.Sprintf(holderSQL, table),
+		insert:      fmt.Sprintf(insertSQL, table),
+		reentry:     fmt.Sprintf(reentrySQL, table),
+		acquire:     fmt.Sprintf(acquireSQL, table),
+		releaseHold: fmt.Sprintf(releaseHoldSQL, table),
+		release:     fmt.Sprintf(releaseSQL, table),
+		renewal:     fmt.Sprintf(renewalSQL, table),
+		readAcquire: fmt.Sprintf(readAcquireSQL, table),
+		readRelease: fmt.Sprintf(readReleaseSQL, table),
+		readRenewal: fmt.Sprintf(readRenewalSQL, table),
+	}
+}
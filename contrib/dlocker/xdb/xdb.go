@@ -0,0 +1,145 @@
+package xdb
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/glue/dlocker"
+	"github.com/zhiyunliu/glue/standard"
+	"github.com/zhiyunliu/glue/xdb"
+	"github.com/zhiyunliu/golibs/xreflect"
+)
+
+// XDB 基于数据库表的锁,每个key对应锁表中的一行;
+// 过期时间使用应用服务器时间计算,各实例需保持时钟同步;不支持公平锁
+type XDB struct {
+	db    xdb.IDB
+	table string
+	stmts *statements
+	rows  sync.Map
+}
+
+// New 构建数据库锁,锁表不存在时自动创建
+func New(db xdb.IDB, table string) (*XDB, error) {
+	if table == "" {
+		table = DefaultTable
+	}
+	x := &XDB{db: db, table: table, stmts: newStatements(table)}
+	if err := x.ensureTable(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Build 构建锁
+func (x *XDB) Build(key string, opts ...dlocker.Option) dlocker.DLocker {
+	return newLock(x, key, modeWrite, dlocker.NewOptions(opts...))
+}
+
+// BuildRW 构建读写锁,读锁与写锁使用相同的持有者标识;
+// 持有写锁时可获取读锁(降级),持有读锁时不能获取写锁
+func (x *XDB) BuildRW(key string, opts ...dlocker.Option) dlocker.RWLocker {
+	opt := dlocker.NewOptions(opts...)
+	write := newLock(x, key, modeWrite, opt)
+	readOpt := *opt
+	readOpt.Data = write.owner
+	return &rwLocker{
+		read:  newLock(x, key, modeRead, &readOpt),
+		write: write,
+	}
+}
+
+type rwLocker struct {
+	read  *Lock
+	write *Lock
+}
+
+func (l *rwLocker) RLocker() dlocker.DLocker {
+	return l.read
+}
+
+func (l *rwLocker) WLocker() dlocker.DLocker {
+	return l.write
+}
+
+// ensureTable 锁表不存在时创建
+func (x *XDB) ensureTable() error {
+	if err := xdb.EnsureTable(context.Background(), x.db, x.table, x.stmts.createTable); err != nil {
+		return fmt.Errorf("dlocker: 创建锁表出错:%w", err)
+	}
+	return nil
+}
+
+// ensureRow key对应的行不存在时插入,并发插入时主键冲突视为成功
+func (x *XDB) ensureRow(key, owner string) error {
+	if _, ok := x.rows.Load(key); ok {
+		return nil
+	}
+	exists, err := x.exists(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err = x.db.Exec(context.Background(), x.stmts.insert, map[string]any{"key": key, "owner": owner})
+		if err != nil {
+			if exists, _ = x.exists(key); !exists {
+				return err
+			}
+		}
+	}
+	x.rows.Store(key, true)
+	return nil
+}
+
+func (x *XDB) exists(key string) (bool, error) {
+	val, err := x.db.Scalar(xdb.WithReadPrimary(context.Background()), x.stmts.token, map[string]any{"key": key})
+	return val != nil, err
+}
+
+func (x *XDB) token(key string) (int64, error) {
+	val, err := x.db.Scalar(xdb.WithReadPrimary(context.Background()), x.stmts.token, map[string]any{"key": key})
+	if err != nil {
+		return 0, err
+	}
+	return xdb.ToInt64(val)
+}
+
+// toString 字符串字段在部分驱动中以sql.NullString等Valuer形式返回
+func toString(val interface{}) string {
+	if v, ok := val.(driver.Valuer); ok {
+		val, _ = v.Value()
+	}
+	if val == nil {
+		return ""
+	}
+	return xreflect.GetString(val)
+}
+
+// exec 执行更新并返回影响行数
+func (x *XDB) exec(sql string, args map[string]any) (int64, error) {
+	r, err := x.db.Exec(context.Background(), sql, args)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+type xdbResolver struct {
+}
+
+func (s *xdbResolver) Name() string {
+	return Proto
+}
+
+// Resolve configName为dbs下的数据库配置名称,可通过 xdb.<configName>.table 指定锁表
+func (s *xdbResolver) Resolve(configName string, setting config.Config) (dlocker.DLockerBuilder, error) {
+	db := standard.GetInstance(xdb.DbTypeNode).(xdb.StandardDB).GetDB(configName)
+	return New(db, setting.Value("table").String())
+}
+
+func init() {
+	dlocker.Register(&xdbResolver{})
+}
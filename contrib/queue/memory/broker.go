@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	brokerLock sync.Mutex
+	brokers    = map[string]*Broker{}
+)
+
+// GetBroker 获取进程内共享的broker,同名的生产者与消费者通过同一个broker交换消息
+func GetBroker(name string) *Broker {
+	brokerLock.Lock()
+	defer brokerLock.Unlock()
+	b, ok := brokers[name]
+	if !ok {
+		b = NewBroker()
+		brokers[name] = b
+	}
+	return b
+}
+
+// Broker 进程内消息代理;
+// 消息投递后进入处理中状态,Ack后删除,Nack后立即重新投递,超过可见时间未完成的消息重新投递,重新投递时重试次数加1;
+// 消息id在重新投递时保持不变,每次投递使用新的tag确认消息
+type Broker struct {
+	mu     sync.Mutex
+	seq    int64
+	queues map[string]*memQueue
+}
+
+type envelope struct {
+	id       int64
+	tag      int64
+	data     string
+	retry    int64
+	deadline time.Time
+}
+
+type memQueue struct {
+	ready []*envelope
+	//处理中的消息,key为投递的tag
+	inflight map[int64]*envelope
+	//有新消息时关闭并替换,唤醒所有等待者
+	signal chan struct{}
+}
+
+// NewBroker 构建独立的broker
+func NewBroker() *Broker {
+	return &Broker{queues: map[string]*memQueue{}}
+}
+
+// Push 写入消息
+func (b *Broker) Push(queue string, data string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	b.enqueue(b.queue(queue), &envelope{id: b.seq, tag: b.seq, data: data})
+}
+
+// DelayPush 延迟delay后写入消息
+func (b *Broker) DelayPush(queue string, data string, delay time.Duration) {
+	if delay <= 0 {
+		b.Push(queue, data)
+		return
+	}
+	time.AfterFunc(delay, func() {
+		b.Push(queue, data)
+	})
+}
+
+// Len 等待投递的消息数
+func (b *Broker) Len(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q := b.queue(queue)
+	b.reclaim(q, time.Now())
+	return len(q.ready)
+}
+
+// Inflight 处理中的消息数
+func (b *Broker) Inflight(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queue(queue).inflight)
+}
+
+// receive 取出一条消息并标记为处理中,没有消息时等待,closeCh或unconsumeCh关闭时返回nil
+func (b *Broker) receive(queue string, visibility time.Duration, closeCh, unconsumeCh <-chan struct{}) *envelope {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closeCh:
+			return nil
+		case <-unconsumeCh:
+			return nil
+		default:
+		}
+		b.mu.Lock()
+		q := b.queue(queue)
+		now := time.Now()
+		b.reclaim(q, now)
+		if len(q.ready) > 0 {
+			env := q.ready[0]
+			q.ready[0] = nil
+			q.ready = q.ready[1:]
+			env.deadline = now.Add(visibility)
+			q.inflight[env.tag] = env
+			b.mu.Unlock()
+			return env
+		}
+		signal := q.signal
+		b.mu.Unlock()
+
+		//等待新消息,定时检查处理超时的消息
+		select {
+		case <-signal:
+		case <-ticker.C:
+		case <-closeCh:
+			return nil
+		case <-unconsumeCh:
+			return nil
+		}
+	}
+}
+
+// ack 消息处理完成
+func (b *Broker) ack(queue string, tag int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.queue(queue).inflight, tag)
+}
+
+// nack 消息处理失败,立即重新投递
+func (b *Broker) nack(queue string, tag int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q := b.queue(queue)
+	env, ok := q.inflight[tag]
+	if !ok {
+		return
+	}
+	delete(q.inflight, tag)
+	b.redeliver(q, env)
+}
+
+// reclaim 超过可见时间的消息重新投递
+func (b *Broker) reclaim(q *memQueue, now time.Time) {
+	for tag, env := range q.inflight {
+		if now.After(env.deadline) {
+			delete(q.inflight, tag)
+			b.redeliver(q, env)
+		}
+	}
+}
+
+// redeliver 保留消息id,使用新的tag重新投递,原投递的Ack/Nack不再生效
+func (b *Broker) redeliver(q *memQueue, env *envelope) {
+	b.seq++
+	b.enqueue(q, &envelope{id: env.id, tag: b.seq, data: env.data, retry: env.retry + 1})
+}
+
+func (b *Broker) enqueue(q *memQueue, env *envelope) {
+	q.ready = append(q.ready, env)
+	close(q.signal)
+	q.signal = make(chan struct{})
+}
+
+func (b *Broker) queue(name string) *memQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memQueue{
+			inflight: map[int64]*envelope{},
+			signal:   make(chan struct{}),
+		}
+		b.queues[name] = q
+	}
+	return q
+}
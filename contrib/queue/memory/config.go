@@ -0,0 +1,23 @@
+package memory
+
+import (
+	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/golibs/xnet"
+)
+
+type ConsumerOptions struct {
+	VisibilityTimeout int `json:"visibility_timeout" yaml:"visibility_timeout"` //消息处理超时时间 秒
+}
+
+// getBroker 根据addr(memory://name)获取broker,未配置时使用默认broker
+func getBroker(setting config.Config) (*Broker, error) {
+	addr := setting.Value("addr").String()
+	if addr == "" {
+		return GetBroker(DefaultBroker), nil
+	}
+	_, name, err := xnet.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	return GetBroker(name), nil
+}
@@ -0,0 +1,9 @@
+package memory
+
+const (
+	Proto = "memory"
+	//DefaultBroker 未配置addr时使用的broker名称
+	DefaultBroker = "default"
+	//DefaultVisibilityTimeout 消息处理超时时间(秒),超时未完成的消息重新投递
+	DefaultVisibilityTimeout = 30
+)
@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map"
+	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/glue/log"
+	"github.com/zhiyunliu/glue/queue"
+)
+
+var _ queue.IMessageIdMQC = (*Consumer)(nil)
+
+// Consumer 进程内消息消费者
+type Consumer struct {
+	configName        string
+	VisibilityTimeout time.Duration
+	broker            *Broker
+	queues            cmap.ConcurrentMap
+	closeCh           chan struct{}
+	once              sync.Once
+	lock              sync.Mutex
+	started           bool
+	wg                sync.WaitGroup
+	config            config.Config
+}
+
+type QueueItem struct {
+	QueueName         string
+	Concurrency       int
+	VisibilityTimeout time.Duration
+
+	unconsumeChan chan struct{}
+	callback      queue.ConsumeCallback
+}
+
+// NewConsumer 创建新的Consumer
+func NewConsumer(configName string, config config.Config) (consumer *Consumer, err error) {
+	consumer = &Consumer{}
+	consumer.configName = configName
+	consumer.config = config
+	consumer.closeCh = make(chan struct{})
+	consumer.queues = cmap.New()
+	return
+}
+
+// Connect 获取broker
+func (consumer *Consumer) Connect() (err error) {
+	consumer.broker, err = getBroker(consumer.config)
+	if err != nil {
+		return
+	}
+	copts := &ConsumerOptions{}
+	if err = consumer.config.ScanTo(copts); err != nil {
+		return
+	}
+	consumer.VisibilityTimeout = DefaultVisibilityTimeout * time.Second
+	if copts.VisibilityTimeout > 0 {
+		consumer.VisibilityTimeout = time.Duration(copts.VisibilityTimeout) * time.Second
+	}
+	return
+}
+
+// Consume 注册消费信息,已启动时立即开始消费
+func (consumer *Consumer) Consume(task queue.TaskInfo, callback queue.ConsumeCallback) (err error) {
+	queueName := task.GetQueue()
+	if strings.EqualFold(queueName, "") {
+		return fmt.Errorf("队列名字不能为空")
+	}
+	if callback == nil {
+		return fmt.Errorf("queue:%s,回调函数不能为nil", queueName)
+	}
+	item := &QueueItem{
+		QueueName:         queueName,
+		Concurrency:       task.GetConcurrency(),
+		VisibilityTimeout: time.Duration(task.GetVisibilityTimeout()) * time.Second,
+		unconsumeChan:     make(chan struct{}),
+		callback:          callback,
+	}
+	if item.Concurrency <= 0 {
+		item.Concurrency = queue.DefaultMaxQueueLen
+	}
+
+	consumer.lock.Lock()
+	defer consumer.lock.Unlock()
+	if !consumer.queues.SetIfAbsent(queueName, item) {
+		return
+	}
+	if consumer.started {
+		consumer.doReceive(item)
+	}
+	return
+}
+
+// StableMessageId 重新投递的消息保留原消息id
+func (consumer *Consumer) StableMessageId() bool {
+	return true
+}
+
+// Unconsume 取消注册消费,处理中的消息完成后退出
+func (consumer *Consumer) Unconsume(queue string) {
+	consumer.lock.Lock()
+	defer consumer.lock.Unlock()
+	if item, ok := consumer.queues.Get(queue); ok {
+		close(item.(*QueueItem).unconsumeChan)
+	}
+	consumer.queues.Remove(queue)
+}
+
+// Start 启动
+func (consumer *Consumer) Start() error {
+	consumer.lock.Lock()
+	defer consumer.lock.Unlock()
+	if consumer.broker == nil {
+		return fmt.Errorf("mqc.memory:%s 未连接", consumer.configName)
+	}
+	if consumer.started {
+		return nil
+	}
+	consumer.started = true
+	for item := range consumer.queues.IterBuffered() {
+		consumer.doReceive(item.Val.(*QueueItem))
+	}
+	return nil
+}
+
+// Close 关闭并等待处理中的消息完成
+func (consumer *Consumer) Close() error {
+	consumer.once.Do(func() {
+		close(consumer.closeCh)
+	})
+	consumer.wg.Wait()
+	return nil
+}
+
+func (consumer *Consumer) doReceive(item *QueueItem) {
+	visibility := item.VisibilityTimeout
+	if visibility <= 0 {
+		visibility = consumer.VisibilityTimeout
+	}
+	consumer.wg.Add(item.Concurrency)
+	for i := 0; i < item.Concurrency; i++ {
+		go consumer.work(item, visibility)
+	}
+}
+
+func (consumer *Consumer) work(item *QueueItem, visibility time.Duration) {
+	defer consumer.wg.Done()
+	for {
+		env := consumer.broker.receive(item.QueueName, visibility, consumer.closeCh, item.unconsumeChan)
+		if env == nil {
+			return
+		}
+		consumer.handle(item, env)
+	}
+}
+
+// handle 回调未Nack时确认消息,否则重新投递;超过最大重试次数的消息丢弃,
+// 失败消息的重试及死信由mqc任务的retry、deadletter配置处理
+func (consumer *Consumer) handle(item *QueueItem, env *envelope) {
+	if env.retry >= queue.MaxRetrtCount {
+		log.Warnf("mqc.memory.discard.queue:%s,retry:%d,msg:%s", item.QueueName, env.retry, env.data)
+		consumer.broker.ack(item.QueueName, env.tag)
+		return
+	}
+	msg := &memoryMessage{env: env}
+	defer func() {
+		if obj := recover(); obj != nil {
+			log.Errorf("mqc.memory.queue:%s,msg:%s,panic:%+v", item.QueueName, env.data, obj)
+			consumer.broker.nack(item.QueueName, env.tag)
+		}
+	}()
+	item.callback(msg)
+	if msg.err != nil {
+		consumer.broker.nack(item.QueueName, env.tag)
+		return
+	}
+	consumer.broker.ack(item.QueueName, env.tag)
+}
+
+type consumeResolver struct {
+}
+
+func (s *consumeResolver) Name() string {
+	return Proto
+}
+
+func (s *consumeResolver) Resolve(configName string, setting config.Config) (queue.IMQC, error) {
+	return NewConsumer(configName, setting)
+}
+func init() {
+	queue.RegisterConsumer(&consumeResolver{})
+}
@@ -0,0 +1,222 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zhiyunliu/glue/config"
+	_ "github.com/zhiyunliu/glue/encoding/binding"
+	"github.com/zhiyunliu/glue/metadata"
+	"github.com/zhiyunliu/glue/queue"
+)
+
+type testTask struct {
+	name       string
+	visibility int
+}
+
+func (t testTask) GetQueue() string           { return t.name }
+func (t testTask) GetConcurrency() int        { return 2 }
+func (t testTask) GetVisibilityTimeout() int  { return t.visibility }
+func (t testTask) GetBufferSize() int         { return 0 }
+func (t testTask) GetMeta() metadata.Metadata { return nil }
+
+// newTestQueue 按queues.mq的配置构建生产者与消费者,每个测试使用独立的broker
+func newTestQueue(t *testing.T, extra string) (queue.IMQP, queue.IMQC, *Broker) {
+	name := fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+	cfg := config.New(config.WithSource(config.NewStrSource(
+		`{"queues":{"mq":{"proto":"memory","addr":"memory://` + name + `"` + extra + `}}}`)))
+	if err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	setting := cfg.Get(queue.TypeNode).Get("mq")
+	mqp, err := queue.NewMQP(Proto, setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mqc, err := queue.NewMQC(Proto, "mq", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mqc.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { mqc.Close() })
+	return mqp, mqc, GetBroker(name)
+}
+
+func receive(t *testing.T, ch <-chan queue.IMQCMessage, timeout time.Duration) queue.IMQCMessage {
+	t.Helper()
+	select {
+	case m := <-ch:
+		return m
+	case <-time.After(timeout):
+		t.Fatal("message not received before timeout")
+		return nil
+	}
+}
+
+func TestPushAndConsume(t *testing.T) {
+	mqp, mqc, broker := newTestQueue(t, "")
+	received := make(chan queue.IMQCMessage, 1)
+	mqc.Consume(testTask{name: "q1"}, func(m queue.IMQCMessage) {
+		received <- m
+	})
+	if err := mqc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	mqp.Push("q1", queue.NewMsg(map[string]int{"id": 1}, queue.WithHeader("k", "v")))
+
+	m := receive(t, received, time.Second)
+	if m.RetryCount() != 0 || m.GetMessage().Header()["k"] != "v" || string(m.GetMessage().Body()) != `{"id":1}` {
+		t.Fatalf("message = %s, retry = %d", m.Original(), m.RetryCount())
+	}
+	time.Sleep(10 * time.Millisecond)
+	if broker.Len("q1") != 0 || broker.Inflight("q1") != 0 {
+		t.Fatalf("acked message should be removed, len = %d, inflight = %d", broker.Len("q1"), broker.Inflight("q1"))
+	}
+}
+
+func TestNackAndDiscard(t *testing.T) {
+	mqp, mqc, broker := newTestQueue(t, "")
+	received := make(chan queue.IMQCMessage, 10)
+	mqc.Consume(testTask{name: "q1"}, func(m queue.IMQCMessage) {
+		received <- m
+		m.Nack(errors.New("failed"))
+	})
+	mqc.Start()
+	mqp.Push("q1", queue.NewMsg("{}"))
+
+	for i := int64(0); i < queue.MaxRetrtCount; i++ {
+		if m := receive(t, received, time.Second); m.RetryCount() != i {
+			t.Fatalf("RetryCount = %d, want %d", m.RetryCount(), i)
+		}
+	}
+	select {
+	case <-received:
+		t.Fatal("message should not be delivered after max retries")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if broker.Len("q1") != 0 || broker.Inflight("q1") != 0 {
+		t.Fatalf("len = %d, inflight = %d", broker.Len("q1"), broker.Inflight("q1"))
+	}
+}
+
+func TestVisibilityTimeout(t *testing.T) {
+	mqp, mqc, _ := newTestQueue(t, "")
+	received := make(chan queue.IMQCMessage, 2)
+	release := make(chan struct{})
+	mqc.Consume(testTask{name: "q1", visibility: 1}, func(m queue.IMQCMessage) {
+		received <- m
+		if m.RetryCount() == 0 {
+			<-release
+		}
+	})
+	mqc.Start()
+	defer close(release)
+	mqp.Push("q1", queue.NewMsg("{}"))
+
+	first := receive(t, received, time.Second)
+	second := receive(t, received, 3*time.Second)
+	if first.RetryCount() != 0 || second.RetryCount() != 1 {
+		t.Fatalf("RetryCount = %d,%d, want 0,1", first.RetryCount(), second.RetryCount())
+	}
+	//重新投递的消息id不变
+	if first.MessageId() != second.MessageId() {
+		t.Fatalf("MessageId = %s,%s, want equal", first.MessageId(), second.MessageId())
+	}
+}
+
+func TestDelayPush(t *testing.T) {
+	mqp, mqc, _ := newTestQueue(t, "")
+	received := make(chan queue.IMQCMessage, 1)
+	mqc.Consume(testTask{name: "q1"}, func(m queue.IMQCMessage) {
+		received <- m
+	})
+	mqc.Start()
+	start := time.Now()
+	mqp.DelayPush("q1", queue.NewMsg("{}"), 1)
+	receive(t, received, 2*time.Second)
+	if time.Since(start) < time.Second {
+		t.Fatalf("delayed message received after %v", time.Since(start))
+	}
+}
+
+func TestUnconsume(t *testing.T) {
+	mqp, mqc, _ := newTestQueue(t, "")
+	received := make(chan queue.IMQCMessage, 1)
+	mqc.Consume(testTask{name: "q1"}, func(m queue.IMQCMessage) {
+		received <- m
+	})
+	mqc.Start()
+	mqc.Unconsume("q1")
+	mqp.Push("q1", queue.NewMsg("{}"))
+	select {
+	case <-received:
+		t.Fatal("unconsumed queue should not receive messages")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	//重新订阅后继续消费
+	mqc.Consume(testTask{name: "q1"}, func(m queue.IMQCMessage) {
+		received <- m
+	})
+	receive(t, received, time.Second)
+}
+
+type testBatchTask struct {
+	testTask
+	size int
+	wait time.Duration
+}
+
+func (t testBatchTask) GetBatchSize() int           { return t.size }
+func (t testBatchTask) GetBatchWait() time.Duration { return t.wait }
+
+func TestConsumeBatch(t *testing.T) {
+	mqp, mqc, broker := newTestQueue(t, "")
+	batches := make(chan []queue.IMQCMessage, 4)
+	err := mqc.Consume(queue.NewBatchConsumer(testBatchTask{testTask: testTask{name: "q1"}, size: 3, wait: 100 * time.Millisecond}, func(msgs []queue.IMQCMessage) {
+		//第二条消息单独Nack,重新投递后在后续批次中处理
+		for _, m := range msgs {
+			if string(m.GetMessage().Body()) == "2" && m.RetryCount() == 0 {
+				m.Nack(errors.New("failed"))
+			}
+		}
+		batches <- msgs
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mqc.Start()
+	for i := 1; i <= 4; i++ {
+		mqp.Push("q1", queue.NewMsg(fmt.Sprint(i)))
+	}
+
+	//凑满3条
+	select {
+	case msgs := <-batches:
+		if len(msgs) != 3 {
+			t.Fatalf("first batch size = %d, want 3", len(msgs))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("batch not received before timeout")
+	}
+	//剩余1条及重新投递的1条等待超时后处理
+	received := 0
+	deadline := time.After(2 * time.Second)
+	for received < 2 {
+		select {
+		case msgs := <-batches:
+			received += len(msgs)
+		case <-deadline:
+			t.Fatalf("received %d messages after first batch, want 2", received)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if broker.Len("q1") != 0 || broker.Inflight("q1") != 0 {
+		t.Fatalf("len = %d, inflight = %d", broker.Len("q1"), broker.Inflight("q1"))
+	}
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/zhiyunliu/glue/queue"
+	"github.com/zhiyunliu/golibs/bytesconv"
+	"github.com/zhiyunliu/golibs/xtypes"
+)
+
+// memoryMessage 进程内消息
+type memoryMessage struct {
+	env    *envelope
+	objMsg queue.Message
+	err    error
+}
+
+func (m *memoryMessage) RetryCount() int64 {
+	return m.env.retry
+}
+
+// MessageId 消息id,重新投递时不变
+func (m *memoryMessage) MessageId() string {
+	return strconv.FormatInt(m.env.id, 10)
+}
+
+// Ack 确定消息
+func (m *memoryMessage) Ack() error {
+	m.err = nil
+	return nil
+}
+
+// Nack 取消消息,回调结束后重新投递
+func (m *memoryMessage) Nack(err error) error {
+	m.err = err
+	return nil
+}
+
+// original message
+func (m *memoryMessage) Original() string {
+	return m.env.data
+}
+
+// GetMessage 获取消息
+func (m *memoryMessage) GetMessage() queue.Message {
+	if m.objMsg == nil {
+		m.objMsg = newMsgBody(m.env.data)
+	}
+	return m.objMsg
+}
+
+//{"user_id":123}
+//{"header":{},"body":{"user_id":123}}
+
+func newMsgBody(msg string) queue.Message {
+	msgBytes := bytesconv.StringToBytes(msg)
+	if !json.Valid(msgBytes) {
+		panic(fmt.Errorf("msg data is invalid json format.:%s", msg))
+	}
+	//BodyBytes需要复制,Unmarshal会复用其空间写入body,不能改写信封中的原始消息
+	msgItem := &queue.MsgItem{
+		HeaderMap: make(xtypes.SMap),
+		BodyBytes: []byte(msg),
+	}
+	json.Unmarshal(msgBytes, msgItem)
+	return msgItem
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/glue/queue"
+	"github.com/zhiyunliu/golibs/bytesconv"
+)
+
+// Producer 进程内消息生产者
+type Producer struct {
+	broker *Broker
+}
+
+// NewProducer 根据配置获取broker,配置中的addr为 memory://name
+func NewProducer(setting config.Config, opts ...queue.Option) (m *Producer, err error) {
+	m = &Producer{}
+	m.broker, err = getBroker(setting)
+	return
+}
+
+// Push 向队列尾部写入消息
+func (c *Producer) Push(key string, msg queue.Message) error {
+	bytes, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	c.broker.Push(key, bytesconv.BytesToString(bytes))
+	return nil
+}
+
+// DelayPush 延迟delaySeconds秒后写入消息
+func (c *Producer) DelayPush(key string, msg queue.Message, delaySeconds int64) error {
+	bytes, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	c.broker.DelayPush(key, bytesconv.BytesToString(bytes), time.Duration(delaySeconds)*time.Second)
+	return nil
+}
+
+// Count 等待投递的消息数
+func (c *Producer) Count(key string) (int64, error) {
+	return int64(c.broker.Len(key)), nil
+}
+
+// Close broker在进程内共享,不释放
+func (c *Producer) Close() error {
+	return nil
+}
+
+type producerResolver struct {
+}
+
+func (s *producerResolver) Name() string {
+	return Proto
+}
+func (s *producerResolver) Resolve(setting config.Config, opts ...queue.Option) (queue.IMQP, error) {
+	return NewProducer(setting, opts...)
+}
+func init() {
+	queue.RegisterProducer(&producerResolver{})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/zhiyunliu/glue/log"
 	"github.com/zhiyunliu/glue/metadata"
 	"github.com/zhiyunliu/glue/queue"
+	"github.com/zhiyunliu/glue/secret"
 	"github.com/zhiyunliu/golibs/xnet"
 	"golang.org/x/sync/errgroup"
 )
@@ -112,7 +113,12 @@ func (c *rabbitClient) Connect() (err error) {
 	rabbitCfg.Properties.SetClientConnectionName(c.options.ConnName)
 
 	if c.conn == nil || c.conn.IsClosed() {
-		c.conn, err = amqp.DialConfig(c.options.Addr, rabbitCfg)
+		//每次连接时重新解析密钥引用,凭据轮换后重连使用新的凭据
+		var addr string
+		if addr, err = secret.Resolve(c.ctx, c.options.Addr); err != nil {
+			return err
+		}
+		c.conn, err = amqp.DialConfig(addr, rabbitCfg)
 		if err != nil {
 			return fmt.Errorf("dial: %s", err)
 		}
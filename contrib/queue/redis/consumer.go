@@ -139,19 +139,8 @@ func (consumer *Consumer) work(item *QueueItem) {
 		select {
 		case msg := <-item.msgChan:
 			rdsMsg := &redisMessage{message: msg}
+			//失败重试及死信由mqc服务按任务的重试策略统一处理
 			item.callback(rdsMsg)
-			// if rdsMsg.err != nil {
-			// 	//超过最大次数
-			// 	if rdsMsg.RetryCount() >= queue.MaxRetrtCount {
-			// 		consumer.writeToDeadLetter(item.QueueName, msg)
-			// 		continue
-			// 	}
-			// 	obj := rdsMsg.PlusRetryCount()
-			// 	go func (){
-			// 		time.Sleep(time.Second)
-			// 		consumer.client.RPush(item.QueueName, obj)
-			// 	}()
-			// }
 		case <-consumer.closeCh:
 			return
 		case <-item.unconsumeChan:
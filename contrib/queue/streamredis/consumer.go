@@ -18,6 +18,8 @@ import (
 	cmap "github.com/orcaman/concurrent-map"
 )
 
+var _ queue.IMessageIdMQC = (*Consumer)(nil)
+
 // Consumer Consumer
 type Consumer struct {
 	configName       string
@@ -178,6 +180,11 @@ func (consumer *Consumer) Consume(task queue.TaskInfo, callback queue.ConsumeCal
 	return
 }
 
+// StableMessageId 超时未确认的消息重新投递时保留stream中的消息id
+func (consumer *Consumer) StableMessageId() bool {
+	return true
+}
+
 // UnConsume 取消注册消费
 func (consumer *Consumer) Unconsume(queue string) {
 	consumer.queues.Remove(queue)
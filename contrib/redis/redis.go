@@ -62,6 +62,9 @@ func newRedis(configName string, opts *Options, mapCfg map[string]any) (r *Clien
 		configName: configName,
 	}
 	r.opts = opts
+	if err = resolveSecrets(opts); err != nil {
+		return
+	}
 
 	ropts := &redis.UniversalOptions{
 		Addrs:        r.opts.Addrs,
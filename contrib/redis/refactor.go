@@ -1,3 +1,18 @@
 package redis
 
+import (
+	"context"
+
+	"github.com/zhiyunliu/glue/secret"
+)
+
 var Refactor func(configName string, orgopts *Options) (opts *Options, err error)
+
+// resolveSecrets 解析地址、用户名、密码中的密钥引用(env://,secret:file://,vault://,encrypt:// 等)
+func resolveSecrets(opts *Options) (err error) {
+	values := []*string{&opts.Username, &opts.Password}
+	for i := range opts.Addrs {
+		values = append(values, &opts.Addrs[i])
+	}
+	return secret.ResolveAll(context.Background(), values...)
+}
@@ -29,6 +29,7 @@ type processor struct {
 	slots        [60]cmap.ConcurrentMap //time slots
 	engine       *alloter.Engine
 	onceLock     sync.Once
+	leader       *dlocker.LazyElection
 }
 
 // NewProcessor 创建processor
@@ -43,6 +44,7 @@ func newProcessor(ctx sctx.Context, engine *alloter.Engine) (p *processor, err e
 		reqs:         cmap.New(),
 		engine:       engine,
 	}
+	p.leader = dlocker.NewLazyElection(dlocker.LeaderKey("cron"), nil, nil, nil)
 
 	for i := range p.slots {
 		p.slots[i] = cmap.New()
@@ -98,6 +100,9 @@ func (s *processor) Add(jobs ...*xcron.Job) (err error) {
 		if err := s.checkMonopoly(t); err != nil {
 			return err
 		}
+		if err := s.checkLeader(t); err != nil {
+			return err
+		}
 		req, err := newRequest(t)
 		if err != nil {
 			return fmt.Errorf("构建cron失败:cron=%s,service=%s,error:%v", t.Cron, t.Service, err)
@@ -123,10 +128,22 @@ func (s *processor) Close() error {
 	s.onceLock.Do(func() {
 		close(s.closeChan)
 		s.closeMonopolyJobs()
+		s.leader.Stop()
 	})
 	return nil
 }
 
+// checkLeader 存在leader_only任务时参与leader竞选,同一processor只竞选一次
+func (s *processor) checkLeader(j *xcron.Job) error {
+	if !j.IsLeaderOnly() {
+		return nil
+	}
+	if err := s.leader.Start(s.ctx); err != nil {
+		return fmt.Errorf("cron任务包含leader_only时需要提供dlocker的配置:%v", err)
+	}
+	return nil
+}
+
 func (s *processor) checkMonopoly(j *xcron.Job) (err error) {
 	if !j.IsMonopoly() {
 		return nil
@@ -225,6 +242,11 @@ func (s *processor) handle(req *Request) {
 		return
 	}
 
+	//非leader节点不执行leader_only任务
+	if req.job.IsLeaderOnly() && !s.leader.IsLeader() {
+		return
+	}
+
 	mjob, hasMonopoly, err := req.Monopoly(s.monopolyJobs)
 	if err != nil {
 		logger.Errorf("cron.handle.monopoly:%s,service:%s, error:%+v", req.job.Cron, req.job.Service, err)
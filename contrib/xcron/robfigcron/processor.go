@@ -2,6 +2,7 @@ package robfigcron
 
 import (
 	sctx "context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -29,6 +30,7 @@ type processor struct {
 	immediatelyJobs *xlist.List
 	cronStdEngine   *cron.Cron
 	cronSecEngine   *cron.Cron
+	leader          *dlocker.LazyElection
 }
 
 type procJob struct {
@@ -49,6 +51,7 @@ func newProcessor(ctx sctx.Context, engine *alloter.Engine) (p *processor, err e
 		cronSecEngine:   cron.New(cron.WithSeconds()),
 		immediatelyJobs: xlist.NewList(),
 	}
+	p.leader = dlocker.NewLazyElection(dlocker.LeaderKey("cron"), nil, nil, nil)
 	return p, nil
 }
 
@@ -80,6 +83,9 @@ func (s *processor) Add(jobs ...*xcron.Job) (err error) {
 		if err := s.checkIsMonopoly(t); err != nil {
 			return err
 		}
+		if err := s.checkLeader(t); err != nil {
+			return err
+		}
 		curEngine = s.cronStdEngine
 		if t.WithSeconds {
 			curEngine = s.cronSecEngine
@@ -120,10 +126,22 @@ func (s *processor) Close() error {
 		s.cronStdEngine.Stop()
 		s.cronSecEngine.Stop()
 		s.closeMonopolyJobs()
+		s.leader.Stop()
 	})
 	return nil
 }
 
+// checkLeader 存在leader_only任务时参与leader竞选,同一processor只竞选一次
+func (s *processor) checkLeader(j *xcron.Job) error {
+	if !j.IsLeaderOnly() {
+		return nil
+	}
+	if err := s.leader.Start(s.ctx); err != nil {
+		return fmt.Errorf("cron任务包含leader_only时需要提供dlocker的配置:%v", err)
+	}
+	return nil
+}
+
 func (s *processor) checkIsMonopoly(j *xcron.Job) (err error) {
 	if !j.IsMonopoly() {
 		return nil
@@ -171,6 +189,10 @@ func (s *processor) releaseMonopolyJob(job *xcron.Job) (err error) {
 	mjob := val.(*monopolyJob)
 	nextSecs := mjob.job.CalcExpireSeconds()
 	err = mjob.locker.Renewal(nextSecs)
+	//未抢到锁的节点同样会执行reset,此时无需续期
+	if errors.Is(err, dlocker.ErrNotHeld) {
+		err = nil
+	}
 	return
 }
 
@@ -219,6 +241,11 @@ func (s *processor) handle(req *Request) {
 		close(done)
 	}()
 
+	//非leader节点不执行leader_only任务
+	if req.job.IsLeaderOnly() && !s.leader.IsLeader() {
+		return
+	}
+
 	hasMonopoly, err := req.Monopoly(s.monopolyJobs)
 	if err != nil {
 		logger.Errorf("cron.handle.monopoly:%s,service:%s, error:%+v", req.job.Cron, req.job.Service, err)
@@ -0,0 +1,263 @@
+package xdb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/zhiyunliu/glue/contrib/xdb/implement"
+	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
+	"github.com/zhiyunliu/glue/xdb"
+	"github.com/zhiyunliu/golibs/xtypes"
+)
+
+const (
+	//批量执行时,非insert语句每个分片的默认条数
+	defaultBatchChunkSize = 500
+	//默认单条语句允许的最大参数个数
+	defaultMaxParams = 999
+)
+
+var (
+	dialects sync.Map
+
+	bulkInsertRegexp = regexp.MustCompile(`(?is)^\s*(insert\s+into\s+.+?\bvalues)\s*(\(.*)$`)
+	bulkSymbolRegexp = regexp.MustCompile(`([@$])\{(\w*[\.]?\w+)\}`)
+)
+
+// BulkInsertBuilder 根据insert头部(insert into tbl(a,b) values)与多行values构建批量插入语句
+type BulkInsertBuilder func(head string, values []string) string
+
+// Dialect 数据库方言:批量操作、保存点、事务重试及struct增删改查
+type Dialect struct {
+	//MaxParams 单条语句允许的最大参数个数
+	MaxParams int
+	//MaxRows 批量插入时单条语句允许的最大values行数,小于等于0时只按MaxParams限制
+	MaxRows int
+	//BulkInsert 构建多行插入语句
+	BulkInsert BulkInsertBuilder
+	//Savepoint 保存点语句
+	Savepoint *SavepointDialect
+	//Retryable 判断事务错误是否可以重试(死锁、序列化失败),为空时不重试
+	Retryable RetryableFunc
+	//Model 按struct生成增删改查语句的方言
+	Model ModelDialect
+}
+
+// SavepointDialect 保存点语句,%s为保存点名称
+type SavepointDialect struct {
+	Create   string
+	Release  string //为空时不释放保存点,由事务结束时释放
+	Rollback string
+}
+
+var (
+	// DefaultSavepoint mysql,postgres,sqlite 保存点语句
+	DefaultSavepoint = &SavepointDialect{
+		Create:   "SAVEPOINT %s",
+		Release:  "RELEASE SAVEPOINT %s",
+		Rollback: "ROLLBACK TO SAVEPOINT %s",
+	}
+	// TransactSavepoint sql server 保存点语句
+	TransactSavepoint = &SavepointDialect{
+		Create:   "SAVE TRANSACTION %s",
+		Rollback: "ROLLBACK TRANSACTION %s",
+	}
+)
+
+// RegisterDialect 注册数据库方言
+func RegisterDialect(proto string, dialect *Dialect) {
+	if dialect.BulkInsert == nil {
+		dialect.BulkInsert = DefaultBulkInsert
+	}
+	if dialect.Savepoint == nil {
+		dialect.Savepoint = DefaultSavepoint
+	}
+	if dialect.MaxParams <= 0 {
+		dialect.MaxParams = defaultMaxParams
+	}
+	dialects.Store(strings.ToLower(proto), dialect)
+}
+
+// GetDialect 获取数据库方言,未注册时返回默认方言
+func GetDialect(proto string) *Dialect {
+	if val, ok := dialects.Load(strings.ToLower(proto)); ok {
+		return val.(*Dialect)
+	}
+	return &Dialect{
+		MaxParams:  defaultMaxParams,
+		BulkInsert: DefaultBulkInsert,
+		Savepoint:  DefaultSavepoint,
+	}
+}
+
+// DefaultBulkInsert insert into tbl(a,b) values (...),(...)
+func DefaultBulkInsert(head string, values []string) string {
+	return head + " " + strings.Join(values, ",")
+}
+
+// ExecFunc 单条语句执行方法
+type ExecFunc func(ctx context.Context, sql string, input any) (xdb.Result, error)
+
+// ExecBatch 按方言对inputs分片并执行,insert语句合并为多行values时分片不超过方言的参数及行数上限;
+// opts.Transaction为true时遇到错误立即返回,否则继续执行后续分片,失败的分片汇总为BatchError
+func ExecBatch(ctx context.Context, dialect *Dialect, exec ExecFunc, sql string, inputs []any, opts *xdb.BatchOptions) (r xdb.Result, err error) {
+	result := &batchResult{}
+	if len(inputs) == 0 {
+		return result, nil
+	}
+
+	bulk, ok := parseBulkInsert(sql)
+	chunkSize := opts.ChunkSize
+	if ok {
+		maxRows := dialect.MaxParams
+		if bulk.paramCount > 0 {
+			maxRows = dialect.MaxParams / bulk.paramCount
+		}
+		if dialect.MaxRows > 0 && maxRows > dialect.MaxRows {
+			maxRows = dialect.MaxRows
+		}
+		if maxRows <= 0 {
+			maxRows = 1
+		}
+		if chunkSize <= 0 || chunkSize > maxRows {
+			chunkSize = maxRows
+		}
+	} else if chunkSize <= 0 {
+		chunkSize = defaultBatchChunkSize
+	}
+
+	var chunkErrs []xdb.BatchChunkError
+	for chunk, offset := 0, 0; offset < len(inputs); chunk, offset = chunk+1, offset+chunkSize {
+		end := offset + chunkSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+		var cerr error
+		if ok {
+			cerr = bulk.exec(ctx, dialect, exec, inputs[offset:end], result)
+		} else {
+			cerr = execRows(ctx, exec, sql, inputs[offset:end], result)
+		}
+		if cerr == nil {
+			continue
+		}
+		chunkErrs = append(chunkErrs, xdb.BatchChunkError{
+			Chunk:  chunk,
+			Offset: offset,
+			Size:   end - offset,
+			Err:    cerr,
+		})
+		if opts.Transaction {
+			break
+		}
+	}
+	if len(chunkErrs) > 0 {
+		return result, xdb.NewBatchError(chunkErrs...)
+	}
+	return result, nil
+}
+
+func execRows(ctx context.Context, exec ExecFunc, sql string, inputs []any, result *batchResult) error {
+	for i := range inputs {
+		r, err := exec(ctx, sql, inputs[i])
+		if err != nil {
+			return err
+		}
+		result.append(r)
+	}
+	return nil
+}
+
+// bulkInsert 可合并为多行values的insert语句
+type bulkInsert struct {
+	head       string
+	values     string
+	paramCount int
+}
+
+// parseBulkInsert 解析 insert into tbl(a,b) values(@{a},@{b}) 语句,values必须位于语句末尾
+func parseBulkInsert(sql string) (*bulkInsert, bool) {
+	matches := bulkInsertRegexp.FindStringSubmatch(sql)
+	if len(matches) != 3 {
+		return nil, false
+	}
+	values := strings.TrimRight(strings.TrimSpace(matches[2]), ";")
+	depth := 0
+	for i, c := range values {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && strings.TrimSpace(values[i+1:]) != "" {
+				return nil, false
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, false
+	}
+	return &bulkInsert{
+		head:       strings.TrimSpace(matches[1]),
+		values:     values,
+		paramCount: strings.Count(values, tpl.SymbolAt+"{"),
+	}, true
+}
+
+// exec 将多行数据的参数重命名为 name_行号 后合并为一条语句执行
+func (b *bulkInsert) exec(ctx context.Context, dialect *Dialect, exec ExecFunc, inputs []any, result *batchResult) error {
+	values := make([]string, len(inputs))
+	params := make(xtypes.XMap)
+	for i := range inputs {
+		rowParams, err := implement.ResolveParams(inputs[i])
+		if err != nil {
+			return err
+		}
+		for k, v := range rowParams {
+			params[bulkParamName(k, i)] = v
+		}
+		values[i] = bulkSymbolRegexp.ReplaceAllStringFunc(b.values, func(s string) string {
+			_, propName, _ := tpl.GetPropName(s[2 : len(s)-1])
+			return fmt.Sprintf("%s{%s}", s[:1], bulkParamName(propName, i))
+		})
+	}
+	r, err := exec(ctx, dialect.BulkInsert(b.head, values), params)
+	if err != nil {
+		return err
+	}
+	result.append(r)
+	return nil
+}
+
+func bulkParamName(name string, idx int) string {
+	return fmt.Sprintf("%s_%d", name, idx)
+}
+
+// batchResult 批量执行的汇总结果
+type batchResult struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (r *batchResult) append(res xdb.Result) {
+	if res == nil {
+		return
+	}
+	if affected, err := res.RowsAffected(); err == nil {
+		r.rowsAffected += affected
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		r.lastInsertId = id
+	}
+}
+
+func (r *batchResult) LastInsertId() (int64, error) {
+	return r.lastInsertId, nil
+}
+
+func (r *batchResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
@@ -0,0 +1,141 @@
+package xdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+func Test_parseBulkInsert(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		wantOk     bool
+		wantHead   string
+		wantValues string
+		wantParams int
+	}{
+		{name: "1.", sql: "insert into t(a,b) values(@{a},@{b})", wantOk: true, wantHead: "insert into t(a,b) values", wantValues: "(@{a},@{b})", wantParams: 2},
+		{name: "2.", sql: " INSERT INTO t(a,b) VALUES (@{a}, now());", wantOk: true, wantHead: "INSERT INTO t(a,b) VALUES", wantValues: "(@{a}, now())", wantParams: 1},
+		{name: "3.", sql: "insert into t(a) values(@{a}) on duplicate key update a=values(a)", wantOk: false},
+		{name: "4.", sql: "insert into t(a) select a from b", wantOk: false},
+		{name: "5.", sql: "update t set a=@{a}", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseBulkInsert(tt.sql)
+			if ok != tt.wantOk {
+				t.Fatalf("parseBulkInsert() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if got.head != tt.wantHead || got.values != tt.wantValues || got.paramCount != tt.wantParams {
+				t.Errorf("parseBulkInsert() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestBatchExec_BulkInsert(t *testing.T) {
+	dbobj := newSqliteTestDB(t, "batch_test")
+	mustExec(t, dbobj, "create table items(id integer primary key, name varchar(20))")
+	ctx := context.Background()
+
+	//2个参数,999个参数上限,每个分片最多499行
+	inputs := make([]any, 1200)
+	for i := range inputs {
+		inputs[i] = map[string]any{"id": i + 1, "name": fmt.Sprintf("name%d", i+1)}
+	}
+	var statements int
+	dialect := GetDialect("sqlite")
+	r, err := ExecBatch(ctx, dialect, func(ctx context.Context, sql string, input any) (xdb.Result, error) {
+		statements++
+		return dbobj.Exec(ctx, sql, input)
+	}, "insert into items(id,name) values(@{id},@{t.name})", inputs, xdb.NewBatchOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statements != 3 {
+		t.Errorf("ExecBatch() statements = %d, want 3", statements)
+	}
+	if affected, _ := r.RowsAffected(); affected != 1200 {
+		t.Errorf("ExecBatch() RowsAffected = %d, want 1200", affected)
+	}
+
+	row, err := dbobj.First(ctx, "select name from items where id=@{id}", map[string]any{"id": 1200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := row.GetString("name"); got != "name1200" {
+		t.Errorf("name of id 1200 = %v, want name1200", got)
+	}
+
+	//行数上限小于参数上限时按行数分片(如sqlserver每条语句最多1000行)
+	if _, err = dbobj.Exec(ctx, "delete from items", nil); err != nil {
+		t.Fatal(err)
+	}
+	limited := *dialect
+	limited.MaxRows = 300
+	statements = 0
+	_, err = ExecBatch(ctx, &limited, func(ctx context.Context, sql string, input any) (xdb.Result, error) {
+		statements++
+		return dbobj.Exec(ctx, sql, input)
+	}, "insert into items(id,name) values(@{id},@{t.name})", inputs, xdb.NewBatchOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statements != 4 {
+		t.Errorf("ExecBatch() with MaxRows statements = %d, want 4", statements)
+	}
+}
+
+func TestBatchExec_ChunkErrors(t *testing.T) {
+	dbobj := newSqliteTestDB(t, "batch_test")
+	mustExec(t, dbobj, "create table items(id integer primary key, name varchar(20))")
+	ctx := context.Background()
+
+	inputs := []any{
+		map[string]any{"id": 1, "name": "a"},
+		map[string]any{"id": 2, "name": "b"},
+		map[string]any{"id": 1, "name": "dup"},
+		map[string]any{"id": 3, "name": "c"},
+	}
+	r, err := dbobj.BatchExec(ctx, "insert into items(id,name) values(@{id},@{name})", inputs, xdb.WithBatchChunkSize(2))
+	var batchErr xdb.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("BatchExec() err = %v, want BatchError", err)
+	}
+	if chunks := batchErr.Chunks(); len(chunks) != 1 || chunks[0].Chunk != 1 || chunks[0].Offset != 2 || chunks[0].Size != 2 {
+		t.Errorf("BatchExec() chunks = %+v", chunks)
+	}
+	if affected, _ := r.RowsAffected(); affected != 2 {
+		t.Errorf("BatchExec() RowsAffected = %d, want 2", affected)
+	}
+}
+
+func TestBatchExec_Transaction(t *testing.T) {
+	dbobj := newSqliteTestDB(t, "batch_test")
+	mustExec(t, dbobj, "create table items(id integer primary key, name varchar(20))")
+	ctx := context.Background()
+
+	inputs := []any{
+		map[string]any{"id": 1, "name": "a"},
+		map[string]any{"id": 2, "name": "b"},
+		map[string]any{"id": 1, "name": "dup"},
+	}
+	_, err := dbobj.BatchExec(ctx, "insert or abort into items(id,name) select @{id},@{name}", inputs, xdb.WithBatchTransaction())
+	if err == nil {
+		t.Fatal("BatchExec() err = nil, want error")
+	}
+	cnt, err := dbobj.Scalar(ctx, "select count(1) from items", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(cnt) != "0" {
+		t.Errorf("rows after rollback = %v, want 0", cnt)
+	}
+}
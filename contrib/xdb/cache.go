@@ -0,0 +1,233 @@
+package xdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zhiyunliu/glue/cache"
+	"github.com/zhiyunliu/glue/standard"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// DefaultQueryCacheTTL 未配置ttl时查询结果的默认缓存时长(秒)
+const DefaultQueryCacheTTL = 60
+
+// getQueryCache 根据名称获取缓存对象
+var getQueryCache = func(name string) cache.ICache {
+	return standard.GetInstance(cache.TypeNode).(cache.StandardCache).GetCache(name)
+}
+
+// queryCache 查询结果缓存:
+// 缓存键由展开后的SQL、参数及相关标签的版本号计算得出,
+// 失效标签时递增标签版本号,旧版本的缓存不再命中并等待过期
+type queryCache struct {
+	prefix string
+	cfg    *xdb.QueryCacheConfig
+	tables []*cacheTable
+}
+
+// cacheTable 声明的表,SQL中出现该表名时将表名作为缓存标签
+type cacheTable struct {
+	name string
+	word *regexp.Regexp
+}
+
+func newQueryCache(connName string, cfg *xdb.QueryCacheConfig) *queryCache {
+	if cfg == nil {
+		return nil
+	}
+	qc := &queryCache{
+		prefix: fmt.Sprintf("xdb:%s:", connName),
+		cfg:    cfg,
+	}
+	for _, name := range cfg.Tables {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		qc.tables = append(qc.tables, &cacheTable{
+			name: name,
+			word: regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(name) + `\b`),
+		})
+	}
+	return qc
+}
+
+// query 上下文开启查询缓存时优先从缓存读取result,未命中时执行load并将result写入缓存,
+// 缓存读写失败时直接查询数据库
+func (c *queryCache) query(ctx context.Context, query string, args []any, result any, load func() error) (err error) {
+	opts, ok := xdb.GetCacheOptions(ctx)
+	if c == nil || !ok {
+		return load()
+	}
+	ic := getQueryCache(c.cfg.Cache)
+	key, err := c.key(ctx, ic, query, args, c.tags(query, opts.Tags))
+	if err != nil {
+		return load()
+	}
+	if val, gerr := ic.Get(ctx, key); gerr == nil && decodeCacheValue(val, result) == nil {
+		return nil
+	}
+	if err = load(); err != nil {
+		return
+	}
+	normalizeScalar(result)
+	if data, merr := encodeCacheValue(result); merr == nil {
+		ic.Set(ctx, key, data, c.ttl(opts))
+	}
+	return nil
+}
+
+// tags SQL中出现的声明表及附加标签
+func (c *queryCache) tags(query string, extra []string) []string {
+	tags := make([]string, 0, len(c.tables)+len(extra))
+	for _, table := range c.tables {
+		if table.word.MatchString(query) {
+			tags = append(tags, table.name)
+		}
+	}
+	return append(tags, extra...)
+}
+
+// evictTags 写操作需要失效的标签
+func (c *queryCache) evictTags(ctx context.Context, query string) []string {
+	if c == nil {
+		return nil
+	}
+	return c.tags(query, xdb.GetCacheEvict(ctx))
+}
+
+// evict 递增标签版本号,使包含这些标签的查询缓存失效
+func (c *queryCache) evict(ctx context.Context, tags []string) {
+	if c == nil || len(tags) == 0 {
+		return
+	}
+	ic := getQueryCache(c.cfg.Cache)
+	for _, tag := range tags {
+		ic.Increase(ctx, c.tagKey(tag))
+	}
+}
+
+func (c *queryCache) key(ctx context.Context, ic cache.ICache, query string, args []any, tags []string) (string, error) {
+	h := sha1.New()
+	io.WriteString(h, query)
+	for _, arg := range args {
+		fmt.Fprintf(h, "\x00%T:%v", arg, arg)
+	}
+	for _, tag := range tags {
+		ver, err := ic.Get(ctx, c.tagKey(tag))
+		if err != nil && !errors.Is(err, cache.Nil) {
+			return "", err
+		}
+		fmt.Fprintf(h, "\x00%s:%s", tag, ver)
+	}
+	return c.prefix + "query:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *queryCache) tagKey(tag string) string {
+	return c.prefix + "tag:" + tag
+}
+
+func (c *queryCache) ttl(opts *xdb.CacheOptions) int {
+	if opts.TTL > 0 {
+		return opts.TTL
+	}
+	if c.cfg.TTL > 0 {
+		return c.cfg.TTL
+	}
+	return DefaultQueryCacheTTL
+}
+
+// normalizeScalar Scalar查询结果为sql.NullString等类型时转换为基础类型,保证与缓存命中时的结果一致
+func normalizeScalar(result any) {
+	val, ok := result.(*any)
+	if !ok {
+		return
+	}
+	if valuer, ok := (*val).(driver.Valuer); ok {
+		*val, _ = valuer.Value()
+	}
+}
+
+// cacheEntry 缓存的查询结果,Value保留具体类型(int64,[]byte,time.Time等),命中时与查询数据库的结果一致
+type cacheEntry struct {
+	Value any
+}
+
+var (
+	rowsType = reflect.TypeOf(xdb.Rows{})
+	rowType  = reflect.TypeOf(xdb.Row{})
+)
+
+func init() {
+	gob.Register([]map[string]any{})
+	gob.Register(map[string]any{})
+	gob.Register(sql.RawBytes{})
+	gob.Register(time.Time{})
+}
+
+// encodeCacheValue 使用gob编码result指向的值,包含未注册的类型时返回错误(不写入缓存);
+// xtypes.XMap实现的MarshalBinary为json编码,编码前转换为map[string]any
+func encodeCacheValue(result any) (string, error) {
+	value := reflect.ValueOf(result).Elem().Interface()
+	switch v := value.(type) {
+	case xdb.Rows:
+		maps := make([]map[string]any, len(v))
+		for i := range v {
+			maps[i] = v[i]
+		}
+		value = maps
+	case xdb.Row:
+		value = map[string]any(v)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&cacheEntry{Value: value}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// decodeCacheValue 解码缓存值并写入result,类型不一致时返回错误(视为未命中)
+func decodeCacheValue(val string, result any) error {
+	var entry cacheEntry
+	if err := gob.NewDecoder(bytes.NewBufferString(val)).Decode(&entry); err != nil {
+		return err
+	}
+	target := reflect.ValueOf(result).Elem()
+	if entry.Value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	switch v := entry.Value.(type) {
+	case []map[string]any:
+		if target.Type() == rowsType {
+			rows := make(xdb.Rows, len(v))
+			for i := range v {
+				rows[i] = v[i]
+			}
+			entry.Value = rows
+		}
+	case map[string]any:
+		if target.Type() == rowType {
+			entry.Value = xdb.Row(v)
+		}
+	}
+	value := reflect.ValueOf(entry.Value)
+	if !value.Type().AssignableTo(target.Type()) {
+		return fmt.Errorf("xdb.cache:类型不一致:%s,%s", value.Type(), target.Type())
+	}
+	target.Set(value)
+	return nil
+}
@@ -0,0 +1,181 @@
+package xdb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhiyunliu/glue/cache"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// mapCache 测试用缓存,只实现查询缓存使用到的方法
+type mapCache struct {
+	cache.ICache
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (c *mapCache) Get(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	val, ok := c.data[key]
+	if !ok {
+		return "", cache.Nil
+	}
+	return val, nil
+}
+
+func (c *mapCache) Set(ctx context.Context, key string, val interface{}, expire int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = val.(string)
+	return nil
+}
+
+func (c *mapCache) Increase(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	val, _ := strconv.ParseInt(c.data[key], 10, 64)
+	val++
+	c.data[key] = strconv.FormatInt(val, 10)
+	return val, nil
+}
+
+func newCacheTestDB(t *testing.T) xdb.IDB {
+	mc := &mapCache{data: map[string]string{}}
+	rawGetQueryCache := getQueryCache
+	getQueryCache = func(name string) cache.ICache {
+		return mc
+	}
+	t.Cleanup(func() { getQueryCache = rawGetQueryCache })
+
+	dbobj := newSqliteTestDB(t, "cache_test", xdb.WithQueryCache("default", 0, "users"))
+	mustExec(t, dbobj,
+		"create table users(id int, name varchar(20))",
+		"create table orders(id int)",
+		"insert into users(id,name) values(1,'a')",
+	)
+	return dbobj
+}
+
+func TestQueryCache(t *testing.T) {
+	dbobj := newCacheTestDB(t)
+	sysdb := dbobj.(*xDB).db
+	ctx := xdb.WithCache(context.Background())
+	query := "select name from users where id=@{id}"
+	input := map[string]any{"id": 1}
+
+	name, err := dbobj.Scalar(ctx, query, input)
+	if err != nil || name != "a" {
+		t.Fatalf("Scalar() = %v,%v", name, err)
+	}
+	rows, err := dbobj.Query(ctx, "select id,name from users", nil)
+	if err != nil || rows.Len() != 1 {
+		t.Fatalf("Query() = %v,%v", rows, err)
+	}
+
+	//绕过Exec修改数据,缓存未失效
+	if _, err = sysdb.Exec("update users set name='b'"); err != nil {
+		t.Fatal(err)
+	}
+	if name, _ = dbobj.Scalar(ctx, query, input); name != "a" {
+		t.Errorf("Scalar() cached = %v, want a", name)
+	}
+	if row, _ := dbobj.First(context.Background(), query, input); row.GetString("name") != "b" {
+		t.Errorf("First() without cache = %v, want b", row)
+	}
+	for i := 0; i < 2; i++ {
+		row, err := dbobj.First(ctx, "select id from users where id=@{id}", input)
+		if id, _ := row.GetInt64("id"); err != nil || id != 1 {
+			t.Errorf("First() = %v,%v", row, err)
+		}
+	}
+
+	//未声明的表不失效
+	if _, err = dbobj.Exec(ctx, "insert into orders(id) values(1)", nil); err != nil {
+		t.Fatal(err)
+	}
+	if name, _ = dbobj.Scalar(ctx, query, input); name != "a" {
+		t.Errorf("Scalar() after orders write = %v, want a", name)
+	}
+
+	//声明的表写入后失效
+	if _, err = dbobj.Exec(ctx, "update users set name=@{name}", map[string]any{"name": "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if name, _ = dbobj.Scalar(ctx, query, input); name != "c" {
+		t.Errorf("Scalar() after users write = %v, want c", name)
+	}
+}
+
+func TestQueryCache_Tags(t *testing.T) {
+	dbobj := newCacheTestDB(t)
+	ctx := xdb.WithCache(context.Background(), xdb.CacheTags("orders"), xdb.CacheTTL(10))
+	query := "select count(1) from orders"
+
+	if cnt, _ := dbobj.Scalar(ctx, query, nil); fmt.Sprint(cnt) != "0" {
+		t.Fatalf("Scalar() = %v", cnt)
+	}
+	err := dbobj.Transaction(func(tx xdb.Executer) error {
+		_, err := tx.Exec(xdb.WithCacheEvict(context.Background(), "orders"), "insert into orders(id) values(1)", nil)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cnt, _ := dbobj.Scalar(ctx, query, nil); fmt.Sprint(cnt) != "1" {
+		t.Errorf("Scalar() after evict = %v, want 1", cnt)
+	}
+}
+
+func TestQueryCache_Types(t *testing.T) {
+	dbobj := newCacheTestDB(t)
+	mustExec(t, dbobj,
+		"create table users_ext(id int, score real, data blob, remark varchar(20))",
+		"insert into users_ext(id,score,data,remark) values(9007199254740993,1.5,x'0102',null)",
+	)
+	ctx := xdb.WithCache(context.Background(), xdb.CacheTags("users"))
+	query := "select id,score,data,remark from users_ext"
+
+	miss, err := dbobj.First(ctx, query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hit, err := dbobj.First(ctx, query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(miss, hit) {
+		t.Errorf("First() cached = %#v, want %#v", hit, miss)
+	}
+	//绕过Exec修改数据,确认第二次查询命中缓存
+	if _, err = dbobj.(*xDB).db.Exec("update users_ext set remark='x'"); err != nil {
+		t.Fatal(err)
+	}
+	if hit, _ = dbobj.First(ctx, query, nil); hit["remark"] != nil {
+		t.Errorf("First() should hit cache, remark = %v", hit["remark"])
+	}
+
+	now := time.Now().Truncate(time.Second)
+	data, err := encodeCacheValue(&xdb.Rows{{"at": now, "n": int64(1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows xdb.Rows
+	if err = decodeCacheValue(data, &rows); err != nil || !rows[0]["at"].(time.Time).Equal(now) || rows[0]["n"] != int64(1) {
+		t.Errorf("decodeCacheValue() = %v,%v", rows, err)
+	}
+	var scalar any
+	if err = decodeCacheValue(data, &scalar); err != nil {
+		t.Errorf("decodeCacheValue() into any = %v", err)
+	}
+	var row xdb.Row
+	if err = decodeCacheValue(data, &row); err == nil {
+		t.Errorf("decodeCacheValue() with mismatched type should return error")
+	}
+}
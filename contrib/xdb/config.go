@@ -2,17 +2,16 @@ package xdb
 
 import (
 	"reflect"
-	"time"
 
 	"github.com/zhiyunliu/glue/xdb"
 )
 
 // DB 数据库配置
 type Setting struct {
-	ConnName      string        `json:"-"`
-	Cfg           *xdb.Config   `json:"-"`
-	slowThreshold time.Duration `json:"-"`
-	logger        xdb.Logger    `json:"-"`
+	ConnName     string               `json:"-"`
+	Cfg          *xdb.Config          `json:"-"`
+	interceptors xdb.InterceptorChain `json:"-"`
+	queryCache   *queryCache          `json:"-"`
 }
 
 // New 构建DB连接信息
@@ -27,6 +26,13 @@ func NewConfig(connName string, opts ...xdb.Option) *Setting {
 			LongQueryTime: xdb.Default.LongQueryTime,
 			LoggerName:    xdb.Default.LoggerName,
 			Debug:         xdb.Default.Debug,
+
+			ReplicaBalancer:      xdb.Default.ReplicaBalancer,
+			ReplicaCheckInterval: xdb.Default.ReplicaCheckInterval,
+			ReplicaLagTolerance:  xdb.Default.ReplicaLagTolerance,
+			QueryCache:           xdb.Default.QueryCache,
+			Interceptors:         xdb.Default.Interceptors,
+			MetricsInterval:      xdb.Default.MetricsInterval,
 		},
 	}
 	for _, opt := range opts {
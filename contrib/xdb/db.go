@@ -9,18 +9,28 @@ import (
 
 	"github.com/zhiyunliu/glue/contrib/xdb/implement"
 	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
+	"github.com/zhiyunliu/glue/selector"
 	"github.com/zhiyunliu/glue/xdb"
 )
 
 // DB 数据库操作类
 type xDB struct {
-	cfg *Setting
-	db  implement.ISysDB
-	tpl tpl.SQLTemplate
+	cfg      *Setting
+	db       implement.ISysDB
+	replicas *replicaSet
+	tpl      tpl.SQLTemplate
+	retryer  *Retryer
+	stats    *PoolStats
 }
 
 // NewDB 创建DB实例
 func NewDB(proto string, setting *Setting, opts ...xdb.Option) (obj xdb.IDB, err error) {
+	//解析前的连接字符串,用于定时刷新密钥
+	rawConn := setting.Cfg.Conn
+	rawReplicas := make([]string, len(setting.Cfg.Replicas))
+	for i := range setting.Cfg.Replicas {
+		rawReplicas[i] = setting.Cfg.Replicas[i].Conn
+	}
 	newCfg, err := xdb.DefaultRefactor(setting.ConnName, setting.Cfg)
 	if err != nil {
 		return
@@ -37,22 +47,30 @@ func NewDB(proto string, setting *Setting, opts ...xdb.Option) (obj xdb.IDB, err
 		cfg: setting,
 	}
 
-	setting.slowThreshold = time.Duration(setting.Cfg.LongQueryTime) * time.Millisecond
-	if setting.Cfg.LoggerName != "" {
-		setting.logger, _ = xdb.GetLogger(setting.Cfg.LoggerName)
+	setting.queryCache = newQueryCache(setting.ConnName, setting.Cfg.QueryCache)
+	setting.interceptors, err = xdb.BuildInterceptors(setting.ConnName, setting.Cfg)
+	if err != nil {
+		return
 	}
 
 	dbobj.tpl, err = tpl.GetDBTemplate(proto)
 	if err != nil {
 		return
 	}
-	dbobj.db, err = implement.NewSysDB(proto, setting.Cfg.Conn,
-		implement.WithConnName(setting.ConnName),
-		implement.WithMaxOpen(setting.Cfg.MaxOpen),
-		implement.WithMaxIdle(setting.Cfg.MaxIdle),
-		implement.WithMaxLifeTime(setting.Cfg.LifeTime),
-	)
-	return dbobj, err
+	dbobj.retryer = NewRetryer(setting, proto)
+	dbobj.db, err = newSysDB(proto, setting, setting.ConnName, rawConn, setting.Cfg.Conn)
+	if err != nil {
+		return
+	}
+	if len(setting.Cfg.Replicas) > 0 {
+		dbobj.replicas, err = newReplicaSet(proto, setting, rawReplicas)
+		if err != nil {
+			dbobj.db.Close()
+			return
+		}
+	}
+	dbobj.stats = StartPoolStats(setting, dbobj.db.Stats)
+	return dbobj, nil
 }
 func (db *xDB) GetImpl() interface{} {
 	return db.db
@@ -60,18 +78,21 @@ func (db *xDB) GetImpl() interface{} {
 
 // Query 查询数据
 func (db *xDB) Query(ctx context.Context, sqls string, input any) (rows xdb.Rows, err error) {
-	tmp, err := db.dbQuery(ctx, sqls, input, func(r *sql.Rows) (any, error) {
-		return implement.ResolveRows(r)
-	})
-	if err != nil {
-		return
+	if tx, ok := xdb.GetTx(ctx, db.cfg.ConnName); ok {
+		return tx.Query(ctx, sqls, input)
 	}
-	rows = tmp.(xdb.Rows)
+	err = db.dbCacheQuery(ctx, sqls, input, &rows, func(r *sql.Rows) (err error) {
+		rows, err = implement.ResolveRows(r)
+		return
+	})
 	return
 }
 
 // Multi 查询数据(多个数据集)
 func (db *xDB) Multi(ctx context.Context, sqls string, input any) (datasetRows []xdb.Rows, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.cfg.ConnName); ok {
+		return tx.Multi(ctx, sqls, input)
+	}
 	tmp, err := db.dbQuery(ctx, sqls, input, func(r *sql.Rows) (any, error) {
 		return implement.ResolveMultiRows(r)
 	})
@@ -83,26 +104,32 @@ func (db *xDB) Multi(ctx context.Context, sqls string, input any) (datasetRows [
 }
 
 func (db *xDB) First(ctx context.Context, sqls string, input any) (data xdb.Row, err error) {
-	tmp, err := db.dbQuery(ctx, sqls, input, func(r *sql.Rows) (any, error) {
-		return implement.ResolveFirstRow(r)
-	})
-	if err != nil {
-		return
+	if tx, ok := xdb.GetTx(ctx, db.cfg.ConnName); ok {
+		return tx.First(ctx, sqls, input)
 	}
-	data = tmp.(xdb.Row)
+	err = db.dbCacheQuery(ctx, sqls, input, &data, func(r *sql.Rows) (err error) {
+		data, err = implement.ResolveFirstRow(r)
+		return
+	})
 	return
 }
 
 func (db *xDB) Scalar(ctx context.Context, sqls string, input any) (data interface{}, err error) {
-	data, err = db.dbQuery(ctx, sqls, input, func(r *sql.Rows) (any, error) {
-		return implement.ResolveScalar(r)
+	if tx, ok := xdb.GetTx(ctx, db.cfg.ConnName); ok {
+		return tx.Scalar(ctx, sqls, input)
+	}
+	err = db.dbCacheQuery(ctx, sqls, input, &data, func(r *sql.Rows) (err error) {
+		data, err = implement.ResolveScalar(r)
+		return
 	})
 	return
 }
 
 // Execute 根据包含@名称占位符的语句执行查询语句
 func (db *xDB) Exec(ctx context.Context, sql string, input any) (r xdb.Result, err error) {
-
+	if tx, ok := xdb.GetTx(ctx, db.cfg.ConnName); ok {
+		return tx.Exec(ctx, sql, input)
+	}
 	dbParam, err := implement.ResolveParams(input)
 	if err != nil {
 		return
@@ -113,30 +140,131 @@ func (db *xDB) Exec(ctx context.Context, sql string, input any) (r xdb.Result, e
 		return
 	}
 
-	start := time.Now()
-	debugPrint(ctx, db.cfg, query, execArgs...)
-	r, err = db.db.Exec(query, execArgs...)
+	info := &xdb.QueryInfo{ConnName: db.cfg.ConnName, Proto: db.tpl.Name(), Kind: xdb.QueryKindExec, SQL: query, Args: execArgs}
+	err = db.cfg.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) (err error) {
+		if r, err = db.db.Exec(info.SQL, info.Args...); err != nil {
+			return
+		}
+		info.RowsAffected, _ = r.RowsAffected()
+		return
+	})
+	if err != nil {
+		return r, implement.GetError(err, info.SQL, info.Args...)
+	}
+	if tracker, ok := xdb.GetWriteTracker(ctx); ok {
+		tracker.MarkWrite()
+	}
+	db.cfg.queryCache.evict(ctx, db.cfg.queryCache.evictTags(ctx, info.SQL))
+	return
+}
+
+// ExecReturning 执行返回结果集的写语句(insert ... returning),在主库执行,
+// 与Exec相同经过拦截器、标记写入并失效查询缓存
+func (db *xDB) ExecReturning(ctx context.Context, sql string, input any) (rows xdb.Rows, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.cfg.ConnName); ok {
+		return execReturning(ctx, tx, sql, input)
+	}
+	query, execArgs, err := db.expand(sql, input)
 	if err != nil {
-		return r, implement.GetError(err, query, execArgs...)
+		return
 	}
-	printSlowQuery(ctx, db.cfg, time.Since(start), query, execArgs...)
 
+	info := &xdb.QueryInfo{ConnName: db.cfg.ConnName, Proto: db.tpl.Name(), Kind: xdb.QueryKindExec, SQL: query, Args: execArgs}
+	err = db.cfg.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) error {
+		r, err := db.db.Query(info.SQL, info.Args...)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if rows, err = implement.ResolveRows(r); err != nil {
+			return err
+		}
+		info.RowsAffected = int64(len(rows))
+		return nil
+	})
+	if err != nil {
+		return nil, implement.GetError(err, info.SQL, info.Args...)
+	}
+	if tracker, ok := xdb.GetWriteTracker(ctx); ok {
+		tracker.MarkWrite()
+	}
+	db.cfg.queryCache.evict(ctx, db.cfg.queryCache.evictTags(ctx, info.SQL))
 	return
 }
 
 // Query 查询数据
 func (db *xDB) QueryAs(ctx context.Context, sqls string, input any, results any) (err error) {
+	if tx, ok := xdb.GetTx(ctx, db.cfg.ConnName); ok {
+		return tx.QueryAs(ctx, sqls, input, results)
+	}
 	return db.dbQueryAs(ctx, sqls, input, results, func(r *sql.Rows, val any) error {
 		return implement.ResolveRowsDataResult(r, val)
 	})
 }
 
 func (db *xDB) FirstAs(ctx context.Context, sqls string, input any, result any) (err error) {
+	if tx, ok := xdb.GetTx(ctx, db.cfg.ConnName); ok {
+		return tx.FirstAs(ctx, sqls, input, result)
+	}
 	return db.dbQueryAs(ctx, sqls, input, result, func(r *sql.Rows, val any) error {
 		return implement.ResolveFirstDataResult(r, val)
 	})
 }
 
+// QueryStream 流式查询数据
+func (db *xDB) QueryStream(ctx context.Context, sqls string, input any) (cursor xdb.Cursor, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.cfg.ConnName); ok {
+		return tx.QueryStream(ctx, sqls, input)
+	}
+	rows, err := db.dbRows(ctx, sqls, input)
+	if err != nil {
+		return
+	}
+	return implement.NewCursor(rows)
+}
+
+// BatchExec 批量执行
+func (db *xDB) BatchExec(ctx context.Context, sqls string, inputs []any, opts ...xdb.BatchOption) (r xdb.Result, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.cfg.ConnName); ok {
+		return tx.BatchExec(ctx, sqls, inputs, opts...)
+	}
+	batchOpts := xdb.NewBatchOptions(opts...)
+	dialect := GetDialect(db.tpl.Name())
+	if !batchOpts.Transaction {
+		return ExecBatch(ctx, dialect, db.Exec, sqls, inputs, batchOpts)
+	}
+	err = db.Transaction(func(tx xdb.Executer) (terr error) {
+		r, terr = ExecBatch(ctx, dialect, tx.Exec, sqls, inputs, batchOpts)
+		return
+	})
+	return
+}
+
+// Insert 按struct插入一行数据,返回自增列的值
+func (db *xDB) Insert(ctx context.Context, obj any) (id int64, err error) {
+	return ModelInsert(ctx, db, GetDialect(db.tpl.Name()), obj)
+}
+
+// Update 按主键更新struct对应的行
+func (db *xDB) Update(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return ModelUpdate(ctx, db, obj)
+}
+
+// Upsert 按主键存在时更新,否则插入
+func (db *xDB) Upsert(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return ModelUpsert(ctx, db, GetDialect(db.tpl.Name()), obj)
+}
+
+// DeleteByKey 按主键删除struct对应的行
+func (db *xDB) DeleteByKey(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return ModelDeleteByKey(ctx, db, obj)
+}
+
+// GetByKey 按主键查询并填充struct
+func (db *xDB) GetByKey(ctx context.Context, obj any) (found bool, err error) {
+	return ModelGetByKey(ctx, db, obj)
+}
+
 // Begin 创建事务
 func (db *xDB) Begin() (t xdb.ITrans, err error) {
 	tt := &xTrans{
@@ -150,8 +278,18 @@ func (db *xDB) Begin() (t xdb.ITrans, err error) {
 	return tt, nil
 }
 
-// Transaction 执行事务
+// Transaction 执行事务,与TransactionContext相同经过拦截器(tracing创建事务span,metrics计数);
+// 配置了重试策略时遇到死锁等可重试错误会重新执行整个事务
 func (db *xDB) Transaction(callback xdb.TransactionCallback) (err error) {
+	info := &xdb.QueryInfo{ConnName: db.cfg.ConnName, Proto: db.tpl.Name(), Kind: xdb.QueryKindTransaction}
+	return db.cfg.interceptors.Invoke(context.Background(), info, func(ctx context.Context, info *xdb.QueryInfo) error {
+		return db.retryer.Do(ctx, func() error {
+			return db.transaction(callback)
+		})
+	})
+}
+
+func (db *xDB) transaction(callback xdb.TransactionCallback) (err error) {
 	tt := &xTrans{
 		cfg: db.cfg,
 	}
@@ -178,69 +316,122 @@ func (db *xDB) Transaction(callback xdb.TransactionCallback) (err error) {
 		tt.Rollback()
 		return
 	}
-	tt.Commit()
-	return
+	return tt.Commit()
+}
+
+// TransactionContext 按传播方式执行事务,ctx中已绑定当前连接的事务时按传播方式加入或创建保存点,
+// 只有开启新事务时才按重试策略重新执行
+func (db *xDB) TransactionContext(ctx context.Context, callback xdb.TxCallback, opts ...xdb.TxOption) error {
+	if !xdb.BeginsNewTx(ctx, db.cfg.ConnName, opts...) {
+		return xdb.RunTransaction(ctx, db.cfg.ConnName, db.Begin, callback, opts...)
+	}
+	info := &xdb.QueryInfo{ConnName: db.cfg.ConnName, Proto: db.tpl.Name(), Kind: xdb.QueryKindTransaction}
+	return db.cfg.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) error {
+		return db.retryer.Do(ctx, func() error {
+			return xdb.RunTransaction(ctx, db.cfg.ConnName, db.Begin, callback, opts...)
+		})
+	})
 }
 
 // Close  关闭当前数据库连接
 func (db *xDB) Close() error {
+	db.stats.Close()
+	if db.replicas != nil {
+		db.replicas.Close()
+	}
 	return db.db.Close()
 }
 
+// readDB 获取读操作使用的连接:
+// 未配置副本、上下文强制读主库或处于写后读时长内时使用主库,否则由selector选择副本
+func (db *xDB) readDB(ctx context.Context) (implement.ISysDB, selector.DoneFunc) {
+	if db.replicas == nil || xdb.IsReadPrimary(ctx) {
+		return db.db, nil
+	}
+	if tracker, ok := xdb.GetWriteTracker(ctx); ok {
+		tolerance := time.Duration(db.cfg.Cfg.ReplicaLagTolerance) * time.Millisecond
+		if tracker.WrittenWithin(tolerance) {
+			return db.db, nil
+		}
+	}
+	replica, done, ok := db.replicas.pick(ctx)
+	if !ok {
+		return db.db, nil
+	}
+	return replica, done
+}
+
 func (db *xDB) dbQuery(ctx context.Context, sql string, input any, callback implement.DbResolveMapValCallback) (result any, err error) {
-	dbParams, err := implement.ResolveParams(input)
+	rows, err := db.dbRows(ctx, sql, input)
 	if err != nil {
 		return
 	}
+	defer rows.Close()
+	result, err = callback(rows)
+	return
+}
 
-	query, execArgs, err := db.tpl.GetSQLContext(sql, dbParams)
+func (db *xDB) dbQueryAs(ctx context.Context, sql string, input any, result any, callback implement.DbResolveResultCallback) (err error) {
+	rows, err := db.dbRows(ctx, sql, input)
 	if err != nil {
-		err = implement.GetError(err, sql, input)
 		return
 	}
+	defer rows.Close()
+	err = callback(rows, result)
+	return
+}
 
-	start := time.Now()
-
-	debugPrint(ctx, db.cfg, query, execArgs...)
-	rows, err := db.db.Query(query, execArgs...)
+// dbCacheQuery 解析模板后按上下文的查询缓存选项读取result,未命中时执行查询并由callback写入result
+func (db *xDB) dbCacheQuery(ctx context.Context, sql string, input any, result any, callback func(*sql.Rows) error) (err error) {
+	query, execArgs, err := db.expand(sql, input)
 	if err != nil {
-		return nil, implement.GetError(err, query, execArgs...)
+		return
 	}
-	defer func() {
-		if rows != nil {
-			rows.Close()
+	return db.cfg.queryCache.query(ctx, query, execArgs, result, func() error {
+		rows, err := db.queryRows(ctx, query, execArgs)
+		if err != nil {
+			return err
 		}
-	}()
-	printSlowQuery(ctx, db.cfg, time.Since(start), query, execArgs...)
-	result, err = callback(rows)
-	return
+		defer rows.Close()
+		return callback(rows)
+	})
 }
 
-func (db *xDB) dbQueryAs(ctx context.Context, sql string, input any, result any, callback implement.DbResolveResultCallback) (err error) {
-	dbParams, err := implement.ResolveParams(input)
+// dbRows 解析模板并执行查询,返回的rows由调用方关闭
+func (db *xDB) dbRows(ctx context.Context, sql string, input any) (rows *sql.Rows, err error) {
+	query, execArgs, err := db.expand(sql, input)
 	if err != nil {
 		return
 	}
+	return db.queryRows(ctx, query, execArgs)
+}
 
-	query, execArgs, err := db.tpl.GetSQLContext(sql, dbParams)
+// expand 解析模板,返回可执行的SQL及参数
+func (db *xDB) expand(sql string, input any) (query string, execArgs []any, err error) {
+	dbParams, err := implement.ResolveParams(input)
 	if err != nil {
-		err = implement.GetError(err, sql, input)
 		return
 	}
-
-	start := time.Now()
-
-	debugPrint(ctx, db.cfg, query, execArgs...)
-	rows, err := db.db.Query(query, execArgs...)
+	query, execArgs, err = db.tpl.GetSQLContext(sql, dbParams)
 	if err != nil {
-		return implement.GetError(err, query, execArgs...)
+		err = implement.GetError(err, sql, input)
 	}
-	defer func() {
-		if rows != nil {
-			rows.Close()
+	return
+}
+
+// queryRows 执行查询,返回的rows由调用方关闭
+func (db *xDB) queryRows(ctx context.Context, query string, execArgs []any) (rows *sql.Rows, err error) {
+	info := &xdb.QueryInfo{ConnName: db.cfg.ConnName, Proto: db.tpl.Name(), Kind: xdb.QueryKindQuery, SQL: query, Args: execArgs}
+	err = db.cfg.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) (err error) {
+		sysdb, done := db.readDB(ctx)
+		rows, err = sysdb.Query(info.SQL, info.Args...)
+		if done != nil {
+			done(ctx, selector.DoneInfo{Err: err})
 		}
-	}()
-	printSlowQuery(ctx, db.cfg, time.Since(start), query, execArgs...)
-	err = callback(rows, result)
+		return
+	})
+	if err != nil {
+		return nil, implement.GetError(err, info.SQL, info.Args...)
+	}
 	return
 }
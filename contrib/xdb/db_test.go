@@ -0,0 +1,46 @@
+package xdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// newSqliteTestDB 在临时目录中创建名为name的sqlite数据库,测试结束时关闭
+func newSqliteTestDB(t *testing.T, name string, opts ...xdb.Option) xdb.IDB {
+	t.Helper()
+	setting := NewConfig(name, opts...)
+	setting.Cfg.Conn = filepath.Join(t.TempDir(), name+".db")
+	dbobj, err := NewDB("sqlite", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dbobj.Close() })
+	return dbobj
+}
+
+// mustExec 依次执行sql,用于初始化测试数据
+func mustExec(t *testing.T, dbobj xdb.Executer, sqls ...string) {
+	t.Helper()
+	for _, sql := range sqls {
+		if _, err := dbobj.Exec(context.Background(), sql, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNewDB_SqliteFileURI(t *testing.T) {
+	setting := NewConfig("file_uri_test")
+	setting.Cfg.Conn = "file://" + filepath.Join(t.TempDir(), "file_uri.db") + "?cache=shared"
+	dbobj, err := NewDB("sqlite", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbobj.Close()
+	mustExec(t, dbobj, "create table items(id integer primary key)", "insert into items(id) values(1)")
+	if got := itemIDs(t, dbobj); got != "[1]" {
+		t.Errorf("items = %v, want [1]", got)
+	}
+}
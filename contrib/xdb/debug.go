@@ -8,30 +8,43 @@ import (
 
 	"github.com/zhiyunliu/glue/contrib/xdb/implement"
 	"github.com/zhiyunliu/glue/log"
+	"github.com/zhiyunliu/glue/xdb"
 )
 
-func debugPrint(ctx context.Context, setting *Setting, query string, args ...interface{}) {
-	if setting.Cfg.Debug {
-		logger, ok := log.FromContext(ctx)
-		if !ok {
-			logger = log.DefaultLogger
-		}
-		builder := strings.Builder{}
-		args = implement.Unwrap(args...)
-		idx := 1
-		for _, v := range args {
-			if na, ok := v.(sql.NamedArg); ok {
-				builder.WriteString(fmt.Sprintf("@%s = %v\n", na.Name, na.Value))
-				continue
-			}
-			if na, ok := v.(*sql.NamedArg); ok {
-				builder.WriteString(fmt.Sprintf("@%s = %v\n", na.Name, na.Value))
-				continue
-			}
-			builder.WriteString(fmt.Sprintf("@p%d = %v\n", idx, v))
-			idx++
+// newDebugInterceptor 调试模式下打印执行的sql及参数
+func newDebugInterceptor(connName string, cfg *xdb.Config) (xdb.Interceptor, error) {
+	if !cfg.Debug {
+		return nil, nil
+	}
+	return xdb.InterceptorFuncs{BeforeFunc: func(ctx context.Context, info *xdb.QueryInfo) (context.Context, error) {
+		if info.Kind == xdb.QueryKindTransaction {
+			return ctx, nil
 		}
+		debugPrint(ctx, info.SQL, info.Args...)
+		return ctx, nil
+	}}, nil
+}
 
-		logger.Debugf("sql:%s,args:%s", query, builder.String())
+func debugPrint(ctx context.Context, query string, args ...interface{}) {
+	logger, ok := log.FromContext(ctx)
+	if !ok {
+		logger = log.DefaultLogger
 	}
+	builder := strings.Builder{}
+	args = implement.Unwrap(args...)
+	idx := 1
+	for _, v := range args {
+		if na, ok := v.(sql.NamedArg); ok {
+			builder.WriteString(fmt.Sprintf("@%s = %v\n", na.Name, na.Value))
+			continue
+		}
+		if na, ok := v.(*sql.NamedArg); ok {
+			builder.WriteString(fmt.Sprintf("@%s = %v\n", na.Name, na.Value))
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("@p%d = %v\n", idx, v))
+		idx++
+	}
+
+	logger.Debugf("sql:%s,args:%s", query, builder.String())
 }
@@ -0,0 +1,116 @@
+package implement
+
+import (
+	"database/sql"
+	"reflect"
+
+	"github.com/zhiyunliu/glue/xdb"
+	"github.com/zhiyunliu/golibs/xreflect"
+)
+
+var _ xdb.Cursor = &sqlCursor{}
+
+// sqlCursor 基于sql.Rows的结果集游标
+type sqlCursor struct {
+	rows    *sql.Rows
+	columns []string
+	values  []interface{}
+	closed  bool
+}
+
+// NewCursor 创建结果集游标
+func NewCursor(rows *sql.Rows) (xdb.Cursor, error) {
+	cursor := &sqlCursor{
+		rows: rows,
+	}
+	if err := cursor.prepare(); err != nil {
+		cursor.Close()
+		return nil, err
+	}
+	return cursor, nil
+}
+
+func (c *sqlCursor) prepare() (err error) {
+	columnTypes, err := c.rows.ColumnTypes()
+	if err != nil {
+		return
+	}
+	c.columns, err = c.rows.Columns()
+	if err != nil {
+		return
+	}
+	c.values = make([]interface{}, len(columnTypes))
+	prepareValues(c.values, columnTypes)
+	return
+}
+
+func (c *sqlCursor) Columns() ([]string, error) {
+	return c.columns, nil
+}
+
+func (c *sqlCursor) Next() bool {
+	return c.rows.Next()
+}
+
+func (c *sqlCursor) NextResultSet() bool {
+	if !c.rows.NextResultSet() {
+		return false
+	}
+	if err := c.prepare(); err != nil {
+		return false
+	}
+	return true
+}
+
+func (c *sqlCursor) Scan(dest ...any) error {
+	return c.rows.Scan(dest...)
+}
+
+func (c *sqlCursor) ScanRow() (row xdb.Row, err error) {
+	err = c.rows.Scan(c.values...)
+	if err != nil {
+		return
+	}
+	row = xdb.NewRow()
+	err = scanIntoMap(reflect.ValueOf(row), c.values, c.columns)
+	return
+}
+
+func (c *sqlCursor) ScanAs(result any) (err error) {
+	rv := reflect.ValueOf(result)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &xdb.InvalidArgTypeError{Type: reflect.TypeOf(result)}
+	}
+	err = c.rows.Scan(c.values...)
+	if err != nil {
+		return
+	}
+
+	indirectType := reflect.Indirect(rv).Type()
+	switch indirectType.Kind() {
+	case reflect.Map:
+		mapval := rv.Elem()
+		if mapval.IsNil() {
+			mapval = reflect.MakeMapWithSize(indirectType, len(c.columns))
+			rv.Elem().Set(mapval)
+		}
+		return scanIntoMap(mapval, c.values, c.columns)
+	case reflect.Struct:
+		fields := xreflect.CachedTypeFields(indirectType)
+		return scanInToStruct(fields, rv, c.columns, c.values)
+	default:
+		return &xdb.InvalidArgTypeError{Type: rv.Type()}
+	}
+}
+
+func (c *sqlCursor) Err() error {
+	return c.rows.Err()
+}
+
+func (c *sqlCursor) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.rows.Close()
+}
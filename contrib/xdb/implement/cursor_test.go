@@ -0,0 +1,148 @@
+package implement
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type cursorItem struct {
+	ID   sql.NullInt64  `json:"id"`
+	Name sql.NullString `json:"name"`
+}
+
+func newCursorTestDB(t *testing.T, count int) ISysDB {
+	db, err := NewSysDB("sqlite", filepath.Join(t.TempDir(), "cursor.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err = db.Exec("create table items(id integer, name varchar(20))"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= count; i++ {
+		if _, err = db.Exec("insert into items(id,name) values(?,?)", i, fmt.Sprintf("name%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestCursor_ScanRow(t *testing.T) {
+	db := newCursorTestDB(t, 5)
+	rows, err := db.Query("select id,name from items order by id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := NewCursor(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cursor.Close()
+
+	count := 0
+	for cursor.Next() {
+		row, err := cursor.ScanRow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+		if got := row.GetString("name"); got != fmt.Sprintf("name%d", count) {
+			t.Errorf("ScanRow() name = %v, want name%d", got, count)
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 {
+		t.Errorf("ScanRow() count = %d, want 5", count)
+	}
+}
+
+func TestCursor_ScanAs(t *testing.T) {
+	db := newCursorTestDB(t, 3)
+	rows, err := db.Query("select id,name from items order by id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := NewCursor(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cursor.Close()
+
+	var items []cursorItem
+	for cursor.Next() {
+		item := cursorItem{}
+		if err := cursor.ScanAs(&item); err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+	if len(items) != 3 || items[2].ID.Int64 != 3 || items[2].Name.String != "name3" {
+		t.Errorf("ScanAs() struct = %+v", items)
+	}
+
+	rows, err = db.Query("select id,name from items where id=?", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapCursor, err := NewCursor(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mapCursor.Close()
+	var item map[string]any
+	for mapCursor.Next() {
+		if err := mapCursor.ScanAs(&item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fmt.Sprint(item["name"]) != "name2" {
+		t.Errorf("ScanAs() map = %+v", item)
+	}
+
+	if err := mapCursor.ScanAs(item); err == nil {
+		t.Errorf("ScanAs() non-pointer should return error")
+	}
+}
+
+func TestCursor_Scan(t *testing.T) {
+	db := newCursorTestDB(t, 2)
+	rows, err := db.Query("select id,name from items order by id desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := NewCursor(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cursor.Close()
+
+	cols, _ := cursor.Columns()
+	if len(cols) != 2 {
+		t.Errorf("Columns() = %v", cols)
+	}
+	if !cursor.Next() {
+		t.Fatal("Next() = false, want true")
+	}
+	var (
+		id   int64
+		name string
+	)
+	if err := cursor.Scan(&id, &name); err != nil {
+		t.Fatal(err)
+	}
+	if id != 2 || name != "name2" {
+		t.Errorf("Scan() = %d,%s", id, name)
+	}
+	if err := cursor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cursor.Close(); err != nil {
+		t.Errorf("Close() twice err:%+v", err)
+	}
+}
@@ -338,7 +338,17 @@ func scanInToStruct(fields *xreflect.StructFields, rv reflect.Value, cols []stri
 		if !(vrf.IsValid() && vrf.CanInterface()) {
 			continue
 		}
-		err = fields.Dencode(rv, col, vrf.Interface())
+		val := vrf.Interface()
+		//sql.NullInt64 等类型取实际值,NULL不填充
+		if valuer, ok := val.(driver.Valuer); ok && vrf.Type().PkgPath() == "database/sql" {
+			if val, err = valuer.Value(); err != nil {
+				return
+			}
+			if val == nil {
+				continue
+			}
+		}
+		err = fields.Dencode(rv, col, val)
 		if err != nil {
 			err = xdb.NewError(fmt.Errorf("field:%s,val:%+v,err:%w", col, vals[i], err), "", nil)
 			return
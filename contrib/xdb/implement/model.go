@@ -0,0 +1,172 @@
+package implement
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/zhiyunliu/golibs/xtypes"
+)
+
+var modelTables sync.Map
+
+// TableNamer 自定义struct对应的表名,未实现时使用struct名称的蛇形命名
+type TableNamer interface {
+	TableName() string
+}
+
+// ModelColumn struct字段对应的列
+type ModelColumn struct {
+	Name  string
+	Index []int
+	Key   bool //主键列, db:"id,pk"
+	Auto  bool //自增列, db:"id,pk,auto"
+}
+
+// ModelTable struct对应的表结构,列名与QueryAs的映射规则一致:
+// 依次取db标签、json标签、字段名称,db标签中的pk、auto选项标记主键及自增列
+type ModelTable struct {
+	Name    string
+	Columns []*ModelColumn
+	Keys    []*ModelColumn
+	Auto    *ModelColumn
+}
+
+// GetModelTable 解析obj对应的表结构,obj必须为struct指针
+func GetModelTable(obj any) (table *ModelTable, rv reflect.Value, err error) {
+	rv = reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, rv, fmt.Errorf("只能接收struct指针; 实际是 %T", obj)
+	}
+	rt := rv.Elem().Type()
+	if val, ok := modelTables.Load(rt); ok {
+		return val.(*ModelTable), rv.Elem(), nil
+	}
+	table = &ModelTable{}
+	if namer, ok := obj.(TableNamer); ok {
+		table.Name = namer.TableName()
+	} else {
+		table.Name = snakeCase(rt.Name())
+	}
+	table.appendColumns(rt, nil)
+	for _, col := range table.Columns {
+		if col.Key {
+			table.Keys = append(table.Keys, col)
+		}
+		if col.Auto {
+			table.Auto = col
+		}
+	}
+	modelTables.Store(rt, table)
+	return table, rv.Elem(), nil
+}
+
+func (t *ModelTable) appendColumns(rt reflect.Type, parent []int) {
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		index := make([]int, len(parent)+1)
+		copy(index, parent)
+		index[len(parent)] = i
+
+		dbTag := sf.Tag.Get("db")
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && dbTag == "" {
+			t.appendColumns(sf.Type, index)
+			continue
+		}
+		if !sf.IsExported() || dbTag == "-" {
+			continue
+		}
+		tag := dbTag
+		if tag == "" {
+			tag = sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+		}
+		name, _ := parseTag(tag)
+		if !isValidTag(name) {
+			name = sf.Name
+		}
+		_, opts := parseTag(dbTag)
+		t.Columns = append(t.Columns, &ModelColumn{
+			Name:  name,
+			Index: index,
+			Key:   opts.Contains("pk"),
+			Auto:  opts.Contains("auto"),
+		})
+	}
+}
+
+// Value 获取列对应的字段值
+func (c *ModelColumn) Value(rv reflect.Value) any {
+	return rv.FieldByIndex(c.Index).Interface()
+}
+
+// IsZero 列对应的字段是否为零值
+func (c *ModelColumn) IsZero(rv reflect.Value) bool {
+	return rv.FieldByIndex(c.Index).IsZero()
+}
+
+// Params 获取列对应的参数,参数名称为列名
+func (t *ModelTable) Params(rv reflect.Value, cols []*ModelColumn) xtypes.XMap {
+	params := make(xtypes.XMap, len(cols))
+	for _, col := range cols {
+		params[col.Name] = col.Value(rv)
+	}
+	return params
+}
+
+// InsertColumns 插入时使用的列,自增列为零值时由数据库生成
+func (t *ModelTable) InsertColumns(rv reflect.Value) []*ModelColumn {
+	cols := make([]*ModelColumn, 0, len(t.Columns))
+	for _, col := range t.Columns {
+		if col.Auto && col.IsZero(rv) {
+			continue
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// UpdateColumns 更新时使用的列,不包含主键及自增列
+func (t *ModelTable) UpdateColumns() []*ModelColumn {
+	cols := make([]*ModelColumn, 0, len(t.Columns))
+	for _, col := range t.Columns {
+		if col.Key || col.Auto {
+			continue
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// SetAuto 将数据库生成的自增值回写到自增列
+func (t *ModelTable) SetAuto(rv reflect.Value, id int64) {
+	if t.Auto == nil {
+		return
+	}
+	fv := rv.FieldByIndex(t.Auto.Index)
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fv.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fv.SetUint(uint64(id))
+	}
+}
+
+func snakeCase(name string) string {
+	builder := strings.Builder{}
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				builder.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
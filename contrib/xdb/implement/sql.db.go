@@ -26,7 +26,10 @@ type ISysDB interface {
 	Query(string, ...interface{}) (*sql.Rows, error)
 	Exec(string, ...interface{}) (sql.Result, error)
 	Begin() (ISysTrans, error)
+	Ping() error
 	Close() error
+	//Stats 连接池状态
+	Stats() sql.DBStats
 }
 
 // SysDB 数据库实体
@@ -115,7 +118,17 @@ func (db *sysDB) Begin() (r ISysTrans, err error) {
 	return t, err
 }
 
+// Ping 检查数据库连接
+func (db *sysDB) Ping() error {
+	return db.db.Ping()
+}
+
 // Close 关闭数据库
 func (db *sysDB) Close() error {
 	return db.db.Close()
 }
+
+// Stats 连接池状态
+func (db *sysDB) Stats() sql.DBStats {
+	return db.db.Stats()
+}
@@ -0,0 +1,32 @@
+package xdb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+func init() {
+	xdb.RegisterInterceptor("debug", newDebugInterceptor)
+	xdb.RegisterInterceptor("slowquery", newSlowQueryInterceptor)
+	xdb.RegisterInterceptor("where_required", newWhereRequiredInterceptor)
+	xdb.RegisterInterceptor("tracing", newTracingInterceptor)
+	xdb.RegisterInterceptor("metrics", newMetricsInterceptor)
+}
+
+var (
+	unsafeWriteRegexp = regexp.MustCompile(`(?is)^\s*(delete|update)\b`)
+	whereRegexp       = regexp.MustCompile(`(?i)\bwhere\b`)
+)
+
+// newWhereRequiredInterceptor 拒绝执行不带where条件的delete、update语句
+func newWhereRequiredInterceptor(connName string, cfg *xdb.Config) (xdb.Interceptor, error) {
+	return xdb.InterceptorFuncs{BeforeFunc: func(ctx context.Context, info *xdb.QueryInfo) (context.Context, error) {
+		if info.Kind == xdb.QueryKindExec && unsafeWriteRegexp.MatchString(info.SQL) && !whereRegexp.MatchString(info.SQL) {
+			return ctx, fmt.Errorf("%w:[%s]delete、update语句必须带where条件", xdb.ErrQueryBlocked, connName)
+		}
+		return ctx, nil
+	}}, nil
+}
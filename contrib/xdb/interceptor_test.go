@@ -0,0 +1,53 @@
+package xdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+func TestInterceptors(t *testing.T) {
+	var infos []xdb.QueryInfo
+	xdb.RegisterInterceptor("interceptor_test", func(connName string, cfg *xdb.Config) (xdb.Interceptor, error) {
+		return xdb.InterceptorFuncs{AfterFunc: func(ctx context.Context, info *xdb.QueryInfo) {
+			infos = append(infos, *info)
+		}}, nil
+	})
+
+	dbobj := newSqliteTestDB(t, "interceptor_test", xdb.WithInterceptors("interceptor_test", "where_required"))
+	ctx := context.Background()
+
+	mustExec(t, dbobj, "create table items(id integer primary key)")
+	_, err := dbobj.Exec(ctx, "insert into items(id) values(@{id})", map[string]any{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dbobj.Query(ctx, "select id from items", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dbobj.Exec(ctx, "delete from items", nil); !errors.Is(err, xdb.ErrQueryBlocked) {
+		t.Fatalf("Exec() delete without where err = %v", err)
+	}
+	if _, err = dbobj.Exec(ctx, "delete from items where id=@{id}", map[string]any{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 5 {
+		t.Fatalf("interceptor calls = %d, want 5", len(infos))
+	}
+	if infos[1].Kind != xdb.QueryKindExec || infos[1].RowsAffected != 1 || len(infos[1].Args) != 1 || infos[1].ConnName != "interceptor_test" {
+		t.Errorf("insert info = %+v", infos[1])
+	}
+	if infos[2].Kind != xdb.QueryKindQuery || infos[2].SQL != "select id from items" {
+		t.Errorf("query info = %+v", infos[2])
+	}
+	if !errors.Is(infos[3].Err, xdb.ErrQueryBlocked) {
+		t.Errorf("blocked info err = %v", infos[3].Err)
+	}
+
+	if _, err = NewDB("sqlite", NewConfig("interceptor_test", xdb.WithInterceptors("none"))); err == nil {
+		t.Errorf("NewDB() unknown interceptor should return error")
+	}
+}
@@ -0,0 +1,127 @@
+package xdb
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/zhiyunliu/glue/metrics"
+	"github.com/zhiyunliu/glue/standard"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// getMetricProvider 获取指标提供程序,测试时可替换
+var getMetricProvider = func(proto string) metrics.Provider {
+	return standard.GetInstance(metrics.TypeNode).(metrics.StandardMetric).GetProvider(proto)
+}
+
+// newMetricsInterceptor 配置了metrics时记录语句执行结果及耗时:
+// 计数器标签依次为: xdb.<query|exec|transaction>,连接名称,ok/error,空;
+// 耗时(秒)标签依次为: xdb.<query|exec|transaction>,连接名称
+func newMetricsInterceptor(connName string, cfg *xdb.Config) (xdb.Interceptor, error) {
+	if cfg.Metrics == "" {
+		return nil, nil
+	}
+	m := &dbMetrics{connName: connName, proto: cfg.Metrics}
+	return xdb.InterceptorFuncs{AfterFunc: m.observe}, nil
+}
+
+type dbMetrics struct {
+	connName string
+	proto    string
+	once     sync.Once
+	provider metrics.Provider
+}
+
+// getProvider 首次使用时获取指标提供程序,避免创建连接时metrics组件尚未初始化
+func (m *dbMetrics) getProvider() metrics.Provider {
+	m.once.Do(func() {
+		m.provider = getMetricProvider(m.proto)
+	})
+	return m.provider
+}
+
+func (m *dbMetrics) observe(ctx context.Context, info *xdb.QueryInfo) {
+	provider := m.getProvider()
+	if provider == nil {
+		return
+	}
+	kind := "xdb." + info.Kind
+	code := "ok"
+	if info.Err != nil {
+		code = "error"
+	}
+	if counter := provider.Counter(); counter != nil {
+		counter.With(kind, m.connName, code, "").Inc()
+	}
+	if observer := provider.Observer(); observer != nil {
+		observer.With(kind, m.connName).Observe(info.Duration.Seconds())
+	}
+}
+
+// PoolStats 定时将连接池状态写入metrics的Gauge,标签依次为: xdb.pool.<指标>,连接名称
+type PoolStats struct {
+	metrics  *dbMetrics
+	stats    func() sql.DBStats
+	interval time.Duration
+	closeCh  chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+}
+
+// StartPoolStats 配置了metrics时按metrics_interval采集连接池状态,未配置时返回nil
+func StartPoolStats(setting *Setting, stats func() sql.DBStats) *PoolStats {
+	if setting.Cfg.Metrics == "" || setting.Cfg.MetricsInterval <= 0 {
+		return nil
+	}
+	p := &PoolStats{
+		metrics:  &dbMetrics{connName: setting.ConnName, proto: setting.Cfg.Metrics},
+		stats:    stats,
+		interval: time.Duration(setting.Cfg.MetricsInterval) * time.Second,
+		closeCh:  make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.loop()
+	return p
+}
+
+func (p *PoolStats) loop() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.closeCh:
+			return
+		case <-ticker.C:
+			p.report()
+		}
+	}
+}
+
+func (p *PoolStats) report() {
+	provider := p.metrics.getProvider()
+	if provider == nil || provider.Gauge() == nil {
+		return
+	}
+	gauge := provider.Gauge()
+	stats := p.stats()
+	connName := p.metrics.connName
+	gauge.With("xdb.pool.open", connName).Set(float64(stats.OpenConnections))
+	gauge.With("xdb.pool.in_use", connName).Set(float64(stats.InUse))
+	gauge.With("xdb.pool.idle", connName).Set(float64(stats.Idle))
+	gauge.With("xdb.pool.wait_count", connName).Set(float64(stats.WaitCount))
+	gauge.With("xdb.pool.wait_duration", connName).Set(stats.WaitDuration.Seconds())
+}
+
+// Close 停止采集,PoolStats为nil时忽略
+func (p *PoolStats) Close() {
+	if p == nil {
+		return
+	}
+	p.once.Do(func() {
+		close(p.closeCh)
+	})
+	p.wg.Wait()
+}
@@ -0,0 +1,99 @@
+package xdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/zhiyunliu/glue/metrics"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+type testObserver struct {
+	values map[string]int
+	lvs    []string
+}
+
+func (o *testObserver) With(lvs ...string) metrics.Observer {
+	return &testObserver{values: o.values, lvs: lvs}
+}
+
+func (o *testObserver) Observe(float64) {
+	o.values[fmt.Sprint(o.lvs)]++
+}
+
+type testGauge struct {
+	values map[string]float64
+	lvs    []string
+}
+
+func (g *testGauge) With(lvs ...string) metrics.Gauge {
+	return &testGauge{values: g.values, lvs: lvs}
+}
+
+func (g *testGauge) Set(value float64) {
+	g.values[fmt.Sprint(g.lvs)] = value
+}
+
+func (g *testGauge) Add(delta float64) {
+	g.values[fmt.Sprint(g.lvs)] += delta
+}
+
+func (g *testGauge) Sub(delta float64) {
+	g.values[fmt.Sprint(g.lvs)] -= delta
+}
+
+type testProvider struct {
+	counter  *testCounter
+	observer *testObserver
+	gauge    *testGauge
+}
+
+func (p *testProvider) Name() string               { return "test" }
+func (p *testProvider) Counter() metrics.Counter   { return p.counter }
+func (p *testProvider) Observer() metrics.Observer { return p.observer }
+func (p *testProvider) Gauge() metrics.Gauge       { return p.gauge }
+func (p *testProvider) GetImpl() interface{}       { return nil }
+
+func TestMetrics(t *testing.T) {
+	provider := &testProvider{
+		counter:  &testCounter{counts: map[string]int{}},
+		observer: &testObserver{values: map[string]int{}},
+		gauge:    &testGauge{values: map[string]float64{}},
+	}
+	old := getMetricProvider
+	getMetricProvider = func(proto string) metrics.Provider { return provider }
+	t.Cleanup(func() { getMetricProvider = old })
+
+	dbobj := newSqliteTestDB(t, "metrics_test", xdb.WithMetrics("test"))
+	ctx := context.Background()
+
+	dbobj.Exec(ctx, "create table items(id integer primary key)", nil)
+	dbobj.Query(ctx, "select id from items", nil)
+	dbobj.Query(ctx, "select id from none", nil)
+	dbobj.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error { return nil })
+
+	wantCounts := map[string]int{
+		"[xdb.exec metrics_test ok ]":        1,
+		"[xdb.query metrics_test ok ]":       1,
+		"[xdb.query metrics_test error ]":    1,
+		"[xdb.transaction metrics_test ok ]": 1,
+	}
+	if fmt.Sprint(provider.counter.counts) != fmt.Sprint(wantCounts) {
+		t.Errorf("counter = %v, want %v", provider.counter.counts, wantCounts)
+	}
+	if provider.observer.values["[xdb.query metrics_test]"] != 2 {
+		t.Errorf("observer = %v", provider.observer.values)
+	}
+
+	stats := StartPoolStats(NewConfig("metrics_test", xdb.WithMetrics("test")), func() sql.DBStats { return sql.DBStats{OpenConnections: 3, InUse: 1, WaitCount: 5} })
+	stats.report()
+	stats.Close()
+	if provider.gauge.values["[xdb.pool.open metrics_test]"] != 3 || provider.gauge.values["[xdb.pool.wait_count metrics_test]"] != 5 {
+		t.Errorf("gauge = %v", provider.gauge.values)
+	}
+	if StartPoolStats(NewConfig("metrics_test"), nil) != nil {
+		t.Errorf("StartPoolStats() without metrics should return nil")
+	}
+}
@@ -0,0 +1,285 @@
+package xdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/zhiyunliu/glue/contrib/xdb/implement"
+	"github.com/zhiyunliu/glue/xdb"
+	"github.com/zhiyunliu/golibs/xreflect"
+)
+
+// ReturningStyle 插入语句获取自增值的方式
+type ReturningStyle int
+
+const (
+	//ReturningNone 通过LastInsertId获取(mysql,sqlite)
+	ReturningNone ReturningStyle = iota
+	//ReturningClause insert ... returning id (postgres)
+	ReturningClause
+	//ReturningOutput insert into t(...) output inserted.id values(...) (sql server)
+	ReturningOutput
+	//ReturningUnsupported 不支持获取自增值(oracle),自增列需要预先赋值(如取序列值)
+	ReturningUnsupported
+)
+
+// ErrReturningUnsupported 数据库不支持获取自增值时插入自增列为零值的数据
+var ErrReturningUnsupported = errors.New("xdb: 数据库不支持获取插入的自增值,自增列需要预先赋值")
+
+// ReturningExecuter 执行返回结果集的写语句(insert ... returning/output),
+// 与Exec相同在主库(或事务中)执行,经过拦截器并按写操作标记写入、失效查询缓存
+type ReturningExecuter interface {
+	ExecReturning(ctx context.Context, sql string, input any) (rows xdb.Rows, err error)
+}
+
+// UpsertStyle 插入或更新语句的语法
+type UpsertStyle int
+
+const (
+	//UpsertOnConflict insert ... on conflict(pk) do update set (postgres,sqlite)
+	UpsertOnConflict UpsertStyle = iota
+	//UpsertOnDuplicateKey insert ... on duplicate key update (mysql)
+	UpsertOnDuplicateKey
+	//UpsertMerge merge into ... using (select ...) (sql server)
+	UpsertMerge
+	//UpsertMergeDual merge into ... using (select ... from dual) (oracle)
+	UpsertMergeDual
+)
+
+// ModelDialect 按struct生成增删改查语句的方言
+type ModelDialect struct {
+	Returning ReturningStyle
+	Upsert    UpsertStyle
+}
+
+// ModelInsert 按struct插入一行数据,自增列为零值时由数据库生成并回写到obj;
+// 方言不支持获取自增值时返回ErrReturningUnsupported
+func ModelInsert(ctx context.Context, exec xdb.Executer, dialect *Dialect, obj any) (id int64, err error) {
+	table, rv, err := implement.GetModelTable(obj)
+	if err != nil {
+		return
+	}
+	cols := table.InsertColumns(rv)
+	params := table.Params(rv, cols)
+	names, values := columnList(cols, "@{", "}")
+	generated := table.Auto != nil && !containsColumn(cols, table.Auto)
+	if generated && dialect.Model.Returning == ReturningUnsupported {
+		return 0, fmt.Errorf("%w:%s.%s", ErrReturningUnsupported, table.Name, table.Auto.Name)
+	}
+
+	if !generated || dialect.Model.Returning == ReturningNone {
+		sql := fmt.Sprintf("insert into %s(%s) values(%s)", table.Name, names, values)
+		r, err := exec.Exec(ctx, sql, params)
+		if err != nil || !generated {
+			return 0, err
+		}
+		if id, err = r.LastInsertId(); err != nil {
+			return 0, err
+		}
+		table.SetAuto(rv, id)
+		return id, nil
+	}
+
+	var sql string
+	if dialect.Model.Returning == ReturningOutput {
+		sql = fmt.Sprintf("insert into %s(%s) output inserted.%s values(%s)", table.Name, names, table.Auto.Name, values)
+	} else {
+		sql = fmt.Sprintf("insert into %s(%s) values(%s) returning %s", table.Name, names, values, table.Auto.Name)
+	}
+	rows, err := execReturning(ctx, exec, sql, params)
+	if err != nil {
+		return
+	}
+	if len(rows) == 0 {
+		return 0, fmt.Errorf("插入数据未返回自增列:%s", table.Auto.Name)
+	}
+	if id, err = xreflect.GetInt64(rows[0][table.Auto.Name]); err != nil {
+		return
+	}
+	table.SetAuto(rv, id)
+	return id, nil
+}
+
+// execReturning exec实现ReturningExecuter时按写操作执行,否则在主库查询
+func execReturning(ctx context.Context, exec xdb.Executer, sql string, input any) (xdb.Rows, error) {
+	if rexec, ok := exec.(ReturningExecuter); ok {
+		return rexec.ExecReturning(ctx, sql, input)
+	}
+	return exec.Query(xdb.WithReadPrimary(ctx), sql, input)
+}
+
+// ModelUpdate 按主键更新除主键、自增列以外的所有列
+func ModelUpdate(ctx context.Context, exec xdb.Executer, obj any) (r xdb.Result, err error) {
+	table, rv, err := modelTableWithKeys(obj)
+	if err != nil {
+		return
+	}
+	cols := table.UpdateColumns()
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("表[%s]没有可更新的列", table.Name)
+	}
+	sets := make([]string, len(cols))
+	for i, col := range cols {
+		sets[i] = fmt.Sprintf("%s=@{%s}", col.Name, col.Name)
+	}
+	sql := fmt.Sprintf("update %s set %s where %s", table.Name, strings.Join(sets, ","), keyCondition(table, ""))
+	return exec.Exec(ctx, sql, table.Params(rv, table.Columns))
+}
+
+// ModelUpsert 按主键存在时更新,否则插入
+func ModelUpsert(ctx context.Context, exec xdb.Executer, dialect *Dialect, obj any) (r xdb.Result, err error) {
+	table, rv, err := modelTableWithKeys(obj)
+	if err != nil {
+		return
+	}
+	cols := table.InsertColumns(rv)
+	updates := table.UpdateColumns()
+	names, values := columnList(cols, "@{", "}")
+
+	var sql string
+	switch dialect.Model.Upsert {
+	case UpsertOnDuplicateKey:
+		sets := make([]string, 0, len(updates))
+		for _, col := range updates {
+			sets = append(sets, fmt.Sprintf("%s=values(%s)", col.Name, col.Name))
+		}
+		if len(sets) == 0 {
+			sets = append(sets, fmt.Sprintf("%s=%s", table.Keys[0].Name, table.Keys[0].Name))
+		}
+		sql = fmt.Sprintf("insert into %s(%s) values(%s) on duplicate key update %s", table.Name, names, values, strings.Join(sets, ","))
+	case UpsertMerge, UpsertMergeDual:
+		sql = buildMerge(dialect.Model.Upsert, table, cols, updates)
+	default:
+		keys := make([]string, len(table.Keys))
+		for i, col := range table.Keys {
+			keys[i] = col.Name
+		}
+		action := "do nothing"
+		if len(updates) > 0 {
+			sets := make([]string, len(updates))
+			for i, col := range updates {
+				sets[i] = fmt.Sprintf("%s=excluded.%s", col.Name, col.Name)
+			}
+			action = "do update set " + strings.Join(sets, ",")
+		}
+		sql = fmt.Sprintf("insert into %s(%s) values(%s) on conflict(%s) %s", table.Name, names, values, strings.Join(keys, ","), action)
+	}
+	return exec.Exec(ctx, sql, table.Params(rv, cols))
+}
+
+// ModelDeleteByKey 按主键删除
+func ModelDeleteByKey(ctx context.Context, exec xdb.Executer, obj any) (r xdb.Result, err error) {
+	table, rv, err := modelTableWithKeys(obj)
+	if err != nil {
+		return
+	}
+	sql := fmt.Sprintf("delete from %s where %s", table.Name, keyCondition(table, ""))
+	return exec.Exec(ctx, sql, table.Params(rv, table.Keys))
+}
+
+// ModelGetByKey 按obj中的主键值查询并填充obj,未找到时返回false
+func ModelGetByKey(ctx context.Context, exec xdb.Executer, obj any) (found bool, err error) {
+	table, rv, err := modelTableWithKeys(obj)
+	if err != nil {
+		return
+	}
+	names, _ := columnList(table.Columns, "", "")
+	sql := fmt.Sprintf("select %s from %s where %s", names, table.Name, keyCondition(table, ""))
+	results := reflect.New(reflect.SliceOf(rv.Type()))
+	if err = exec.QueryAs(ctx, sql, table.Params(rv, table.Keys), results.Interface()); err != nil {
+		return
+	}
+	if results.Elem().Len() == 0 {
+		return false, nil
+	}
+	rv.Set(results.Elem().Index(0))
+	return true, nil
+}
+
+func modelTableWithKeys(obj any) (table *implement.ModelTable, rv reflect.Value, err error) {
+	table, rv, err = implement.GetModelTable(obj)
+	if err != nil {
+		return
+	}
+	if len(table.Keys) == 0 {
+		err = fmt.Errorf("表[%s]未设置主键,请在db标签中添加pk选项", table.Name)
+	}
+	return
+}
+
+// buildMerge sql server,oracle 使用merge实现插入或更新;
+// sql server的自增列(identity)不能显式插入,未匹配时插入的列不包含自增列,由数据库生成;oracle的自增值由序列预先赋值,仍然插入
+func buildMerge(style UpsertStyle, table *implement.ModelTable, cols, updates []*implement.ModelColumn) string {
+	selects := make([]string, len(cols))
+	for i, col := range cols {
+		selects[i] = fmt.Sprintf("@{%s} %s", col.Name, col.Name)
+	}
+	source := "select " + strings.Join(selects, ",")
+	alias := " as "
+	if style == UpsertMergeDual {
+		source += " from dual"
+		alias = " "
+	}
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("merge into %s%starget using (%s)%ssource on (%s)",
+		table.Name, alias, source, alias, keyCondition(table, "source.")))
+	if len(updates) > 0 {
+		sets := make([]string, len(updates))
+		for i, col := range updates {
+			sets[i] = fmt.Sprintf("target.%s=source.%s", col.Name, col.Name)
+		}
+		builder.WriteString(" when matched then update set ")
+		builder.WriteString(strings.Join(sets, ","))
+	}
+	inserts := cols
+	if style == UpsertMerge {
+		inserts = make([]*implement.ModelColumn, 0, len(cols))
+		for _, col := range cols {
+			if !col.Auto {
+				inserts = append(inserts, col)
+			}
+		}
+	}
+	names, values := columnList(inserts, "source.", "")
+	builder.WriteString(fmt.Sprintf(" when not matched then insert (%s) values (%s)", names, values))
+	if style == UpsertMerge {
+		builder.WriteString(";")
+	}
+	return builder.String()
+}
+
+// keyCondition 主键条件,source为空时使用参数,否则使用source表的列
+func keyCondition(table *implement.ModelTable, source string) string {
+	conds := make([]string, len(table.Keys))
+	for i, col := range table.Keys {
+		if source == "" {
+			conds[i] = fmt.Sprintf("%s=@{%s}", col.Name, col.Name)
+			continue
+		}
+		conds[i] = fmt.Sprintf("target.%s=%s%s", col.Name, source, col.Name)
+	}
+	return strings.Join(conds, " and ")
+}
+
+// columnList 列名列表及以prefix,suffix包裹列名的值列表
+func columnList(cols []*implement.ModelColumn, prefix, suffix string) (names string, values string) {
+	nameList := make([]string, len(cols))
+	valueList := make([]string, len(cols))
+	for i, col := range cols {
+		nameList[i] = col.Name
+		valueList[i] = prefix + col.Name + suffix
+	}
+	return strings.Join(nameList, ","), strings.Join(valueList, ",")
+}
+
+func containsColumn(cols []*implement.ModelColumn, target *implement.ModelColumn) bool {
+	for _, col := range cols {
+		if col == target {
+			return true
+		}
+	}
+	return false
+}
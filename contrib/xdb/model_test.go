@@ -0,0 +1,157 @@
+package xdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+type modelUser struct {
+	ID   int64  `db:"id,pk,auto"`
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+}
+
+func (modelUser) TableName() string {
+	return "users"
+}
+
+// modelCode 非自增主键
+type modelCode struct {
+	Code string `db:"code,pk"`
+	Name string `db:"name"`
+}
+
+func (modelCode) TableName() string {
+	return "codes"
+}
+
+func TestModel_CRUD(t *testing.T) {
+	dbobj := newSqliteTestDB(t, "model_test")
+	ctx := context.Background()
+	mustExec(t, dbobj, "create table users(id integer primary key autoincrement,name text,age integer)")
+
+	user := &modelUser{Name: "a", Age: 1}
+	id, err := dbobj.Insert(ctx, user)
+	if err != nil || id != 1 || user.ID != 1 {
+		t.Fatalf("Insert() = %v,%v,%v", id, user.ID, err)
+	}
+	user.Age = 2
+	if _, err = dbobj.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dbobj.Upsert(ctx, &modelUser{ID: 5, Name: "b", Age: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dbobj.Upsert(ctx, &modelUser{ID: 5, Name: "c", Age: 6}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &modelUser{ID: 1}
+	if found, err := dbobj.GetByKey(ctx, got); err != nil || !found || got.Name != "a" || got.Age != 2 {
+		t.Fatalf("GetByKey() = %v,%+v,%v", found, got, err)
+	}
+	got = &modelUser{ID: 5}
+	if found, err := dbobj.GetByKey(ctx, got); err != nil || !found || got.Name != "c" || got.Age != 6 {
+		t.Fatalf("GetByKey() upsert = %v,%+v,%v", found, got, err)
+	}
+
+	err = dbobj.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+		_, err := tx.DeleteByKey(ctx, &modelUser{ID: 1})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := dbobj.GetByKey(ctx, &modelUser{ID: 1}); err != nil || found {
+		t.Fatalf("GetByKey() after delete = %v,%v", found, err)
+	}
+
+	if _, err = dbobj.Update(ctx, &struct{ Name string }{}); err == nil {
+		t.Errorf("Update() without pk should return error")
+	}
+}
+
+// sqlRecorder 只记录生成的SQL
+type sqlRecorder struct {
+	xdb.Executer
+	sql string
+}
+
+func (r *sqlRecorder) Exec(ctx context.Context, sql string, input any) (xdb.Result, error) {
+	r.sql = sql
+	return nil, nil
+}
+
+func TestModel_Upsert(t *testing.T) {
+	tests := []struct {
+		name  string
+		style UpsertStyle
+		obj   any
+		want  string
+	}{
+		{name: "conflict", style: UpsertOnConflict, want: "insert into users(id,name,age) values(@{id},@{name},@{age}) on conflict(id) do update set name=excluded.name,age=excluded.age"},
+		{name: "duplicate", style: UpsertOnDuplicateKey, want: "insert into users(id,name,age) values(@{id},@{name},@{age}) on duplicate key update name=values(name),age=values(age)"},
+		{name: "merge", style: UpsertMerge, want: "merge into users as target using (select @{id} id,@{name} name,@{age} age) as source on (target.id=source.id) when matched then update set target.name=source.name,target.age=source.age when not matched then insert (name,age) values (source.name,source.age);"},
+		{name: "merge key", style: UpsertMerge, obj: &modelCode{Code: "a", Name: "b"}, want: "merge into codes as target using (select @{code} code,@{name} name) as source on (target.code=source.code) when matched then update set target.name=source.name when not matched then insert (code,name) values (source.code,source.name);"},
+		{name: "dual", style: UpsertMergeDual, want: "merge into users target using (select @{id} id,@{name} name,@{age} age from dual) source on (target.id=source.id) when matched then update set target.name=source.name,target.age=source.age when not matched then insert (id,name,age) values (source.id,source.name,source.age)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &sqlRecorder{}
+			dialect := &Dialect{Model: ModelDialect{Upsert: tt.style}}
+			obj := tt.obj
+			if obj == nil {
+				obj = &modelUser{ID: 1}
+			}
+			if _, err := ModelUpsert(context.Background(), recorder, dialect, obj); err != nil {
+				t.Fatal(err)
+			}
+			if recorder.sql != tt.want {
+				t.Errorf("ModelUpsert() = %v, want %v", recorder.sql, tt.want)
+			}
+		})
+	}
+}
+
+func TestModel_InsertReturning(t *testing.T) {
+	RegisterDialect("sqlite", &Dialect{Model: ModelDialect{Returning: ReturningClause}})
+	t.Cleanup(func() { dialects.Delete("sqlite") })
+	var infos []xdb.QueryInfo
+	xdb.RegisterInterceptor("model_returning_test", func(connName string, cfg *xdb.Config) (xdb.Interceptor, error) {
+		return xdb.InterceptorFuncs{AfterFunc: func(ctx context.Context, info *xdb.QueryInfo) {
+			infos = append(infos, *info)
+		}}, nil
+	})
+
+	dbobj := newSqliteTestDB(t, "model_returning_test", xdb.WithInterceptors("model_returning_test"))
+	ctx := context.Background()
+	mustExec(t, dbobj, "create table users(id integer primary key autoincrement,name text,age integer)")
+
+	user := &modelUser{Name: "a", Age: 1}
+	if id, err := dbobj.Insert(ctx, user); err != nil || id != 1 || user.ID != 1 {
+		t.Fatalf("Insert() = %v,%v,%v", id, user.ID, err)
+	}
+	info := infos[len(infos)-1]
+	if info.Kind != xdb.QueryKindExec || info.RowsAffected != 1 {
+		t.Errorf("Insert() returning info = %+v", info)
+	}
+	err := dbobj.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+		_, err := tx.Insert(ctx, &modelUser{Name: "b"})
+		return err
+	})
+	if err != nil || infos[len(infos)-2].Kind != xdb.QueryKindExec {
+		t.Errorf("Insert() in transaction = %v,%+v", err, infos[len(infos)-2])
+	}
+
+	recorder := &sqlRecorder{}
+	dialect := &Dialect{Model: ModelDialect{Returning: ReturningUnsupported}}
+	if _, err := ModelInsert(ctx, recorder, dialect, &modelUser{Name: "c"}); !errors.Is(err, ErrReturningUnsupported) || recorder.sql != "" {
+		t.Errorf("ModelInsert() unsupported = %v,%s", err, recorder.sql)
+	}
+	if _, err := ModelInsert(ctx, recorder, dialect, &modelUser{ID: 3, Name: "c"}); err != nil || recorder.sql == "" {
+		t.Errorf("ModelInsert() assigned id = %v,%s", err, recorder.sql)
+	}
+}
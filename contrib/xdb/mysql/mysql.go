@@ -1,9 +1,10 @@
 package mysql
 
 import (
+	"errors"
 	"fmt"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/zhiyunliu/glue/config"
 	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
 	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
@@ -12,6 +13,9 @@ import (
 
 const Proto = "mysql"
 
+// MaxParams 单条语句允许的最大参数个数
+const MaxParams = 65535
+
 type mysqlResolver struct {
 }
 
@@ -30,5 +34,15 @@ func (s *mysqlResolver) Resolve(connName string, setting config.Config, opts ...
 func init() {
 	xdb.Register(&mysqlResolver{})
 	tpl.Register(tpl.NewFixed(Proto, "?"))
+	contribxdb.RegisterDialect(Proto, &contribxdb.Dialect{MaxParams: MaxParams, Retryable: Retryable, Model: contribxdb.ModelDialect{Upsert: contribxdb.UpsertOnDuplicateKey}})
+
+}
 
+// Retryable 死锁(1213)及锁等待超时(1205)时可以重试事务
+func Retryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
 }
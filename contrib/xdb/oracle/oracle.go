@@ -2,6 +2,7 @@ package oracle
 
 import (
 	"fmt"
+	"strings"
 
 	_ "github.com/mattn/go-oci8"
 	"github.com/zhiyunliu/glue/config"
@@ -12,6 +13,12 @@ import (
 
 const Proto = "oracle"
 
+// ArgumentPrefix 参数占位符前缀(:1,:2...)
+const ArgumentPrefix = ":"
+
+// MaxParams 单条语句允许的最大参数个数
+const MaxParams = 65535
+
 type oracleResolver struct {
 }
 
@@ -29,6 +36,34 @@ func (s *oracleResolver) Resolve(connName string, setting config.Config, opts ..
 }
 func init() {
 	xdb.Register(&oracleResolver{})
-	tpl.Register(tpl.NewSeq(Proto, ":"))
+	tpl.Register(New(Proto, ArgumentPrefix))
+	contribxdb.RegisterDialect(Proto, &contribxdb.Dialect{MaxParams: MaxParams, BulkInsert: BulkInsert, Savepoint: Savepoint, Retryable: Retryable, Model: contribxdb.ModelDialect{Returning: contribxdb.ReturningUnsupported, Upsert: contribxdb.UpsertMergeDual}})
 
 }
+
+// BulkInsert oracle不支持多行values,使用 insert all into ... select 1 from dual
+func BulkInsert(head string, values []string) string {
+	into := strings.TrimSpace(head)
+	if len(into) > 6 && strings.EqualFold(into[:6], "insert") {
+		into = strings.TrimSpace(into[6:])
+	}
+	builder := strings.Builder{}
+	builder.WriteString("insert all")
+	for i := range values {
+		builder.WriteString(" ")
+		builder.WriteString(into)
+		builder.WriteString(" ")
+		builder.WriteString(values[i])
+	}
+	builder.WriteString(" select 1 from dual")
+	return builder.String()
+}
+
+// Savepoint oracle不支持释放保存点,保存点在事务结束时释放
+var Savepoint = &contribxdb.SavepointDialect{
+	Create:   "SAVEPOINT %s",
+	Rollback: "ROLLBACK TO SAVEPOINT %s",
+}
+
+// Retryable 死锁(ORA-00060)及序列化失败(ORA-08177)时可以重试事务
+var Retryable = contribxdb.ErrorTextRetryable("ORA-00060", "ORA-08177")
@@ -0,0 +1,21 @@
+package oracle
+
+import (
+	"fmt"
+
+	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
+)
+
+// New 构建oracle模板,%{pi,ps}分页使用PageClause生成
+func New(name, prefix string) tpl.SQLTemplate {
+	return tpl.NewSeq(name, prefix, tpl.NewPageSymbol(PageClause))
+}
+
+// PageClause oracle分页语句,需要oracle 12c及以上版本并与order by一起使用,第一页使用fetch first;
+// 12c以下版本只支持rownum分页,rownum需要包裹整个查询,无法由%{}语句片段生成,请在SQL中自行编写
+func PageClause(offset, limit int64) string {
+	if offset == 0 {
+		return fmt.Sprintf("fetch first %d rows only", limit)
+	}
+	return tpl.OffsetFetch(offset, limit)
+}
@@ -12,6 +12,9 @@ import (
 
 const Proto = "postgres"
 
+// MaxParams 单条语句允许的最大参数个数
+const MaxParams = 65535
+
 type postgresResolver struct {
 }
 
@@ -30,5 +33,9 @@ func (s *postgresResolver) Resolve(connName string, setting config.Config, opts
 func init() {
 	xdb.Register(&postgresResolver{})
 	tpl.Register(tpl.NewFixed(Proto, "$"))
+	contribxdb.RegisterDialect(Proto, &contribxdb.Dialect{MaxParams: MaxParams, Retryable: Retryable, Model: contribxdb.ModelDialect{Returning: contribxdb.ReturningClause}})
 
 }
+
+// Retryable 序列化失败(40001)及死锁(40P01)时可以重试事务
+var Retryable = contribxdb.SQLStateRetryable("40001", "40P01")
@@ -0,0 +1,168 @@
+package xdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zhiyunliu/glue/contrib/xdb/implement"
+	"github.com/zhiyunliu/glue/log"
+	"github.com/zhiyunliu/glue/selector"
+	"github.com/zhiyunliu/glue/xdb"
+
+	_ "github.com/zhiyunliu/glue/selector/p2c"
+	_ "github.com/zhiyunliu/glue/selector/random"
+	_ "github.com/zhiyunliu/glue/selector/wrr"
+)
+
+// replicaNode 只读副本节点
+type replicaNode struct {
+	addr     string
+	connName string
+	weight   *int64
+	db       implement.ISysDB
+}
+
+func (n *replicaNode) Address() string {
+	return n.addr
+}
+
+func (n *replicaNode) ServiceName() string {
+	return n.connName
+}
+
+func (n *replicaNode) InitialWeight() *int64 {
+	return n.weight
+}
+
+func (n *replicaNode) Version() string {
+	return ""
+}
+
+func (n *replicaNode) Metadata() map[string]string {
+	return map[string]string{}
+}
+
+// replicaSet 只读副本集合,通过selector选择副本,并定时进行健康检查
+type replicaSet struct {
+	connName string
+	nodes    []*replicaNode
+	selector selector.Selector
+	interval time.Duration
+	closeCh  chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+}
+
+// newReplicaSet 创建副本集合,rawConns为各副本解析前的连接字符串,配置了secret_refresh时副本连接同样定时刷新
+func newReplicaSet(proto string, setting *Setting, rawConns []string) (set *replicaSet, err error) {
+	cfg := setting.Cfg
+	set = &replicaSet{
+		connName: setting.ConnName,
+		nodes:    make([]*replicaNode, 0, len(cfg.Replicas)),
+		interval: time.Duration(cfg.ReplicaCheckInterval) * time.Second,
+		closeCh:  make(chan struct{}),
+	}
+
+	balancer := cfg.ReplicaBalancer
+	if balancer == "" {
+		balancer = xdb.Default.ReplicaBalancer
+	}
+	set.selector, err = selector.GetSelector(balancer)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, replica := range cfg.Replicas {
+		node := &replicaNode{
+			addr:     fmt.Sprintf("%s#replica%d", setting.ConnName, i),
+			connName: setting.ConnName,
+		}
+		if replica.Weight > 0 {
+			weight := int64(replica.Weight)
+			node.weight = &weight
+		}
+		rawConn := replica.Conn
+		if i < len(rawConns) {
+			rawConn = rawConns[i]
+		}
+		node.db, err = newSysDB(proto, setting, node.addr, rawConn, replica.Conn)
+		if err != nil {
+			set.Close()
+			return nil, err
+		}
+		set.nodes = append(set.nodes, node)
+	}
+	set.apply(set.nodes)
+
+	if set.interval > 0 {
+		set.wg.Add(1)
+		go set.healthCheck()
+	}
+	return set, nil
+}
+
+// pick 选择一个可用副本,无可用副本时返回false
+func (s *replicaSet) pick(ctx context.Context) (db implement.ISysDB, done selector.DoneFunc, ok bool) {
+	selected, done, err := s.selector.Select(ctx)
+	if err != nil {
+		return nil, nil, false
+	}
+	node, ok := selected.(*replicaNode)
+	if !ok {
+		return nil, nil, false
+	}
+	return node.db, done, true
+}
+
+func (s *replicaSet) apply(nodes []*replicaNode) {
+	list := make([]selector.Node, len(nodes))
+	for i := range nodes {
+		list[i] = nodes[i]
+	}
+	s.selector.Apply(list)
+}
+
+// healthCheck 定时检查副本连接,剔除不可用的副本
+func (s *replicaSet) healthCheck() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	lastHealthy := len(s.nodes)
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-ticker.C:
+			healthy := make([]*replicaNode, 0, len(s.nodes))
+			for _, node := range s.nodes {
+				if err := node.db.Ping(); err != nil {
+					log.Warnf("xdb.replica:%s,ping error:%+v", node.addr, err)
+					continue
+				}
+				healthy = append(healthy, node)
+			}
+			if len(healthy) != lastHealthy {
+				log.Infof("xdb.replica:%s,healthy replicas:%d/%d", s.connName, len(healthy), len(s.nodes))
+			}
+			lastHealthy = len(healthy)
+			s.apply(healthy)
+		}
+	}
+}
+
+// Close 停止健康检查并关闭副本连接
+func (s *replicaSet) Close() (err error) {
+	s.once.Do(func() {
+		close(s.closeCh)
+	})
+	s.wg.Wait()
+	for _, node := range s.nodes {
+		if cerr := node.db.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	return
+}
@@ -0,0 +1,126 @@
+package xdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/zhiyunliu/glue/contrib/xdb/implement"
+	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+func init() {
+	tpl.Register(tpl.NewFixed("sqlite", "?"))
+}
+
+func newReplicaTestDB(t *testing.T) xdb.IDB {
+	replica := filepath.Join(t.TempDir(), "replica.db")
+	dbobj := newSqliteTestDB(t, "replica_test", func(cfg *xdb.Config) {
+		cfg.Replicas = []*xdb.ReplicaConfig{{Conn: replica, Weight: 10}}
+	})
+
+	//主库与副本写入不同的数据,用于区分路由
+	db := dbobj.(*xDB)
+	initData := map[string]implement.ISysDB{
+		"primary": db.db,
+		"replica": db.replicas.nodes[0].db,
+	}
+	for name, sysdb := range initData {
+		if _, err := sysdb.Exec("create table source(name varchar(20))"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sysdb.Exec("insert into source(name) values(?)", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dbobj
+}
+
+func TestReplicaRouting(t *testing.T) {
+	dbobj := newReplicaTestDB(t)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "1.", ctx: context.Background(), want: "replica"},
+		{name: "2.", ctx: xdb.WithReadPrimary(context.Background()), want: "primary"},
+		{name: "3.", ctx: xdb.WithReadYourWrites(context.Background()), want: "replica"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row, err := dbobj.First(tt.ctx, "select name from source", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := row.GetString("name"); got != tt.want {
+				t.Errorf("First() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplicaReadYourWrites(t *testing.T) {
+	dbobj := newReplicaTestDB(t)
+	ctx := xdb.WithReadYourWrites(context.Background())
+
+	_, err := dbobj.Exec(ctx, "update source set name=@{name}", map[string]any{"name": "written"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := dbobj.First(ctx, "select name from source", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := row.GetString("name"); got != "written" {
+		t.Errorf("First() after write = %v, want written", got)
+	}
+
+	rows, err := dbobj.Query(context.Background(), "select name from source", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Get(0).GetString("name") != "replica" {
+		t.Errorf("Query() without tracker = %v, want replica", rows)
+	}
+}
+
+func TestReplicaTransactionUsesPrimary(t *testing.T) {
+	dbobj := newReplicaTestDB(t)
+	err := dbobj.Transaction(func(tx xdb.Executer) error {
+		row, err := tx.First(context.Background(), "select name from source", nil)
+		if err != nil {
+			return err
+		}
+		if got := row.GetString("name"); got != "primary" {
+			t.Errorf("First() in transaction = %v, want primary", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplicaReadYourWrites_Transaction(t *testing.T) {
+	dbobj := newReplicaTestDB(t)
+	ctx := xdb.WithReadYourWrites(context.Background())
+
+	err := dbobj.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+		_, err := tx.Exec(ctx, "update source set name=@{name}", map[string]any{"name": "written"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := dbobj.First(ctx, "select name from source", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := row.GetString("name"); got != "written" {
+		t.Errorf("First() after transaction = %v, want written", got)
+	}
+}
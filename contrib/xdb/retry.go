@@ -0,0 +1,111 @@
+package xdb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/zhiyunliu/glue/metrics"
+	"github.com/zhiyunliu/glue/standard"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// RetryableFunc 判断事务错误是否可以重试
+type RetryableFunc func(err error) bool
+
+// SQLStateRetryable 按SQLSTATE判断错误是否可以重试,适用于实现了 SQLState() string 的驱动错误(lib/pq,pgx)
+func SQLStateRetryable(states ...string) RetryableFunc {
+	return func(err error) bool {
+		var stateErr interface{ SQLState() string }
+		if !errors.As(err, &stateErr) {
+			return false
+		}
+		for i := range states {
+			if stateErr.SQLState() == states[i] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ErrorNumberRetryable 按错误号判断错误是否可以重试,适用于实现了 SQLErrorNumber() int32 的驱动错误(go-mssqldb)
+func ErrorNumberRetryable(numbers ...int32) RetryableFunc {
+	return func(err error) bool {
+		var numberErr interface{ SQLErrorNumber() int32 }
+		if !errors.As(err, &numberErr) {
+			return false
+		}
+		for i := range numbers {
+			if numberErr.SQLErrorNumber() == numbers[i] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ErrorTextRetryable 按错误信息中的关键字判断错误是否可以重试
+func ErrorTextRetryable(keywords ...string) RetryableFunc {
+	return func(err error) bool {
+		msg := err.Error()
+		for i := range keywords {
+			if strings.Contains(msg, keywords[i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Retryer 事务重试,按数据库方言判断错误是否可以重试,
+// 配置了retry.metrics时通过metrics计数器记录重试(retry)及重试后最终失败(failed)的次数,
+// 计数器标签依次为: xdb,连接名称,retry/failed,空
+type Retryer struct {
+	connName  string
+	policy    *xdb.RetryPolicy
+	retryable RetryableFunc
+	counter   metrics.Counter
+	once      sync.Once
+}
+
+// NewRetryer 构建事务重试对象,未配置重试策略时返回nil
+func NewRetryer(setting *Setting, proto string) *Retryer {
+	policy := setting.Cfg.Retry
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return nil
+	}
+	return &Retryer{
+		connName:  setting.ConnName,
+		policy:    policy,
+		retryable: GetDialect(proto).Retryable,
+	}
+}
+
+// Do 执行事务,返回可重试错误时重新执行,Retryer为nil时只执行一次
+func (r *Retryer) Do(ctx context.Context, fn func() error) (err error) {
+	if r == nil || r.retryable == nil {
+		return fn()
+	}
+	err = r.policy.Do(ctx, r.retryable, func(attempt int, err error) {
+		r.report("retry")
+	}, fn)
+	if err != nil && r.retryable(err) {
+		r.report("failed")
+	}
+	return
+}
+
+func (r *Retryer) report(code string) {
+	r.once.Do(func() {
+		if r.policy.Metrics == "" {
+			return
+		}
+		provider := standard.GetInstance(metrics.TypeNode).(metrics.StandardMetric).GetProvider(r.policy.Metrics)
+		r.counter = provider.Counter()
+	})
+	if r.counter != nil {
+		r.counter.With("xdb", r.connName, code, "").Inc()
+	}
+}
@@ -0,0 +1,122 @@
+package xdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zhiyunliu/glue/metrics"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+var errRetryTest = errors.New("deadlock")
+
+type testCounter struct {
+	counts map[string]int
+	lvs    []string
+}
+
+func (c *testCounter) With(lvs ...string) metrics.Counter {
+	return &testCounter{counts: c.counts, lvs: lvs}
+}
+
+func (c *testCounter) Inc() {
+	c.Add(1)
+}
+
+func (c *testCounter) Add(delta float64) {
+	c.counts[fmt.Sprint(c.lvs)] += int(delta)
+}
+
+func TestRetryer(t *testing.T) {
+	RegisterDialect("retry_test", &Dialect{Retryable: func(err error) bool {
+		return errors.Is(err, errRetryTest)
+	}})
+	setting := NewConfig("retry_test", xdb.WithRetry(3, 1))
+
+	tests := []struct {
+		name      string
+		failTimes int
+		err       error
+		wantCalls int
+		wantErr   bool
+		wantCount map[string]int
+	}{
+		{name: "1.", failTimes: 2, err: errRetryTest, wantCalls: 3, wantCount: map[string]int{"[xdb retry_test retry ]": 2}},
+		{name: "2.", failTimes: 5, err: errRetryTest, wantCalls: 3, wantErr: true, wantCount: map[string]int{"[xdb retry_test retry ]": 2, "[xdb retry_test failed ]": 1}},
+		{name: "3.", failTimes: 5, err: errors.New("other"), wantCalls: 1, wantErr: true, wantCount: map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryer := NewRetryer(setting, "retry_test")
+			counter := &testCounter{counts: map[string]int{}}
+			retryer.once.Do(func() {})
+			retryer.counter = counter
+
+			calls := 0
+			err := retryer.Do(context.Background(), func() error {
+				calls++
+				if calls <= tt.failTimes {
+					return xdb.NewError(tt.err, "update t set a=1", nil)
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr || calls != tt.wantCalls {
+				t.Fatalf("Do() err = %v, calls = %d", err, calls)
+			}
+			if fmt.Sprint(counter.counts) != fmt.Sprint(tt.wantCount) {
+				t.Errorf("counts = %v, want %v", counter.counts, tt.wantCount)
+			}
+		})
+	}
+
+	if NewRetryer(NewConfig("retry_none"), "retry_test") != nil {
+		t.Errorf("NewRetryer() without policy should be nil")
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := &xdb.RetryPolicy{Backoff: 10, MaxBackoff: 50}
+	wants := []time.Duration{10, 20, 40, 50, 50}
+	for i, want := range wants {
+		if got := policy.Delay(i + 1); got != want*time.Millisecond {
+			t.Errorf("Delay(%d) = %v, want %v", i+1, got, want*time.Millisecond)
+		}
+	}
+	policy.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		if got := policy.Delay(1); got < 5*time.Millisecond || got > 15*time.Millisecond {
+			t.Errorf("Delay() with jitter = %v", got)
+		}
+	}
+}
+
+func TestTransaction_Retry(t *testing.T) {
+	RegisterDialect("sqlite", &Dialect{Retryable: func(err error) bool {
+		return errors.Is(err, errRetryTest)
+	}})
+	t.Cleanup(func() { dialects.Delete("sqlite") })
+
+	dbobj := newSqliteTestDB(t, "retry_trans", xdb.WithRetry(3, 1))
+	mustExec(t, dbobj, "create table items(id integer primary key)")
+
+	calls := 0
+	err := dbobj.Transaction(func(tx xdb.Executer) error {
+		calls++
+		if _, err := tx.Exec(context.Background(), "insert into items(id) values(@{id})", map[string]any{"id": calls}); err != nil {
+			return err
+		}
+		if calls == 1 {
+			return errRetryTest
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("Transaction() err = %v, calls = %d", err, calls)
+	}
+	if got := itemIDs(t, dbobj); got != "[2]" {
+		t.Errorf("items = %v, want [2]", got)
+	}
+}
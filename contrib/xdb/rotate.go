@@ -0,0 +1,156 @@
+package xdb
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/zhiyunliu/glue/contrib/xdb/implement"
+	"github.com/zhiyunliu/glue/log"
+	"github.com/zhiyunliu/glue/secret"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// rotatingDB 连接字符串为密钥引用(env://,vault:// 等)且配置了secret_refresh时,
+// 定时重新解析连接字符串,变化后使用新连接创建连接池并切换,旧连接池在下一个刷新周期关闭,
+// 已开始的查询及事务继续使用旧连接池完成;主库及副本各自刷新
+type rotatingDB struct {
+	proto    string
+	setting  *Setting
+	name     string
+	rawConn  string
+	conn     string
+	interval time.Duration
+
+	mu       sync.RWMutex
+	current  implement.ISysDB
+	retiring []implement.ISysDB
+
+	closeCh chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+// newSysDB 创建数据库连接池,name为连接池名称(主库为连接名,副本为 连接名#replicaN),
+// rawConn为解析前的连接字符串,conn为解析后的连接字符串,需要定时刷新时返回rotatingDB
+func newSysDB(proto string, setting *Setting, name, rawConn, conn string) (implement.ISysDB, error) {
+	current, err := openSysDB(proto, setting, name, conn)
+	if err != nil {
+		return nil, err
+	}
+	if setting.Cfg.SecretRefresh <= 0 || !secret.IsReference(rawConn) {
+		return current, nil
+	}
+	db := &rotatingDB{
+		proto:    proto,
+		setting:  setting,
+		name:     name,
+		rawConn:  rawConn,
+		conn:     conn,
+		interval: time.Duration(setting.Cfg.SecretRefresh) * time.Second,
+		current:  current,
+		closeCh:  make(chan struct{}),
+	}
+	db.wg.Add(1)
+	go db.loop()
+	return db, nil
+}
+
+func openSysDB(proto string, setting *Setting, name, conn string) (implement.ISysDB, error) {
+	return implement.NewSysDB(proto, conn,
+		implement.WithConnName(name),
+		implement.WithMaxOpen(setting.Cfg.MaxOpen),
+		implement.WithMaxIdle(setting.Cfg.MaxIdle),
+		implement.WithMaxLifeTime(setting.Cfg.LifeTime),
+	)
+}
+
+func (db *rotatingDB) loop() {
+	defer db.wg.Done()
+	ticker := time.NewTicker(db.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-db.closeCh:
+			return
+		case <-ticker.C:
+			db.closeRetiring()
+			if err := db.refresh(); err != nil {
+				log.Warnf("xdb.secret:%s,refresh error:%+v", db.name, err)
+			}
+		}
+	}
+}
+
+// refresh 重新解析连接字符串,变化时切换连接池;副本的连接字符串同样作为Conn解析
+func (db *rotatingDB) refresh() error {
+	cfg := *db.setting.Cfg
+	cfg.Conn = db.rawConn
+	cfg.Replicas = nil
+	newCfg, err := xdb.DefaultRefactor(db.name, &cfg)
+	if err != nil {
+		return err
+	}
+	if newCfg.Conn == db.conn {
+		return nil
+	}
+	next, err := openSysDB(db.proto, db.setting, db.name, newCfg.Conn)
+	if err != nil {
+		return err
+	}
+	db.mu.Lock()
+	db.retiring = append(db.retiring, db.current)
+	db.current = next
+	db.conn = newCfg.Conn
+	db.mu.Unlock()
+	log.Infof("xdb.secret:%s,connection rotated", db.name)
+	return nil
+}
+
+func (db *rotatingDB) closeRetiring() {
+	db.mu.Lock()
+	retiring := db.retiring
+	db.retiring = nil
+	db.mu.Unlock()
+	for _, old := range retiring {
+		if err := old.Close(); err != nil {
+			log.Warnf("xdb.secret:%s,close retired pool error:%+v", db.name, err)
+		}
+	}
+}
+
+func (db *rotatingDB) get() implement.ISysDB {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.current
+}
+
+func (db *rotatingDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.get().Query(query, args...)
+}
+
+func (db *rotatingDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.get().Exec(query, args...)
+}
+
+func (db *rotatingDB) Begin() (implement.ISysTrans, error) {
+	return db.get().Begin()
+}
+
+func (db *rotatingDB) Ping() error {
+	return db.get().Ping()
+}
+
+func (db *rotatingDB) Stats() sql.DBStats {
+	return db.get().Stats()
+}
+
+// Close 停止刷新并关闭当前及待关闭的连接池
+func (db *rotatingDB) Close() error {
+	db.once.Do(func() {
+		close(db.closeCh)
+	})
+	db.wg.Wait()
+	db.closeRetiring()
+	return db.get().Close()
+}
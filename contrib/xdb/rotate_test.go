@@ -0,0 +1,94 @@
+package xdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+func TestRotatingDB(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDB_ROTATE_TEST_CONN", filepath.Join(dir, "a.db"))
+
+	setting := NewConfig("rotate_test", xdb.WithSecretRefresh(3600))
+	setting.Cfg.Conn = "env://XDB_ROTATE_TEST_CONN"
+	dbobj, err := NewDB("sqlite", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbobj.Close()
+	ctx := context.Background()
+	rotating, ok := dbobj.(*xDB).db.(*rotatingDB)
+	if !ok {
+		t.Fatalf("db = %T, want *rotatingDB", dbobj.(*xDB).db)
+	}
+
+	if _, err = dbobj.Exec(ctx, "create table items(name text)", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = rotating.refresh(); err != nil || len(rotating.retiring) != 0 {
+		t.Fatalf("refresh() unchanged = %v,%d", err, len(rotating.retiring))
+	}
+
+	t.Setenv("XDB_ROTATE_TEST_CONN", filepath.Join(dir, "b.db"))
+	tx, err := dbobj.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rotating.refresh(); err != nil || len(rotating.retiring) != 1 {
+		t.Fatalf("refresh() changed = %v,%d", err, len(rotating.retiring))
+	}
+	if _, err = tx.Exec(ctx, "insert into items(name) values('a')", nil); err != nil {
+		t.Fatalf("transaction on retired pool: %v", err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	rotating.closeRetiring()
+
+	if _, err = dbobj.Query(ctx, "select name from items", nil); err == nil {
+		t.Errorf("Query() should use rotated connection without items table")
+	}
+	if _, err = dbobj.Exec(ctx, "create table items(name text)", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := NewConfig("rotate_test", xdb.WithSecretRefresh(3600))
+	plain.Cfg.Conn = filepath.Join(dir, "c.db")
+	plainDB, err := NewDB("sqlite", plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plainDB.Close()
+	if _, ok := plainDB.(*xDB).db.(*rotatingDB); ok {
+		t.Errorf("plain connection string should not rotate")
+	}
+}
+
+func TestRotatingDB_Replica(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDB_ROTATE_TEST_REPLICA", filepath.Join(dir, "r1.db"))
+
+	setting := NewConfig("rotate_replica", xdb.WithSecretRefresh(3600))
+	setting.Cfg.Conn = filepath.Join(dir, "primary.db")
+	setting.Cfg.Replicas = []*xdb.ReplicaConfig{{Conn: "env://XDB_ROTATE_TEST_REPLICA"}}
+	dbobj, err := NewDB("sqlite", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbobj.Close()
+	if _, ok := dbobj.(*xDB).db.(*rotatingDB); ok {
+		t.Errorf("plain primary connection should not rotate")
+	}
+	rotating, ok := dbobj.(*xDB).replicas.nodes[0].db.(*rotatingDB)
+	if !ok {
+		t.Fatalf("replica db = %T, want *rotatingDB", dbobj.(*xDB).replicas.nodes[0].db)
+	}
+
+	t.Setenv("XDB_ROTATE_TEST_REPLICA", filepath.Join(dir, "r2.db"))
+	if err = rotating.refresh(); err != nil || rotating.conn != filepath.Join(dir, "r2.db") {
+		t.Fatalf("refresh() = %v, conn = %s", err, rotating.conn)
+	}
+}
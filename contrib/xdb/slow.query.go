@@ -6,19 +6,26 @@ import (
 	"time"
 
 	"github.com/zhiyunliu/glue/contrib/xdb/implement"
+	"github.com/zhiyunliu/glue/xdb"
 )
 
-// 打印慢sql
-func printSlowQuery(ctx context.Context, setting *Setting, timeRange time.Duration, query string, args ...interface{}) {
-	if !setting.Cfg.ShowQueryLog {
-		return
+// newSlowQueryInterceptor 开启慢查询日志时,打印执行时长超过阈值的sql
+func newSlowQueryInterceptor(connName string, cfg *xdb.Config) (xdb.Interceptor, error) {
+	if !cfg.ShowQueryLog || cfg.LoggerName == "" {
+		return nil, nil
 	}
-	if setting.logger == nil {
-		return
+	threshold := time.Duration(cfg.LongQueryTime) * time.Millisecond
+	if threshold <= 0 {
+		return nil, nil
 	}
-
-	if setting.slowThreshold <= 0 || timeRange < setting.slowThreshold {
-		return
+	logger, ok := xdb.GetLogger(cfg.LoggerName)
+	if !ok {
+		return nil, nil
 	}
-	setting.logger.Log(ctx, timeRange.Milliseconds(), fmt.Sprintf("[%s] %s", setting.ConnName, query), implement.Unwrap(args...)...)
+	return xdb.InterceptorFuncs{AfterFunc: func(ctx context.Context, info *xdb.QueryInfo) {
+		if info.Err != nil || info.Kind == xdb.QueryKindTransaction || info.Duration < threshold {
+			return
+		}
+		logger.Log(ctx, info.Duration.Milliseconds(), fmt.Sprintf("[%s] %s", connName, info.SQL), implement.Unwrap(info.Args...)...)
+	}}, nil
 }
@@ -1,9 +1,10 @@
 package sqlite
 
 import (
+	"errors"
 	"fmt"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 	"github.com/zhiyunliu/glue/config"
 	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
 	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
@@ -12,6 +13,9 @@ import (
 
 const Proto = "sqlite"
 
+// MaxParams 单条语句允许的最大参数个数(SQLITE_MAX_VARIABLE_NUMBER)
+const MaxParams = 999
+
 type sqliteResolver struct {
 }
 
@@ -30,4 +34,14 @@ func (s *sqliteResolver) Resolve(connName string, setting config.Config, opts ..
 func init() {
 	xdb.Register(&sqliteResolver{})
 	tpl.Register(tpl.NewFixed(Proto, "?"))
+	contribxdb.RegisterDialect(Proto, &contribxdb.Dialect{MaxParams: MaxParams, Retryable: Retryable})
+}
+
+// Retryable 数据库被占用(SQLITE_BUSY,SQLITE_LOCKED)时可以重试事务
+func Retryable(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
 }
@@ -13,6 +13,12 @@ import (
 const Proto = "sqlserver"
 const ArgumentPrefix = "p_"
 
+// MaxParams 单条语句允许的最大参数个数;协议上限为2100,sp_executesql自身占用2个,用户参数最多2098
+const MaxParams = 2098
+
+// MaxRows 单条insert语句values允许的最大行数(表值构造函数上限)
+const MaxRows = 1000
+
 type sqlserverResolver struct {
 }
 
@@ -32,4 +38,8 @@ func (s *sqlserverResolver) Resolve(connName string, setting config.Config, opts
 func init() {
 	xdb.Register(&sqlserverResolver{})
 	tpl.Register(New(Proto, ArgumentPrefix))
+	contribxdb.RegisterDialect(Proto, &contribxdb.Dialect{MaxParams: MaxParams, MaxRows: MaxRows, Savepoint: contribxdb.TransactSavepoint, Retryable: Retryable, Model: contribxdb.ModelDialect{Returning: contribxdb.ReturningOutput, Upsert: contribxdb.UpsertMerge}})
 }
+
+// Retryable 被选为死锁牺牲品(1205)时可以重试事务
+var Retryable = contribxdb.ErrorNumberRetryable(1205)
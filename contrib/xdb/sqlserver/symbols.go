@@ -24,6 +24,8 @@ func newMssqlSymbols(operMap tpl.OperatorMap) tpl.SymbolMap {
 		return argName, nil
 	})
 
+	symbols.LoadOrStore(tpl.SymbolIdentifier, tpl.IdentifierCallback)
+
 	symbols.LoadOrStore(tpl.SymbolAnd, func(input tpl.DBParam, fullKey string, item *tpl.ReplaceItem) (string, xdb.MissError) {
 		item.HasAndOper = true
 
@@ -33,15 +35,19 @@ func newMssqlSymbols(operMap tpl.OperatorMap) tpl.SymbolMap {
 			return "", xdb.NewMissOperError(oper)
 		}
 
-		if ph, ok := item.NameCache[propName]; ok {
+		argMode := tpl.GetArgMode(oper)
+		if ph, ok := item.NameCache[propName]; ok && argMode == tpl.ArgSingle {
 			return opercall(tpl.SymbolAnd, fullField, ph), nil
 			//return fmt.Sprintf("and %s=%s ", fullKey, ph), nil
 		}
-		argName, value, _ := input.Get(propName, item.Placeholder)
-		if !tpl.IsNil(value) {
-			item.Names = append(item.Names, propName)
-			item.Values = append(item.Values, value)
-			item.NameCache[propName] = argName
+		argName, ok, err := tpl.ConditionArg(input, propName, oper, item)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			if argMode == tpl.ArgSingle {
+				item.NameCache[propName] = argName
+			}
 			return opercall(tpl.SymbolAnd, fullField, argName), nil
 			//return fmt.Sprintf("and %s=%s ", fullKey, argName), nil
 		}
@@ -56,16 +62,19 @@ func newMssqlSymbols(operMap tpl.OperatorMap) tpl.SymbolMap {
 		if !ok {
 			return "", xdb.NewMissOperError(oper)
 		}
-		if ph, ok := item.NameCache[propName]; ok {
+		argMode := tpl.GetArgMode(oper)
+		if ph, ok := item.NameCache[propName]; ok && argMode == tpl.ArgSingle {
 			return opercall(tpl.SymbolOr, fullField, ph), nil
 			//return fmt.Sprintf("or %s=%s ", fullKey, ph), nil
 		}
-		argName, value, _ := input.Get(propName, item.Placeholder)
-		if !tpl.IsNil(value) {
-			item.Names = append(item.Names, propName)
-			item.Values = append(item.Values, value)
-			item.NameCache[propName] = argName
-
+		argName, ok, err := tpl.ConditionArg(input, propName, oper, item)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			if argMode == tpl.ArgSingle {
+				item.NameCache[propName] = argName
+			}
 			return opercall(tpl.SymbolOr, fullField, argName), nil
 			//return fmt.Sprintf("or %s=%s ", fullKey, argName), nil
 		}
@@ -44,11 +44,13 @@ func (ph *mssqlPlaceHolder) Clone() tpl.Placeholder {
 }
 
 func New(name, prefix string) tpl.SQLTemplate {
-	return &MssqlContext{
+	ctx := &MssqlContext{
 		name:    name,
 		prefix:  prefix,
 		symbols: newMssqlSymbols(tpl.DefaultOperator.Clone()),
 	}
+	ctx.symbols.Register(tpl.NewPageSymbol(tpl.OffsetFetch))
+	return ctx
 }
 
 func (ctx *MssqlContext) Name() string {
@@ -68,6 +70,10 @@ func (ctx *MssqlContext) AnalyzeTPL(template string, input map[string]interface{
 	return tpl.DefaultAnalyze(ctx.symbols, template, input, ph)
 }
 
+func (ctx *MssqlContext) Symbols() tpl.SymbolMap {
+	return ctx.symbols
+}
+
 func (ctx *MssqlContext) RegisterSymbol(symbol tpl.Symbol) error {
 	return ctx.symbols.Register(symbol)
 }
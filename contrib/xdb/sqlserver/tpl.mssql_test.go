@@ -147,6 +147,19 @@ func TestMssqlContext_AnalyzeTPL(t *testing.T) {
 				HasAndOper:  true,
 				HasOrOper:   true,
 			}},
+
+		{name: "3a.", args: args{template: `select * from a where 1=1 &{in a.status} &{between a.create_time} |{is null a.remark}`, input: map[string]interface{}{"status": []int{1, 2}, "create_time": []string{"2023-01-01", "2023-02-01"}, "remark": true}, ph: ph},
+			want: "select * from a where 1=1 and a.status in (@p_status_0,@p_status_1) and a.create_time between @p_create_time_0 and @p_create_time_1 or a.remark is null",
+			want1: &tpl.ReplaceItem{Names: []string{"status", "status", "create_time", "create_time"},
+				Values: []any{
+					sql.NamedArg{Name: "p_status_0", Value: 1}, sql.NamedArg{Name: "p_status_1", Value: 2},
+					sql.NamedArg{Name: "p_create_time_0", Value: "2023-01-01"}, sql.NamedArg{Name: "p_create_time_1", Value: "2023-02-01"},
+				},
+				NameCache:   map[string]string{},
+				Placeholder: ph,
+				HasAndOper:  true,
+				HasOrOper:   true,
+			}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -160,3 +173,17 @@ func TestMssqlContext_AnalyzeTPL(t *testing.T) {
 		})
 	}
 }
+
+func TestMssqlContext_Page(t *testing.T) {
+	ctx := New("mssql_page", ArgumentPrefix)
+	query, args, err := ctx.GetSQLContext("select * from a where a.id=@{id} order by a.id %{pi,ps}", map[string]interface{}{"id": 1, "pi": 2, "ps": 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "select * from a where a.id=@p_id order by a.id offset 15 rows fetch next 15 rows only"; query != want {
+		t.Errorf("GetSQLContext() got[%v], want[%v]", query, want)
+	}
+	if len(args) != 1 {
+		t.Errorf("GetSQLContext() args[%v]", args)
+	}
+}
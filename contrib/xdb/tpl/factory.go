@@ -13,15 +13,22 @@ var (
 )
 
 const (
-	TotalPattern = `[@]\{\w*[\.]?\w+\}|[&]\{like\s+%?\w*[\.]?\w+%?\s?\}|[&]\{(>|>=|<|<=)?\s*\w*[\.]?\w+\s?\}|[\|]\{like\s+%?\w*[\.]?\w+%?\s?\}|[\|]\{(>|>=|<|<=)?\s*\w*[\.]?\w+\s?\}`
+	TotalPattern = `[@]\{\w*[\.]?\w+\}|[#]\{\w+:\w+\}|[&]\{like\s+%?\w*[\.]?\w+%?\s?\}|[&]\{(in|not\s+in|between|is\s+null|is\s+not\s+null)\s+\w*[\.]?\w+\s?\}|[&]\{(>|>=|<|<=|!=)?\s*\w*[\.]?\w+\s?\}|[\|]\{like\s+%?\w*[\.]?\w+%?\s?\}|[\|]\{(in|not\s+in|between|is\s+null|is\s+not\s+null)\s+\w*[\.]?\w+\s?\}|[\|]\{(>|>=|<|<=|!=)?\s*\w*[\.]?\w+\s?\}`
 	// ParamPattern   = `[@]\{\w*[\.]?\w+\}`
 	// AndPattern     = `[&]\{\w*[\.]?\w+\}`
 	// OrPattern      = `[\|]\{\w*[\.]?\w+\}`
 	ReplacePattern = `\$\{\w*[\.]?\w+\}`
+	// IdentifierPattern #{白名单:参数名}
+	IdentifierPattern = `[#]\{\w+:\w+\}`
+	// PagePattern %{页码参数名,每页条数参数名} 或 %{条数参数名}
+	PagePattern = `[%]\{\s*\w+\s*(,\s*\w+\s*)?\}`
 
 	SymbolAt  = "@"
 	SymbolAnd = "&"
 	SymbolOr  = "|"
+
+	SymbolIdentifier = "#"
+	SymbolPage       = "%"
 )
 
 // 符号回调函数
@@ -36,6 +43,8 @@ type SymbolMap interface {
 	Delete(name string)
 	Load(name string) (SymbolCallback, bool)
 	Clone() SymbolMap
+	//Version 符号表版本,符号表变更后改变,用于执行计划缓存失效
+	Version() uint64
 }
 
 type OperatorCallback func(string, string, string) string
@@ -70,6 +79,7 @@ type SQLTemplate interface {
 	Placeholder() Placeholder
 	GetSQLContext(tpl string, input map[string]interface{}) (query string, args []any, err error)
 	AnalyzeTPL(tpl string, input map[string]interface{}, ph Placeholder) (sql string, item *ReplaceItem, err error)
+	Symbols() SymbolMap
 	RegisterSymbol(symbol Symbol) error
 	RegisterOperator(Operator) error
 }
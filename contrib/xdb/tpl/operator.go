@@ -2,9 +2,24 @@ package tpl
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 )
 
+// ArgMode 操作符的参数绑定方式
+type ArgMode int
+
+const (
+	//ArgSingle 绑定单个参数
+	ArgSingle ArgMode = iota
+	//ArgList 切片参数展开为多个占位符,如 in (?,?,?)
+	ArgList
+	//ArgNone 不绑定参数,参数值只决定条件是否生效,如 is null
+	ArgNone
+)
+
+var argModes sync.Map
+
 type operatorMap struct {
 	syncMap *sync.Map
 }
@@ -39,6 +54,10 @@ func init() {
 		return fmt.Sprintf("%s %s<=%s", getConcat(symbol), fullkey, argName)
 	})
 
+	DefaultOperator.LoadOrStore("!=", func(symbol, fullkey, argName string) string {
+		return fmt.Sprintf("%s %s<>%s", getConcat(symbol), fullkey, argName)
+	})
+
 	DefaultOperator.LoadOrStore("like", func(symbol, fullkey, argName string) string {
 		return fmt.Sprintf("%s %s like %s", getConcat(symbol), fullkey, argName)
 	})
@@ -55,6 +74,52 @@ func init() {
 		return fmt.Sprintf("%s %s like '%%'+%s+'%%'", getConcat(symbol), fullkey, argName)
 	})
 
+	//argName 为逗号分隔的占位符列表,空列表时in恒为假,not in恒为真
+	DefaultOperator.LoadOrStore("in", func(symbol, fullkey, argName string) string {
+		if argName == "" {
+			return fmt.Sprintf("%s 1=0", getConcat(symbol))
+		}
+		return fmt.Sprintf("%s %s in (%s)", getConcat(symbol), fullkey, argName)
+	})
+
+	DefaultOperator.LoadOrStore("not in", func(symbol, fullkey, argName string) string {
+		if argName == "" {
+			return fmt.Sprintf("%s 1=1", getConcat(symbol))
+		}
+		return fmt.Sprintf("%s %s not in (%s)", getConcat(symbol), fullkey, argName)
+	})
+
+	DefaultOperator.LoadOrStore("between", func(symbol, fullkey, argName string) string {
+		args := strings.SplitN(argName, ",", 2)
+		return fmt.Sprintf("%s %s between %s and %s", getConcat(symbol), fullkey, args[0], args[len(args)-1])
+	})
+
+	DefaultOperator.LoadOrStore("is null", func(symbol, fullkey, argName string) string {
+		return fmt.Sprintf("%s %s is null", getConcat(symbol), fullkey)
+	})
+
+	DefaultOperator.LoadOrStore("is not null", func(symbol, fullkey, argName string) string {
+		return fmt.Sprintf("%s %s is not null", getConcat(symbol), fullkey)
+	})
+
+	RegisterArgMode("in", ArgList)
+	RegisterArgMode("not in", ArgList)
+	RegisterArgMode("between", ArgList)
+	RegisterArgMode("is null", ArgNone)
+	RegisterArgMode("is not null", ArgNone)
+}
+
+// RegisterArgMode 设置操作符的参数绑定方式,未设置的操作符绑定单个参数
+func RegisterArgMode(oper string, mode ArgMode) {
+	argModes.Store(oper, mode)
+}
+
+// GetArgMode 获取操作符的参数绑定方式
+func GetArgMode(oper string) ArgMode {
+	if mode, ok := argModes.Load(oper); ok {
+		return mode.(ArgMode)
+	}
+	return ArgSingle
 }
 
 func getConcat(symbol string) (concat string) {
@@ -84,6 +149,9 @@ func (m *operatorMap) LoadOrStore(name string, callback OperatorCallback) (loade
 
 func (m *operatorMap) Load(name string) (OperatorCallback, bool) {
 	callback, ok := m.syncMap.Load(name)
+	if !ok {
+		return nil, false
+	}
 	return callback.(OperatorCallback), ok
 }
 
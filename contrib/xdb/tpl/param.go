@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
@@ -33,13 +34,46 @@ func (p DBParam) GetVal(name string) (val interface{}, err xdb.MissError) {
 		err = xdb.NewMissParamError(name)
 		return
 	}
+	return convertVal(val), nil
+}
+
+// GetList 将切片参数展开为多个参数,每个元素使用独立的占位符,用于in/not in/between
+func (p DBParam) GetList(name string, ph Placeholder) (phNames []string, argVals []interface{}, err xdb.MissError) {
+	val, ok := p[name]
+	if !ok {
+		err = xdb.NewMissParamError(name)
+		return
+	}
+	items := []interface{}{val}
+	if rv := reflect.ValueOf(val); (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8 {
+		items = make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+	}
+	phNames = make([]string, 0, len(items))
+	argVals = make([]interface{}, 0, len(items))
+	for i := range items {
+		item := convertVal(items[i])
+		if tmpv, ok := item.(sql.NamedArg); ok {
+			phNames = append(phNames, ph.NamedArg(tmpv.Name))
+			argVals = append(argVals, tmpv)
+			continue
+		}
+		argName, phName := ph.Get(fmt.Sprintf("%s_%d", name, i))
+		phNames = append(phNames, phName)
+		argVals = append(argVals, ph.BuildArgVal(argName, item))
+	}
+	return
+}
+
+// convertVal 转换为数据库参数值
+func convertVal(val interface{}) interface{} {
 	switch t := val.(type) {
 	case sql.NamedArg:
 		val = t
-		return
 	case *sql.NamedArg:
 		val = *t
-		return
 	case driver.Valuer:
 		val, _ = t.Value()
 	case time.Time:
@@ -57,7 +91,7 @@ func (p DBParam) GetVal(name string) (val interface{}, err xdb.MissError) {
 	case driver.Value:
 		val = t
 	}
-	return
+	return val
 }
 
 func TransArgs(args []sql.NamedArg) []interface{} {
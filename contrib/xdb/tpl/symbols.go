@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/zhiyunliu/glue/xdb"
 )
@@ -18,10 +19,14 @@ func init() {
 	GetPropName = DefaultGetPropName
 }
 
+// symbolsVersion 全局递增的符号表版本,保证不同符号表及同一符号表变更前后的版本都不相同
+var symbolsVersion uint64
+
 type symbolsMap struct {
 	pattern string
 	syncMap *sync.Map
 	operMap OperatorMap
+	version uint64
 }
 
 func NewSymbolMap(operMap OperatorMap) SymbolMap {
@@ -29,9 +34,18 @@ func NewSymbolMap(operMap OperatorMap) SymbolMap {
 		pattern: TotalPattern,
 		syncMap: &sync.Map{},
 		operMap: operMap,
+		version: atomic.AddUint64(&symbolsVersion, 1),
 	}
 }
 
+func (m *symbolsMap) Version() uint64 {
+	return atomic.LoadUint64(&m.version)
+}
+
+func (m *symbolsMap) changed() {
+	atomic.StoreUint64(&m.version, atomic.AddUint64(&symbolsVersion, 1))
+}
+
 func (m *symbolsMap) GetPattern() string {
 	return m.pattern
 }
@@ -48,6 +62,7 @@ func (m *symbolsMap) Register(symbol Symbol) error {
 			return fmt.Errorf("表达式:%s,不是有效的正则,%w", pattern, err)
 		}
 		m.pattern = m.pattern + "|" + pattern
+		m.changed()
 	}
 	return nil
 }
@@ -62,6 +77,9 @@ func (m *symbolsMap) Operator(oper Operator) error {
 
 func (m *symbolsMap) LoadOrStore(name string, callback SymbolCallback) (loaded bool) {
 	_, loaded = m.syncMap.LoadOrStore(name, callback)
+	if !loaded {
+		m.changed()
+	}
 	return
 }
 
@@ -71,15 +89,20 @@ func (m *symbolsMap) LoadOperator(oper string) (callback OperatorCallback, loade
 }
 func (m *symbolsMap) Delete(name string) {
 	m.syncMap.Delete(name)
+	m.changed()
 }
 
 func (m *symbolsMap) Load(name string) (SymbolCallback, bool) {
 	callback, ok := m.syncMap.Load(name)
+	if !ok {
+		return nil, false
+	}
 	return callback.(SymbolCallback), ok
 }
 
 func (m *symbolsMap) Clone() SymbolMap {
-	clone := NewSymbolMap(m.operMap.Clone())
+	clone := NewSymbolMap(m.operMap.Clone()).(*symbolsMap)
+	clone.pattern = m.pattern
 	m.syncMap.Range(func(key, value any) bool {
 		clone.LoadOrStore(key.(string), value.(SymbolCallback))
 		return true
@@ -89,6 +112,20 @@ func (m *symbolsMap) Clone() SymbolMap {
 
 var defaultSymbols SymbolMap //  Symbols
 
+// cloneDefaultSymbols 复制默认符号表,symbols按名称覆盖其中的同名符号
+func cloneDefaultSymbols(symbols ...Symbol) SymbolMap {
+	clone := defaultSymbols.Clone()
+	for _, symbol := range symbols {
+		if _, ok := clone.Load(symbol.Name()); ok {
+			clone.Delete(symbol.Name())
+			clone.LoadOrStore(symbol.Name(), symbol.Callback)
+			continue
+		}
+		clone.Register(symbol)
+	}
+	return clone
+}
+
 func init() {
 	defaultSymbols = NewSymbolMap(DefaultOperator)
 	defaultSymbols.LoadOrStore(SymbolAt, func(input DBParam, fullKey string, item *ReplaceItem) (string, xdb.MissError) {
@@ -107,6 +144,10 @@ func init() {
 		return argName, nil
 	})
 
+	defaultSymbols.LoadOrStore(SymbolIdentifier, IdentifierCallback)
+	//默认分页语法limit offset,其他语法的数据库在创建模板时传入NewPageSymbol覆盖
+	defaultSymbols.Register(NewPageSymbol(LimitOffset))
+
 	defaultSymbols.LoadOrStore(SymbolAnd, func(input DBParam, fullKey string, item *ReplaceItem) (string, xdb.MissError) {
 		item.HasAndOper = true
 
@@ -115,11 +156,11 @@ func init() {
 		if !ok {
 			return "", xdb.NewMissOperError(oper)
 		}
-
-		argName, value, _ := input.Get(propName, item.Placeholder)
-		if !IsNil(value) {
-			item.Names = append(item.Names, propName)
-			item.Values = append(item.Values, value)
+		argName, ok, err := ConditionArg(input, propName, oper, item)
+		if err != nil {
+			return "", err
+		}
+		if ok {
 			return opercall(SymbolAnd, fullField, argName), nil
 			//return fmt.Sprintf(" and %s=%s", fullKey, argName), nil
 		}
@@ -134,12 +175,11 @@ func init() {
 		if !ok {
 			return "", xdb.NewMissOperError(oper)
 		}
-
-		argName, value, _ := input.Get(propName, item.Placeholder)
-
-		if !IsNil(value) {
-			item.Names = append(item.Names, propName)
-			item.Values = append(item.Values, value)
+		argName, ok, err := ConditionArg(input, propName, oper, item)
+		if err != nil {
+			return "", err
+		}
+		if ok {
 			return opercall(SymbolOr, fullField, argName), nil
 			//return fmt.Sprintf(" or %s=%s", fullKey, argName), nil
 		}
@@ -147,6 +187,41 @@ func init() {
 	})
 }
 
+// ConditionArg 按操作符的参数绑定方式绑定动态条件的参数,参数值为空时条件不生效(ok=false)
+// in/not in/between 的切片参数展开为逗号分隔的占位符列表;空切片时argName为空,由操作符生成恒等条件;
+// between 的参数不是两个元素时返回错误
+func ConditionArg(input DBParam, propName, oper string, item *ReplaceItem) (argName string, ok bool, err xdb.MissError) {
+	switch GetArgMode(oper) {
+	case ArgList:
+		if IsNil(input[propName]) {
+			return "", false, nil
+		}
+		phNames, values, _ := input.GetList(propName, item.Placeholder)
+		if oper == "between" && len(phNames) != 2 {
+			return "", false, xdb.NewInvalidParamError(propName)
+		}
+		for i := range values {
+			item.Names = append(item.Names, propName)
+			item.Values = append(item.Values, values[i])
+		}
+		return strings.Join(phNames, ","), true, nil
+	case ArgNone:
+		value := input[propName]
+		if flag, isBool := value.(bool); isBool {
+			return "", flag, nil
+		}
+		return "", !IsNil(value), nil
+	default:
+		argName, value, _ := input.Get(propName, item.Placeholder)
+		if IsNil(value) {
+			return "", false, nil
+		}
+		item.Names = append(item.Names, propName)
+		item.Values = append(item.Values, value)
+		return argName, true, nil
+	}
+}
+
 func IsNil(input interface{}) bool {
 	if input == nil {
 		return true
@@ -179,6 +254,10 @@ func DefaultGetPropName(fullKey string) (fullField, propName, oper string) {
 	if idx < 0 {
 		// <tbl.field,<=tbl.field,>tbl.field,>=tbl.field
 		switch {
+		case strings.HasPrefix(fullField, "!="): //!=tbl.field,!=field
+			propName = strings.TrimPrefix(fullField, "!=")
+			fullField = propName
+			oper = "!="
 		case strings.HasPrefix(fullField, "<="): //<=tbl.field,<=field
 			propName = strings.TrimPrefix(fullField, "<=")
 			fullField = propName
@@ -205,11 +284,12 @@ func DefaultGetPropName(fullKey string) (fullField, propName, oper string) {
 		return fullField, propName, oper
 	}
 
-	parties := strings.Split(propName, " ")
+	//like tbl.field, not in tbl.field, is not null tbl.field
+	parties := strings.Fields(propName)
 
 	tmpfield := parties[len(parties)-1]
 	fullField = strings.Trim(tmpfield, "%")
-	propName, oper = procLike(tmpfield, parties[0])
+	propName, oper = procLike(tmpfield, strings.Join(parties[:len(parties)-1], " "))
 
 	if strings.Index(propName, ".") > 0 {
 		propName = strings.Split(propName, ".")[1]
@@ -50,6 +50,13 @@ func TestDefaultGetPropName(t *testing.T) {
 		{name: "8a.", fullKey: "like %tbl.field", wantFullfield: "tbl.field", wantPropName: "field", wantOper: "%like"},
 		{name: "9a.", fullKey: "like tbl.field%", wantFullfield: "tbl.field", wantPropName: "field", wantOper: "like%"},
 		{name: "10a.", fullKey: "like %tbl.field%", wantFullfield: "tbl.field", wantPropName: "field", wantOper: "%like%"},
+
+		{name: "1i.", fullKey: "in tbl.field", wantFullfield: "tbl.field", wantPropName: "field", wantOper: "in"},
+		{name: "2i.", fullKey: "not   in field", wantFullfield: "field", wantPropName: "field", wantOper: "not in"},
+		{name: "3i.", fullKey: "between tbl.field", wantFullfield: "tbl.field", wantPropName: "field", wantOper: "between"},
+		{name: "4i.", fullKey: "is not null tbl.field", wantFullfield: "tbl.field", wantPropName: "field", wantOper: "is not null"},
+		{name: "5i.", fullKey: "!=tbl.field", wantFullfield: "tbl.field", wantPropName: "field", wantOper: "!="},
+		{name: "6i.", fullKey: "!= field", wantFullfield: "field", wantPropName: "field", wantOper: "!="},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
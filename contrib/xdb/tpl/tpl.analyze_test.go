@@ -93,3 +93,44 @@ func TestAnalyzeTPLFromCache(t *testing.T) {
 		})
 	}
 }
+
+func TestConditionOperators(t *testing.T) {
+	fixed := NewFixed("test_fixed_oper", "?")
+	seq := NewSeq("test_seq_oper", ":")
+	tests := []struct {
+		name       string
+		template   SQLTemplate
+		tpl        string
+		input      map[string]interface{}
+		wantSql    string
+		wantValues []any
+		wantErr    bool
+	}{
+		{name: "1.", template: fixed, tpl: "where 1=1 &{in t.id}", input: map[string]any{"id": []int{1, 2, 3}}, wantSql: "where 1=1 and t.id in (?,?,?)", wantValues: []any{1, 2, 3}},
+		{name: "2.", template: seq, tpl: "where a=@{a} &{not in t.id}", input: map[string]any{"a": "a", "id": []string{"x", "y"}}, wantSql: "where a=:1 and t.id not in (:2,:3)", wantValues: []any{"a", "x", "y"}},
+		{name: "3.", template: fixed, tpl: "where 1=1 &{in id}", input: map[string]any{"id": []int{}}, wantSql: "where 1=1 and 1=0"},
+		{name: "3n.", template: fixed, tpl: "where 1=0 |{not in id}", input: map[string]any{"id": []int{}}, wantSql: "where 1=0 or 1=1"},
+		{name: "4.", template: seq, tpl: "where 1=1 &{between t.age}", input: map[string]any{"age": []int{18, 30}}, wantSql: "where 1=1 and t.age between :1 and :2", wantValues: []any{18, 30}},
+		{name: "5.", template: fixed, tpl: "where 1=1 &{between age}", input: map[string]any{"age": []int{18}}, wantErr: true},
+		{name: "6.", template: fixed, tpl: "where 1=1 &{is null t.deleted} |{is not null t.remark}", input: map[string]any{"deleted": true, "remark": false}, wantSql: "where 1=1 and t.deleted is null "},
+		{name: "7.", template: fixed, tpl: "where 1=1 &{!=t.status} |{!= name}", input: map[string]any{"status": 1, "name": "x"}, wantSql: "where 1=1 and t.status<>? or name<>?", wantValues: []any{1, "x"}},
+		{name: "8.", template: fixed, tpl: "where 1=1 &{in  t.id}", input: map[string]any{"id": 5}, wantSql: "where 1=1 and t.id in (?)", wantValues: []any{5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSql, gotValues, err := tt.template.GetSQLContext(tt.tpl, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSQLContext() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotSql != tt.wantSql {
+				t.Errorf("GetSQLContext() gotSql = %v, want %v", gotSql, tt.wantSql)
+			}
+			if !reflect.DeepEqual(gotValues, tt.wantValues) {
+				t.Errorf("GetSQLContext() gotValues = %v, want %v", gotValues, tt.wantValues)
+			}
+		})
+	}
+}
@@ -31,11 +31,12 @@ func (ph *fixedPlaceHolder) Clone() Placeholder {
 	}
 }
 
-func NewFixed(name, prefix string) SQLTemplate {
+// NewFixed 构建模板,symbols覆盖默认符号表中的同名符号(如数据库特有的分页语法)
+func NewFixed(name, prefix string, symbols ...Symbol) SQLTemplate {
 	return &FixedContext{
 		name:    name,
 		prefix:  prefix,
-		symbols: defaultSymbols.Clone(),
+		symbols: cloneDefaultSymbols(symbols...),
 	}
 }
 
@@ -56,6 +57,10 @@ func (ctx *FixedContext) AnalyzeTPL(tpl string, input map[string]interface{}, ph
 	return DefaultAnalyze(ctx.symbols, tpl, input, ph)
 }
 
+func (ctx *FixedContext) Symbols() SymbolMap {
+	return ctx.symbols
+}
+
 func (ctx *FixedContext) RegisterSymbol(symbol Symbol) error {
 	return ctx.symbols.Register(symbol)
 }
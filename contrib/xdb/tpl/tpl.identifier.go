@@ -0,0 +1,99 @@
+package tpl
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+var (
+	identifierLists  sync.Map
+	identifierRegexp = regexp.MustCompile(`^\w+(\.\w+)?$`)
+)
+
+// RegisterIdentifiers 注册标识符白名单, #{name:param} 中param的值必须在名称为name的白名单中
+func RegisterIdentifiers(name string, identifiers ...string) {
+	identifierMap := make(map[string]string, len(identifiers))
+	for _, identifier := range identifiers {
+		identifierMap[identifier] = identifier
+	}
+	RegisterIdentifierMap(name, identifierMap)
+}
+
+// RegisterIdentifierMap 注册标识符白名单,key为参数值,value为输出到SQL中的列名
+func RegisterIdentifierMap(name string, identifiers map[string]string) {
+	for key, column := range identifiers {
+		if !identifierRegexp.MatchString(column) {
+			panic(fmt.Errorf("tpl: 白名单[%s]中的列名不合法:%s=%s", name, key, column))
+		}
+	}
+	identifierLists.Store(name, identifiers)
+}
+
+// RegisterStructIdentifiers 按struct字段的tag注册标识符白名单,tag为空或"-"的字段不加入白名单
+func RegisterStructIdentifiers(name string, obj any, tagName string) {
+	rt := reflect.TypeOf(obj)
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		panic(fmt.Errorf("tpl: 白名单[%s]只支持struct,当前类型:%s", name, rt))
+	}
+	identifiers := make([]string, 0, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		tag, _, _ := strings.Cut(rt.Field(i).Tag.Get(tagName), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		identifiers = append(identifiers, tag)
+	}
+	RegisterIdentifiers(name, identifiers...)
+}
+
+// IdentifierCallback #{name:param},将param的值按白名单name校验后输出
+// 参数值可以是 "name desc,id" 格式的字符串或[]string,每项为 标识符 [asc|desc]
+func IdentifierCallback(input DBParam, fullKey string, item *ReplaceItem) (string, xdb.MissError) {
+	name, propName, _ := strings.Cut(fullKey, ":")
+	tmp, ok := identifierLists.Load(name)
+	if !ok {
+		return "", xdb.NewInvalidParamError(fullKey)
+	}
+	identifiers := tmp.(map[string]string)
+
+	val, ok := input[propName]
+	if !ok || IsNil(val) {
+		return "", xdb.NewMissParamError(propName)
+	}
+	var list []string
+	switch t := val.(type) {
+	case []string:
+		list = t
+	default:
+		list = strings.Split(fmt.Sprint(t), ",")
+	}
+
+	result := make([]string, 0, len(list))
+	for _, itemVal := range list {
+		fields := strings.Fields(itemVal)
+		if len(fields) == 0 || len(fields) > 2 {
+			return "", xdb.NewInvalidParamError(propName)
+		}
+		column, ok := identifiers[fields[0]]
+		if !ok {
+			return "", xdb.NewInvalidParamError(propName)
+		}
+		if len(fields) == 2 {
+			direction := strings.ToLower(fields[1])
+			if direction != "asc" && direction != "desc" {
+				return "", xdb.NewInvalidParamError(propName)
+			}
+			column = column + " " + direction
+		}
+		result = append(result, column)
+	}
+	return strings.Join(result, ","), nil
+}
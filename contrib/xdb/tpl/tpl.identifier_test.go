@@ -0,0 +1,58 @@
+package tpl
+
+import (
+	"testing"
+)
+
+type identifierUser struct {
+	ID       int    `json:"id"`
+	Name     string `json:"user_name,omitempty"`
+	Password string `json:"-"`
+	Remark   string
+}
+
+func TestIdentifierAndPage(t *testing.T) {
+	RegisterIdentifiers("test_order", "id", "t.name")
+	RegisterIdentifierMap("test_alias", map[string]string{"createTime": "t.create_time"})
+	RegisterStructIdentifiers("test_user", &identifierUser{}, "json")
+
+	fixed := NewFixed("test_page_fixed", "?")
+	seq := NewSeq("test_page_seq", ":", NewPageSymbol(OffsetFetch))
+
+	tests := []struct {
+		name     string
+		template SQLTemplate
+		tpl      string
+		input    map[string]interface{}
+		wantSql  string
+		wantErr  bool
+	}{
+		{name: "1.", template: fixed, tpl: "order by #{test_order:sort}", input: map[string]any{"sort": "t.name desc, id"}, wantSql: "order by t.name desc,id"},
+		{name: "2.", template: fixed, tpl: "order by #{test_order:sort}", input: map[string]any{"sort": []string{"id ASC"}}, wantSql: "order by id asc"},
+		{name: "3.", template: fixed, tpl: "order by #{test_alias:sort}", input: map[string]any{"sort": "createTime desc"}, wantSql: "order by t.create_time desc"},
+		{name: "4.", template: fixed, tpl: "select #{test_user:col} from t", input: map[string]any{"col": "user_name"}, wantSql: "select user_name from t"},
+		{name: "5.", template: fixed, tpl: "order by #{test_order:sort}", input: map[string]any{"sort": "id;drop table t"}, wantErr: true},
+		{name: "6.", template: fixed, tpl: "order by #{test_order:sort}", input: map[string]any{"sort": "id desc nulls"}, wantErr: true},
+		{name: "7.", template: fixed, tpl: "select #{test_user:col} from t", input: map[string]any{"col": "Password"}, wantErr: true},
+		{name: "8.", template: fixed, tpl: "order by #{none:sort}", input: map[string]any{"sort": "id"}, wantErr: true},
+		{name: "9.", template: fixed, tpl: "order by #{test_order:sort}", input: map[string]any{}, wantErr: true},
+
+		{name: "1a.", template: fixed, tpl: "select * from t where a=@{a} %{pi,ps}", input: map[string]any{"a": 1, "pi": 3, "ps": "20"}, wantSql: "select * from t where a=? limit 20 offset 40"},
+		{name: "2a.", template: fixed, tpl: "select * from t %{ps}", input: map[string]any{"ps": 10}, wantSql: "select * from t limit 10 offset 0"},
+		{name: "3a.", template: seq, tpl: "select * from t where a=@{a} order by id %{ pi , ps }", input: map[string]any{"a": 1, "pi": 2, "ps": 10}, wantSql: "select * from t where a=:1 order by id offset 10 rows fetch next 10 rows only"},
+		{name: "4a.", template: fixed, tpl: "select * from t %{pi,ps}", input: map[string]any{"pi": 0, "ps": 10}, wantErr: true},
+		{name: "5a.", template: fixed, tpl: "select * from t %{pi,ps}", input: map[string]any{"pi": 1, "ps": "10 or 1=1"}, wantErr: true},
+		{name: "6a.", template: fixed, tpl: "select * from t %{pi,ps}", input: map[string]any{"pi": 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSql, _, err := tt.template.GetSQLContext(tt.tpl, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSQLContext() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && gotSql != tt.wantSql {
+				t.Errorf("GetSQLContext() gotSql = %v, want %v", gotSql, tt.wantSql)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package tpl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// PageRender 根据偏移量与条数构建分页语句
+type PageRender func(offset, limit int64) string
+
+// LimitOffset mysql,postgres,sqlite 分页语句
+func LimitOffset(offset, limit int64) string {
+	return fmt.Sprintf("limit %d offset %d", limit, offset)
+}
+
+// OffsetFetch sql server(2012+),oracle(12c+) 分页语句,需要与order by一起使用
+func OffsetFetch(offset, limit int64) string {
+	return fmt.Sprintf("offset %d rows fetch next %d rows only", offset, limit)
+}
+
+// pageSymbol %{pi,ps} 分页语句,pi为页码(从1开始),ps为每页条数; %{ps} 只取前ps条
+type pageSymbol struct {
+	render PageRender
+}
+
+// NewPageSymbol 构建分页符号,由各数据库的模板按自身语法注册
+func NewPageSymbol(render PageRender) Symbol {
+	return &pageSymbol{render: render}
+}
+
+func (s *pageSymbol) Name() string {
+	return SymbolPage
+}
+
+func (s *pageSymbol) GetPattern() string {
+	return PagePattern
+}
+
+func (s *pageSymbol) Callback(input DBParam, fullKey string, item *ReplaceItem) (string, xdb.MissError) {
+	names := strings.Split(fullKey, ",")
+	var pageIndex int64 = 1
+	if len(names) == 2 {
+		val, err := getPageValue(input, strings.TrimSpace(names[0]))
+		if err != nil {
+			return "", err
+		}
+		pageIndex = val
+	}
+	pageSize, err := getPageValue(input, strings.TrimSpace(names[len(names)-1]))
+	if err != nil {
+		return "", err
+	}
+	return s.render((pageIndex-1)*pageSize, pageSize), nil
+}
+
+// getPageValue 分页参数必须为正整数,直接输出到SQL中
+func getPageValue(input DBParam, name string) (int64, xdb.MissError) {
+	val, ok := input[name]
+	if !ok || IsNil(val) {
+		return 0, xdb.NewMissParamError(name)
+	}
+	num, err := strconv.ParseInt(fmt.Sprint(val), 10, 64)
+	if err != nil || num <= 0 {
+		return 0, xdb.NewInvalidParamError(name)
+	}
+	return num, nil
+}
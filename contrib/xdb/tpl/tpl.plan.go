@@ -0,0 +1,171 @@
+package tpl
+
+import (
+	"strings"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// DefaultPlanCacheSize 默认缓存的执行计划条数
+const DefaultPlanCacheSize = 4096
+
+var planCache *lru.Cache
+
+func init() {
+	SetPlanCacheSize(DefaultPlanCacheSize)
+}
+
+// SetPlanCacheSize 设置执行计划缓存条数,会清空已缓存的执行计划
+func SetPlanCacheSize(size int) {
+	if size <= 0 {
+		size = DefaultPlanCacheSize
+	}
+	planCache, _ = lru.New(size)
+}
+
+type segmentKind int
+
+const (
+	segmentText    segmentKind = iota //原样输出的文本
+	segmentSymbol                     //@{} &{} |{} 及自定义符号
+	segmentReplace                    //${} 替换符号
+)
+
+type planSegment struct {
+	kind    segmentKind
+	text    string
+	symbol  string
+	fullKey string
+}
+
+// planKey 执行计划缓存键,符号表变更后版本号改变,旧的执行计划自动失效
+type planKey struct {
+	version uint64
+	tpl     string
+}
+
+// Plan 编译后的SQL模板,重复执行时只需按顺序绑定参数,无需再进行正则匹配
+type Plan struct {
+	symbols  SymbolMap
+	segments []planSegment
+}
+
+// GetPlan 从缓存中获取执行计划,不存在时编译并缓存
+func GetPlan(symbols SymbolMap, tpl string) *Plan {
+	key := planKey{version: symbols.Version(), tpl: tpl}
+	if val, ok := planCache.Get(key); ok {
+		return val.(*Plan)
+	}
+	plan := CompilePlan(symbols, tpl)
+	planCache.Add(key, plan)
+	return plan
+}
+
+// CompilePlan 按符号表的匹配模式将模板拆分为文本、符号、替换符三类片段
+func CompilePlan(symbols SymbolMap, tpl string) *Plan {
+	plan := &Plan{symbols: symbols}
+	word := GetPatternRegexp(symbols.GetPattern())
+	last := 0
+	for _, loc := range word.FindAllStringIndex(tpl, -1) {
+		plan.appendText(tpl[last:loc[0]])
+		s := tpl[loc[0]:loc[1]]
+		plan.segments = append(plan.segments, planSegment{
+			kind:    segmentSymbol,
+			text:    s,
+			symbol:  s[:1],
+			fullKey: s[2 : len(s)-1],
+		})
+		last = loc[1]
+	}
+	plan.appendText(tpl[last:])
+	return plan
+}
+
+// appendText 拆分文本中的${}替换符
+func (p *Plan) appendText(text string) {
+	if text == "" {
+		return
+	}
+	word := GetPatternRegexp(ReplacePattern)
+	last := 0
+	for _, loc := range word.FindAllStringIndex(text, -1) {
+		if loc[0] > last {
+			p.segments = append(p.segments, planSegment{kind: segmentText, text: text[last:loc[0]]})
+		}
+		s := text[loc[0]:loc[1]]
+		p.segments = append(p.segments, planSegment{
+			kind:    segmentReplace,
+			text:    s,
+			fullKey: s[2 : len(s)-1],
+		})
+		last = loc[1]
+	}
+	if last < len(text) {
+		p.segments = append(p.segments, planSegment{kind: segmentText, text: text[last:]})
+	}
+}
+
+// Analyze 绑定符号参数,${}替换符原样保留,结果与DefaultAnalyze一致
+func (p *Plan) Analyze(input map[string]interface{}, ph Placeholder) (string, *ReplaceItem, error) {
+	sql, item, _, err := p.render(input, ph, false)
+	return sql, item, err
+}
+
+// Build 绑定符号参数并处理${}替换符,返回可执行的SQL及参数
+func (p *Plan) Build(input map[string]interface{}, ph Placeholder) (sql string, values []any, err error) {
+	sql, item, replaceErr, err := p.render(input, ph, true)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, item.Values, replaceErr
+}
+
+// render 按片段顺序输出SQL,分别返回符号与${}替换符的参数缺失错误
+func (p *Plan) render(input DBParam, ph Placeholder, replace bool) (sql string, item *ReplaceItem, replaceErr error, err error) {
+	item = &ReplaceItem{
+		NameCache:   map[string]string{},
+		Placeholder: ph,
+	}
+	var (
+		outerrs     []xdb.MissError
+		replaceErrs []xdb.MissError
+	)
+	builder := strings.Builder{}
+	for i := range p.segments {
+		seg := &p.segments[i]
+		switch seg.kind {
+		case segmentSymbol:
+			callback, ok := p.symbols.Load(seg.symbol)
+			if !ok {
+				builder.WriteString(seg.text)
+				continue
+			}
+			tmpv, err := callback(input, seg.fullKey, item)
+			if err != nil {
+				outerrs = append(outerrs, err)
+			}
+			builder.WriteString(tmpv)
+		case segmentReplace:
+			if !replace {
+				builder.WriteString(seg.text)
+				continue
+			}
+			tmpv, err := replaceSymbols(input, seg.fullKey, item)
+			if err != nil {
+				replaceErrs = append(replaceErrs, err)
+			}
+			builder.WriteString(tmpv)
+		default:
+			builder.WriteString(seg.text)
+		}
+	}
+	sql = builder.String()
+	if len(outerrs) > 0 {
+		err = xdb.NewMissListError(outerrs...)
+	}
+	if len(replaceErrs) > 0 {
+		replaceErr = xdb.NewMissListError(replaceErrs...)
+	}
+	return
+}
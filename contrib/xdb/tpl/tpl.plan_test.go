@@ -0,0 +1,160 @@
+package tpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// regexpAnalyze 未编译执行计划时的解析方式,每次执行都进行正则匹配,作为对照
+func regexpAnalyze(symbols SymbolMap, tpl string, input map[string]interface{}, ph Placeholder) (string, []any, error) {
+	word := GetPatternRegexp(symbols.GetPattern())
+	item := &ReplaceItem{
+		NameCache:   map[string]string{},
+		Placeholder: ph,
+	}
+	var outerrs []xdb.MissError
+	sql := word.ReplaceAllStringFunc(tpl, func(s string) string {
+		callback, ok := symbols.Load(s[:1])
+		if !ok {
+			return s
+		}
+		tmpv, err := callback(input, s[2:len(s)-1], item)
+		if err != nil {
+			outerrs = append(outerrs, err)
+		}
+		return tmpv
+	})
+	if len(outerrs) > 0 {
+		return "", nil, xdb.NewMissListError(outerrs...)
+	}
+	sql = GetPatternRegexp(ReplacePattern).ReplaceAllStringFunc(sql, func(s string) string {
+		tmpv, err := replaceSymbols(input, s[2:len(s)-1], item)
+		if err != nil {
+			outerrs = append(outerrs, err)
+		}
+		return tmpv
+	})
+	if len(outerrs) > 0 {
+		return sql, item.Values, xdb.NewMissListError(outerrs...)
+	}
+	return sql, item.Values, nil
+}
+
+var planCases = []struct {
+	name  string
+	tpl   string
+	input map[string]interface{}
+}{
+	{name: "@", tpl: "select * from t where a=@{a} and b=@{t.b}", input: map[string]interface{}{"a": 1, "b": "x"}},
+	{name: "&", tpl: "select * from t where 1=1 &{a} &{>=t.b} &{like %c%}", input: map[string]interface{}{"a": 1, "b": 2, "c": ""}},
+	{name: "|", tpl: "select * from t where 1=0 |{a} |{<t.b}", input: map[string]interface{}{"a": 1, "b": nil}},
+	{name: "$", tpl: "select * from ${tbl} where a=@{a} order by ${order}", input: map[string]interface{}{"tbl": "t", "a": 1, "order": "id desc"}},
+	{name: "mixed", tpl: "select ${cols} from t where a=@{a} &{b} |{c} and d in (${d})", input: map[string]interface{}{"cols": "a,b", "a": 1, "b": "b", "c": 3, "d": []int{1, 2}}},
+}
+
+func TestPlan_Build(t *testing.T) {
+	templates := []SQLTemplate{NewFixed("plan_fixed", "?"), NewSeq("plan_seq", ":")}
+	for _, template := range templates {
+		for _, tt := range planCases {
+			t.Run(template.Name()+"_"+tt.name, func(t *testing.T) {
+				wantSql, wantValues, wantErr := regexpAnalyze(template.Symbols(), tt.tpl, tt.input, template.Placeholder())
+				for i := 0; i < 2; i++ {
+					gotSql, gotValues, err := template.GetSQLContext(tt.tpl, tt.input)
+					if (err != nil) != (wantErr != nil) {
+						t.Fatalf("GetSQLContext() err = %v, want %v", err, wantErr)
+					}
+					if gotSql != wantSql {
+						t.Errorf("GetSQLContext() sql = %v, want %v", gotSql, wantSql)
+					}
+					if !reflect.DeepEqual(gotValues, wantValues) {
+						t.Errorf("GetSQLContext() values = %v, want %v", gotValues, wantValues)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestPlan_Analyze(t *testing.T) {
+	template := NewFixed("plan_analyze", "?")
+	sql, item, err := template.AnalyzeTPL("select * from ${tbl} where a=@{a}", map[string]interface{}{"a": 1}, template.Placeholder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "select * from ${tbl} where a=?" || !reflect.DeepEqual(item.Values, []any{1}) {
+		t.Errorf("AnalyzeTPL() = %v,%v", sql, item.Values)
+	}
+
+	_, _, err = template.GetSQLContext("select * from t where a=@{a}", map[string]interface{}{})
+	if err == nil {
+		t.Errorf("GetSQLContext() missing param should return error")
+	}
+}
+
+type testSymbol struct{}
+
+func (testSymbol) Name() string {
+	return "~"
+}
+
+func (testSymbol) GetPattern() string {
+	return `[~]\{\w+\}`
+}
+
+func (testSymbol) Callback(input DBParam, fullKey string, item *ReplaceItem) (string, xdb.MissError) {
+	return "`" + fullKey + "`", nil
+}
+
+func TestPlan_RegisterSymbol(t *testing.T) {
+	template := NewFixed("plan_symbol", "?")
+	query := "select ~{name} from t where a=@{a}"
+	input := map[string]interface{}{"a": 1}
+
+	sql, _, err := template.GetSQLContext(query, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "select ~{name} from t where a=?" {
+		t.Errorf("GetSQLContext() before register = %v", sql)
+	}
+
+	version := template.Symbols().Version()
+	if err = template.RegisterSymbol(testSymbol{}); err != nil {
+		t.Fatal(err)
+	}
+	if template.Symbols().Version() == version {
+		t.Errorf("RegisterSymbol() should change symbols version")
+	}
+	sql, _, err = template.GetSQLContext(query, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "select `name` from t where a=?" {
+		t.Errorf("GetSQLContext() after register = %v", sql)
+	}
+
+	other := NewFixed("plan_symbol_other", "?")
+	if sql, _, _ = other.GetSQLContext(query, input); sql != "select ~{name} from t where a=?" {
+		t.Errorf("GetSQLContext() other template = %v", sql)
+	}
+}
+
+func BenchmarkAnalyze(b *testing.B) {
+	template := NewFixed("plan_bench", "?")
+	for _, tt := range planCases {
+		b.Run("regexp_"+tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				regexpAnalyze(template.Symbols(), tt.tpl, tt.input, template.Placeholder())
+			}
+		})
+		b.Run("plan_"+tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				template.GetSQLContext(tt.tpl, tt.input)
+			}
+		})
+	}
+}
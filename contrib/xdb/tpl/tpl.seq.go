@@ -40,11 +40,12 @@ func (ph *seqPlaceHolder) Clone() Placeholder {
 	}
 }
 
-func NewSeq(name, prefix string) SQLTemplate {
+// NewSeq 构建模板,symbols覆盖默认符号表中的同名符号(如数据库特有的分页语法)
+func NewSeq(name, prefix string, symbols ...Symbol) SQLTemplate {
 	return &SeqContext{
 		name:    name,
 		prefix:  prefix,
-		symbols: defaultSymbols.Clone(),
+		symbols: cloneDefaultSymbols(symbols...),
 	}
 }
 
@@ -65,6 +66,10 @@ func (ctx *SeqContext) AnalyzeTPL(tpl string, input map[string]interface{}, ph P
 	return DefaultAnalyze(ctx.symbols, tpl, input, ph)
 }
 
+func (ctx *SeqContext) Symbols() SymbolMap {
+	return ctx.symbols
+}
+
 func (ctx *SeqContext) RegisterSymbol(symbol Symbol) error {
 	return ctx.symbols.Register(symbol)
 }
@@ -0,0 +1,91 @@
+package xdb
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/zhiyunliu/glue/xdb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "glue.xdb"
+
+var (
+	stringLiteralRegexp  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numberLiteralRegexp  = regexp.MustCompile(`([\s=<>(,+\-*/]|^)-?\d+(\.\d+)?\b`)
+	operationRegexp      = regexp.MustCompile(`^\s*(\w+)`)
+	whitespaceRegexp     = regexp.MustCompile(`\s+`)
+	systemNames          = map[string]string{"postgres": "postgresql", "sqlserver": "mssql", "ora": "oracle"}
+	spanAttrRowsAffected = attribute.Key("db.rows_affected")
+)
+
+// newTracingInterceptor 开启tracing时,每次查询、执行及事务在调用方上下文的span下创建子span
+func newTracingInterceptor(connName string, cfg *xdb.Config) (xdb.Interceptor, error) {
+	if !cfg.Tracing {
+		return nil, nil
+	}
+	return &tracingInterceptor{connName: connName}, nil
+}
+
+type tracingInterceptor struct {
+	connName string
+}
+
+func (t *tracingInterceptor) Before(ctx context.Context, info *xdb.QueryInfo) (context.Context, error) {
+	operation := "TRANSACTION"
+	if info.Kind != xdb.QueryKindTransaction {
+		operation = "QUERY"
+		if match := operationRegexp.FindStringSubmatch(info.SQL); match != nil {
+			operation = strings.ToUpper(match[1])
+		}
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", dbSystem(info.Proto)),
+		attribute.String("db.name", t.connName),
+		attribute.String("db.operation", operation),
+	}
+	if info.SQL != "" {
+		attrs = append(attrs, attribute.String("db.statement", sanitizeStatement(info.SQL)))
+	}
+	ctx, _ = otel.Tracer(tracerName).Start(ctx, operation+" "+t.connName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	return ctx, nil
+}
+
+func (t *tracingInterceptor) After(ctx context.Context, info *xdb.QueryInfo) {
+	span := trace.SpanFromContext(ctx)
+	if info.Kind == xdb.QueryKindExec {
+		span.SetAttributes(spanAttrRowsAffected.Int64(info.RowsAffected))
+	}
+	if info.Err != nil {
+		span.RecordError(info.Err)
+		span.SetStatus(codes.Error, info.Err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "OK")
+	}
+	span.End()
+}
+
+// dbSystem 按模板名称获取db.system,gorm.mysql等取驱动名称
+func dbSystem(proto string) string {
+	if idx := strings.LastIndex(proto, "."); idx >= 0 {
+		proto = proto[idx+1:]
+	}
+	proto = strings.ToLower(proto)
+	if name, ok := systemNames[proto]; ok {
+		return name
+	}
+	return proto
+}
+
+// sanitizeStatement 将语句中的字符串、数字常量替换为?,参数占位符保持不变
+func sanitizeStatement(query string) string {
+	query = stringLiteralRegexp.ReplaceAllString(query, "?")
+	query = numberLiteralRegexp.ReplaceAllString(query, "${1}?")
+	return strings.TrimSpace(whitespaceRegexp.ReplaceAllString(query, " "))
+}
@@ -0,0 +1,128 @@
+package xdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zhiyunliu/glue/xdb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type testSpan struct {
+	trace.Span
+	name   string
+	parent *testSpan
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+	ended  bool
+}
+
+func (s *testSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attr := range kv {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *testSpan) SetStatus(code codes.Code, description string) {
+	s.status = code
+}
+
+func (s *testSpan) End(options ...trace.SpanEndOption) {
+	s.ended = true
+}
+
+type testTracer struct {
+	trace.TracerProvider
+	spans []*testSpan
+}
+
+func (t *testTracer) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return t
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &testSpan{Span: trace.SpanFromContext(context.Background()), name: name, attrs: map[attribute.Key]attribute.Value{}}
+	span.parent, _ = trace.SpanFromContext(ctx).(*testSpan)
+	cfg := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(cfg.Attributes()...)
+	t.spans = append(t.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func TestTracing(t *testing.T) {
+	tracer := &testTracer{TracerProvider: otel.GetTracerProvider()}
+	otel.SetTracerProvider(tracer)
+	t.Cleanup(func() { otel.SetTracerProvider(tracer.TracerProvider) })
+
+	dbobj := newSqliteTestDB(t, "tracing_test", xdb.WithTracing(true))
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	err := dbobj.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+		if _, err := dbobj.Exec(ctx, "create table items(id integer primary key,name text)", nil); err != nil {
+			return err
+		}
+		_, err := dbobj.Exec(ctx, "insert into items(id,name) values(@{id},'a')", map[string]any{"id": 1})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dbobj.Query(ctx, "select id from items where id=1", nil); err != nil {
+		t.Fatal(err)
+	}
+	dbobj.Exec(ctx, "delete from none", nil)
+
+	if len(tracer.spans) != 6 {
+		t.Fatalf("spans = %d, want 6", len(tracer.spans))
+	}
+	txSpan, insertSpan, querySpan, errSpan := tracer.spans[1], tracer.spans[3], tracer.spans[4], tracer.spans[5]
+	if txSpan.name != "TRANSACTION tracing_test" || txSpan.parent != root.(*testSpan) || !txSpan.ended {
+		t.Errorf("transaction span = %+v", txSpan)
+	}
+	if insertSpan.name != "INSERT tracing_test" || insertSpan.parent != txSpan {
+		t.Errorf("insert span = %+v", insertSpan)
+	}
+	if got := insertSpan.attrs["db.statement"].AsString(); got != "insert into items(id,name) values(?,?)" {
+		t.Errorf("insert db.statement = %v", got)
+	}
+	if insertSpan.attrs["db.system"].AsString() != "sqlite" || insertSpan.attrs["db.rows_affected"].AsInt64() != 1 {
+		t.Errorf("insert attrs = %v", insertSpan.attrs)
+	}
+	if got := querySpan.attrs["db.statement"].AsString(); got != "select id from items where id=?" || querySpan.parent != root.(*testSpan) {
+		t.Errorf("query span = %v", got)
+	}
+	if errSpan.status != codes.Error || !errSpan.ended {
+		t.Errorf("error span status = %v", errSpan.status)
+	}
+
+	//Transaction(callback)同样创建事务span
+	err = dbobj.Transaction(func(tx xdb.Executer) error {
+		return errors.New("rollback")
+	})
+	if err == nil || len(tracer.spans) != 7 {
+		t.Fatalf("Transaction() err = %v, spans = %d", err, len(tracer.spans))
+	}
+	if span := tracer.spans[6]; span.name != "TRANSACTION tracing_test" || span.status != codes.Error || !span.ended {
+		t.Errorf("callback transaction span = %+v", span)
+	}
+}
+
+func TestSanitizeStatement(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "select * from t1 where a=$1 and b='x''y' and c=-10.5", want: "select * from t1 where a=$1 and b=? and c=?"},
+		{query: "select *\n  from t where a in (1, 2) limit 20 offset 40", want: "select * from t where a in (?, ?) limit ? offset ?"},
+		{query: "update t set a=:1,b=@p2", want: "update t set a=:1,b=@p2"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeStatement(tt.query); got != tt.want {
+			t.Errorf("sanitizeStatement() = %v, want %v", got, tt.want)
+		}
+	}
+}
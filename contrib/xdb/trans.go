@@ -3,7 +3,7 @@ package xdb
 import (
 	"context"
 	"database/sql"
-	"time"
+	"fmt"
 
 	"github.com/zhiyunliu/glue/contrib/xdb/implement"
 	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
@@ -12,9 +12,12 @@ import (
 
 // xTrans 数据库事务操作类
 type xTrans struct {
-	cfg *Setting
-	tpl tpl.SQLTemplate
-	tx  implement.ISysTrans
+	cfg       *Setting
+	tpl       tpl.SQLTemplate
+	tx        implement.ISysTrans
+	evictTags []string          //提交后需要失效的查询缓存标签
+	tracker   *xdb.WriteTracker //事务中写操作所在上下文的写后读记录,提交后再次标记
+	spSeq     int               //保存点序号
 }
 
 // Query 查询数据
@@ -73,13 +76,52 @@ func (db *xTrans) Exec(ctx context.Context, sql string, input any) (r xdb.Result
 		return
 	}
 
-	start := time.Now()
-	debugPrint(ctx, db.cfg, query, execArgs...)
-	r, err = db.tx.Execute(query, execArgs...)
+	info := &xdb.QueryInfo{ConnName: db.cfg.ConnName, Proto: db.tpl.Name(), Kind: xdb.QueryKindExec, SQL: query, Args: execArgs}
+	err = db.cfg.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) (err error) {
+		if r, err = db.tx.Execute(info.SQL, info.Args...); err != nil {
+			return
+		}
+		info.RowsAffected, _ = r.RowsAffected()
+		return
+	})
+	if err != nil {
+		return nil, implement.GetError(err, info.SQL, info.Args...)
+	}
+	db.evictTags = append(db.evictTags, db.cfg.queryCache.evictTags(ctx, info.SQL)...)
+	db.markWrite(ctx)
+	return
+}
+
+// ExecReturning 在事务中执行返回结果集的写语句(insert ... returning),与Exec相同经过拦截器并在提交后失效查询缓存
+func (db *xTrans) ExecReturning(ctx context.Context, sql string, input any) (rows xdb.Rows, err error) {
+	dbParams, err := implement.ResolveParams(input)
+	if err != nil {
+		return
+	}
+	query, execArgs, err := db.tpl.GetSQLContext(sql, dbParams)
+	if err != nil {
+		err = implement.GetError(err, sql, input)
+		return
+	}
+
+	info := &xdb.QueryInfo{ConnName: db.cfg.ConnName, Proto: db.tpl.Name(), Kind: xdb.QueryKindExec, SQL: query, Args: execArgs}
+	err = db.cfg.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) error {
+		r, err := db.tx.Query(info.SQL, info.Args...)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if rows, err = implement.ResolveRows(r); err != nil {
+			return err
+		}
+		info.RowsAffected = int64(len(rows))
+		return nil
+	})
 	if err != nil {
-		return nil, implement.GetError(err, query, execArgs...)
+		return nil, implement.GetError(err, info.SQL, info.Args...)
 	}
-	printSlowQuery(ctx, db.cfg, time.Since(start), query, execArgs...)
+	db.evictTags = append(db.evictTags, db.cfg.queryCache.evictTags(ctx, info.SQL)...)
+	db.markWrite(ctx)
 	return
 }
 
@@ -96,46 +138,93 @@ func (db *xTrans) FirstAs(ctx context.Context, sqls string, input any, result an
 	})
 }
 
+// QueryStream 流式查询数据
+func (db *xTrans) QueryStream(ctx context.Context, sqls string, input any) (cursor xdb.Cursor, err error) {
+	rows, err := db.dbRows(ctx, sqls, input)
+	if err != nil {
+		return
+	}
+	return implement.NewCursor(rows)
+}
+
+// BatchExec 批量执行,事务内忽略Transaction选项
+func (db *xTrans) BatchExec(ctx context.Context, sqls string, inputs []any, opts ...xdb.BatchOption) (r xdb.Result, err error) {
+	batchOpts := xdb.NewBatchOptions(opts...)
+	return ExecBatch(ctx, GetDialect(db.tpl.Name()), db.Exec, sqls, inputs, batchOpts)
+}
+
+// Insert 按struct插入一行数据,返回自增列的值
+func (db *xTrans) Insert(ctx context.Context, obj any) (id int64, err error) {
+	return ModelInsert(ctx, db, GetDialect(db.tpl.Name()), obj)
+}
+
+// Update 按主键更新struct对应的行
+func (db *xTrans) Update(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return ModelUpdate(ctx, db, obj)
+}
+
+// Upsert 按主键存在时更新,否则插入
+func (db *xTrans) Upsert(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return ModelUpsert(ctx, db, GetDialect(db.tpl.Name()), obj)
+}
+
+// DeleteByKey 按主键删除struct对应的行
+func (db *xTrans) DeleteByKey(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return ModelDeleteByKey(ctx, db, obj)
+}
+
+// GetByKey 按主键查询并填充struct
+func (db *xTrans) GetByKey(ctx context.Context, obj any) (found bool, err error) {
+	return ModelGetByKey(ctx, db, obj)
+}
+
 // Rollback 回滚所有操作
 func (t *xTrans) Rollback() error {
 	return t.tx.Rollback()
 }
 
-// Commit 提交所有操作
-func (t *xTrans) Commit() error {
-	return t.tx.Commit()
+// Commit 提交所有操作,提交成功后失效事务中写操作涉及的查询缓存
+func (t *xTrans) Commit() (err error) {
+	if err = t.tx.Commit(); err != nil {
+		return
+	}
+	t.cfg.queryCache.evict(context.Background(), t.evictTags)
+	if t.tracker != nil {
+		t.tracker.MarkWrite()
+	}
+	return
 }
 
-func (db *xTrans) dbQuery(ctx context.Context, sql string, input any, callback implement.DbResolveMapValCallback) (result any, err error) {
-	dbParams, err := implement.ResolveParams(input)
-	if err != nil {
-		return
+// markWrite 记录事务中的写操作,写后读的时间窗口在提交成功后重新开始
+func (t *xTrans) markWrite(ctx context.Context) {
+	if tracker, ok := xdb.GetWriteTracker(ctx); ok {
+		tracker.MarkWrite()
+		t.tracker = tracker
 	}
+}
 
-	query, execArgs, err := db.tpl.GetSQLContext(sql, dbParams)
+func (db *xTrans) dbQuery(ctx context.Context, sql string, input any, callback implement.DbResolveMapValCallback) (result any, err error) {
+	rows, err := db.dbRows(ctx, sql, input)
 	if err != nil {
-		err = implement.GetError(err, sql, input)
 		return
 	}
+	defer rows.Close()
+	result, err = callback(rows)
+	return
+}
 
-	start := time.Now()
-
-	debugPrint(ctx, db.cfg, query, execArgs...)
-	rows, err := db.tx.Query(query, execArgs...)
+func (db *xTrans) dbQueryAs(ctx context.Context, sql string, input any, result any, callback implement.DbResolveResultCallback) (err error) {
+	rows, err := db.dbRows(ctx, sql, input)
 	if err != nil {
-		return nil, implement.GetError(err, query, execArgs...)
+		return
 	}
-	defer func() {
-		if rows != nil {
-			rows.Close()
-		}
-	}()
-	printSlowQuery(ctx, db.cfg, time.Since(start), query, execArgs...)
-	result, err = callback(rows)
+	defer rows.Close()
+	err = callback(rows, result)
 	return
 }
 
-func (db *xTrans) dbQueryAs(ctx context.Context, sql string, input any, result any, callback implement.DbResolveResultCallback) (err error) {
+// dbRows 解析模板并执行查询,返回的rows由调用方关闭
+func (db *xTrans) dbRows(ctx context.Context, sql string, input any) (rows *sql.Rows, err error) {
 	dbParams, err := implement.ResolveParams(input)
 	if err != nil {
 		return
@@ -147,19 +236,54 @@ func (db *xTrans) dbQueryAs(ctx context.Context, sql string, input any, result a
 		return
 	}
 
-	start := time.Now()
-
-	debugPrint(ctx, db.cfg, query, execArgs...)
-	rows, err := db.tx.Query(query, execArgs...)
+	info := &xdb.QueryInfo{ConnName: db.cfg.ConnName, Proto: db.tpl.Name(), Kind: xdb.QueryKindQuery, SQL: query, Args: execArgs}
+	err = db.cfg.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) (err error) {
+		rows, err = db.tx.Query(info.SQL, info.Args...)
+		return
+	})
 	if err != nil {
-		return implement.GetError(err, query, execArgs...)
+		return nil, implement.GetError(err, info.SQL, info.Args...)
 	}
-	defer func() {
-		if rows != nil {
-			rows.Close()
-		}
-	}()
-	printSlowQuery(ctx, db.cfg, time.Since(start), query, execArgs...)
-	err = callback(rows, result)
+	return
+}
+
+// ConnName 事务所属的数据库连接名称
+func (t *xTrans) ConnName() string {
+	return t.cfg.ConnName
+}
+
+// Savepoint 在当前事务中创建保存点
+func (t *xTrans) Savepoint() (xdb.ITrans, error) {
+	t.spSeq++
+	sp := &xSavepoint{
+		xTrans:  t,
+		name:    fmt.Sprintf("glue_sp_%d", t.spSeq),
+		dialect: GetDialect(t.tpl.Name()).Savepoint,
+	}
+	if _, err := t.tx.Execute(fmt.Sprintf(sp.dialect.Create, sp.name)); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// xSavepoint 保存点,与所属事务共用连接,提交时释放保存点,回滚时回滚到保存点
+type xSavepoint struct {
+	*xTrans
+	name    string
+	dialect *SavepointDialect
+}
+
+// Rollback 回滚到保存点
+func (s *xSavepoint) Rollback() (err error) {
+	_, err = s.tx.Execute(fmt.Sprintf(s.dialect.Rollback, s.name))
+	return
+}
+
+// Commit 释放保存点,保存点中的操作随所属事务提交
+func (s *xSavepoint) Commit() (err error) {
+	if s.dialect.Release == "" {
+		return
+	}
+	_, err = s.tx.Execute(fmt.Sprintf(s.dialect.Release, s.name))
 	return
 }
@@ -0,0 +1,114 @@
+package xdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+func itemIDs(t *testing.T, dbobj xdb.IDB) string {
+	rows, err := dbobj.Query(context.Background(), "select id from items order by id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, 0, rows.Len())
+	for i := range rows {
+		ids = append(ids, rows[i].GetString("id"))
+	}
+	return fmt.Sprint(ids)
+}
+
+func insertItem(ctx context.Context, dbobj xdb.IDB, id int) error {
+	_, err := dbobj.Exec(ctx, "insert into items(id) values(@{id})", map[string]any{"id": id})
+	return err
+}
+
+func TestTransactionContext(t *testing.T) {
+	errTest := errors.New("test")
+
+	tests := []struct {
+		name string
+		run  func(dbobj xdb.IDB) error
+		want string
+	}{
+		{name: "1.required", want: "[]", run: func(dbobj xdb.IDB) error {
+			return dbobj.TransactionContext(context.Background(), func(ctx context.Context, tx xdb.Executer) error {
+				if err := insertItem(ctx, dbobj, 1); err != nil {
+					return err
+				}
+				err := dbobj.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+					return insertItem(ctx, dbobj, 2)
+				})
+				if err != nil {
+					return err
+				}
+				return errTest
+			})
+		}},
+		{name: "2.nested", want: "[1 3]", run: func(dbobj xdb.IDB) error {
+			return dbobj.TransactionContext(context.Background(), func(ctx context.Context, tx xdb.Executer) error {
+				if err := insertItem(ctx, dbobj, 1); err != nil {
+					return err
+				}
+				err := dbobj.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+					if err := insertItem(ctx, dbobj, 2); err != nil {
+						return err
+					}
+					return errTest
+				}, xdb.WithPropagation(xdb.PropagationNested))
+				if !errors.Is(err, errTest) {
+					return fmt.Errorf("nested err = %v", err)
+				}
+				return dbobj.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+					return insertItem(ctx, dbobj, 3)
+				}, xdb.WithPropagation(xdb.PropagationNested))
+			})
+		}},
+		{name: "3.requires_new", want: "[4]", run: func(dbobj xdb.IDB) error {
+			return dbobj.TransactionContext(context.Background(), func(ctx context.Context, tx xdb.Executer) error {
+				err := dbobj.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+					return insertItem(ctx, dbobj, 4)
+				}, xdb.WithPropagation(xdb.PropagationRequiresNew))
+				if err != nil {
+					return err
+				}
+				return errTest
+			})
+		}},
+		{name: "4.with_tx", want: "[]", run: func(dbobj xdb.IDB) error {
+			tx, err := dbobj.Begin()
+			if err != nil {
+				return err
+			}
+			if err = insertItem(xdb.WithTx(context.Background(), tx), dbobj, 5); err != nil {
+				return err
+			}
+			tx.Rollback()
+			return errTest
+		}},
+		{name: "5.panic", want: "[]", run: func(dbobj xdb.IDB) error {
+			return dbobj.TransactionContext(context.Background(), func(ctx context.Context, tx xdb.Executer) error {
+				insertItem(ctx, dbobj, 6)
+				panic(errTest)
+			})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbobj := newSqliteTestDB(t, "trans_test")
+			mustExec(t, dbobj, "create table items(id integer primary key)")
+			err := tt.run(dbobj)
+			if err != nil && !errors.Is(err, errTest) {
+				if _, ok := err.(xdb.PanicError); !ok {
+					t.Fatal(err)
+				}
+			}
+			if got := itemIDs(t, dbobj); got != tt.want {
+				t.Errorf("items = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"runtime"
 
+	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
 	"github.com/zhiyunliu/glue/contrib/xdb/implement"
 	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
 	"github.com/zhiyunliu/glue/xdb"
@@ -15,19 +16,54 @@ import (
 var _ xdb.IDB = &dbWrap{}
 
 type dbWrap struct {
-	gromDB *gorm.DB
-	tpl    tpl.SQLTemplate
+	connName string
+	gromDB   *gorm.DB
+	tpl      tpl.SQLTemplate
+	retryer  *contribxdb.Retryer
+	stats    *contribxdb.PoolStats
+
+	interceptors xdb.InterceptorChain
+}
+
+// newDBWrap 构建数据库操作对象
+func newDBWrap(proto string, cfg *contribxdb.Setting, gromDB *gorm.DB) (*dbWrap, error) {
+	tpl, err := tpl.GetDBTemplate(proto)
+	if err != nil {
+		return nil, err
+	}
+	interceptors, err := xdb.BuildInterceptors(cfg.ConnName, cfg.Cfg)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := gromDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	return &dbWrap{
+		connName:     cfg.ConnName,
+		tpl:          tpl,
+		gromDB:       gromDB,
+		retryer:      contribxdb.NewRetryer(cfg, proto),
+		stats:        contribxdb.StartPoolStats(cfg, sqlDB.Stats),
+		interceptors: interceptors,
+	}, nil
 }
 
 func (d *dbWrap) Begin() (xdb.ITrans, error) {
 	txdb := d.gromDB.Begin()
+	if txdb.Error != nil {
+		return nil, txdb.Error
+	}
 	return &transWrap{
-		gromDB: txdb,
-		tpl:    d.tpl,
+		connName:     d.connName,
+		gromDB:       txdb,
+		tpl:          d.tpl,
+		interceptors: d.interceptors,
 	}, nil
 }
 
 func (d *dbWrap) Close() (err error) {
+	d.stats.Close()
 	return
 }
 
@@ -36,6 +72,9 @@ func (d *dbWrap) GetImpl() interface{} {
 }
 
 func (db *dbWrap) Query(ctx context.Context, sqls string, input any) (data xdb.Rows, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.connName); ok {
+		return tx.Query(ctx, sqls, input)
+	}
 	tmp, err := db.dbQuery(ctx, sqls, input, func(r *sql.Rows) (any, error) {
 		return implement.ResolveRows(r)
 	})
@@ -47,6 +86,9 @@ func (db *dbWrap) Query(ctx context.Context, sqls string, input any) (data xdb.R
 }
 
 func (db *dbWrap) Multi(ctx context.Context, sqls string, input any) (data []xdb.Rows, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.connName); ok {
+		return tx.Multi(ctx, sqls, input)
+	}
 	tmp, err := db.dbQuery(ctx, sqls, input, func(r *sql.Rows) (any, error) {
 		return implement.ResolveMultiRows(r)
 	})
@@ -58,6 +100,9 @@ func (db *dbWrap) Multi(ctx context.Context, sqls string, input any) (data []xdb
 }
 
 func (db *dbWrap) First(ctx context.Context, sqls string, input any) (data xdb.Row, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.connName); ok {
+		return tx.First(ctx, sqls, input)
+	}
 	tmp, err := db.dbQuery(ctx, sqls, input, func(r *sql.Rows) (any, error) {
 		return implement.ResolveFirstRow(r)
 	})
@@ -69,6 +114,9 @@ func (db *dbWrap) First(ctx context.Context, sqls string, input any) (data xdb.R
 }
 
 func (db *dbWrap) Scalar(ctx context.Context, sqls string, input any) (data interface{}, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.connName); ok {
+		return tx.Scalar(ctx, sqls, input)
+	}
 	data, err = db.dbQuery(ctx, sqls, input, func(r *sql.Rows) (any, error) {
 		return implement.ResolveScalar(r)
 	})
@@ -76,6 +124,9 @@ func (db *dbWrap) Scalar(ctx context.Context, sqls string, input any) (data inte
 }
 
 func (d *dbWrap) Exec(ctx context.Context, sql string, input any) (r xdb.Result, err error) {
+	if tx, ok := xdb.GetTx(ctx, d.connName); ok {
+		return tx.Exec(ctx, sql, input)
+	}
 	dbParam, err := implement.ResolveParams(input)
 	if err != nil {
 		return
@@ -86,39 +137,58 @@ func (d *dbWrap) Exec(ctx context.Context, sql string, input any) (r xdb.Result,
 		return
 	}
 
-	db := d.gromDB
-	db = db.Exec(query, execArgs...)
-	err = db.Error
+	info := &xdb.QueryInfo{ConnName: d.connName, Proto: d.tpl.Name(), Kind: xdb.QueryKindExec, SQL: query, Args: execArgs}
+	err = d.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) (err error) {
+		if r, err = execResult(ctx, d.gromDB, info.SQL, info.Args...); err != nil {
+			return
+		}
+		info.RowsAffected, _ = r.RowsAffected()
+		return
+	})
 	if err != nil {
-		err = implement.GetError(err, query, execArgs...)
+		err = implement.GetError(err, info.SQL, info.Args...)
 		return
 	}
-
-	r = &sqlResult{
-		rowsAffected: db.RowsAffected,
-	}
 	return
 }
 
 // Query 查询数据
 func (db *dbWrap) QueryAs(ctx context.Context, sqls string, input any, results any) (err error) {
+	if tx, ok := xdb.GetTx(ctx, db.connName); ok {
+		return tx.QueryAs(ctx, sqls, input, results)
+	}
 	return db.dbQueryAs(ctx, sqls, input, results, func(r *sql.Rows, a any) error {
 		return implement.ResolveRowsDataResult(r, results)
 	})
 }
 
 func (db *dbWrap) FirstAs(ctx context.Context, sqls string, input any, result any) (err error) {
+	if tx, ok := xdb.GetTx(ctx, db.connName); ok {
+		return tx.FirstAs(ctx, sqls, input, result)
+	}
 	return db.dbQueryAs(ctx, sqls, input, result, func(r *sql.Rows, a any) error {
 		return implement.ResolveFirstDataResult(r, result)
 	})
 }
 
-// Transaction 执行事务
+// Transaction 执行事务,与TransactionContext相同经过拦截器(tracing创建事务span,metrics计数);
+// 配置了重试策略时遇到死锁等可重试错误会重新执行整个事务
 func (d *dbWrap) Transaction(callback xdb.TransactionCallback) (err error) {
+	info := &xdb.QueryInfo{ConnName: d.connName, Proto: d.tpl.Name(), Kind: xdb.QueryKindTransaction}
+	return d.interceptors.Invoke(context.Background(), info, func(ctx context.Context, info *xdb.QueryInfo) error {
+		return d.retryer.Do(ctx, func() error {
+			return d.transaction(callback)
+		})
+	})
+}
+
+func (d *dbWrap) transaction(callback xdb.TransactionCallback) (err error) {
 	txdb := d.gromDB.Begin()
 	tt := &transWrap{
-		gromDB: txdb,
-		tpl:    d.tpl,
+		connName:     d.connName,
+		gromDB:       txdb,
+		tpl:          d.tpl,
+		interceptors: d.interceptors,
 	}
 
 	defer func() {
@@ -139,34 +209,50 @@ func (d *dbWrap) Transaction(callback xdb.TransactionCallback) (err error) {
 		tt.Rollback()
 		return
 	}
-	tt.Commit()
-	return
+	return tt.Commit()
 }
 
-func (db *dbWrap) dbQuery(ctx context.Context, sql string, input any, callback implement.DbResolveMapValCallback) (result any, err error) {
-	dbParam, err := implement.ResolveParams(input)
-	if err != nil {
-		return
+// TransactionContext 按传播方式执行事务,ctx中已绑定当前连接的事务时按传播方式加入或创建保存点,
+// 只有开启新事务时才按重试策略重新执行
+func (d *dbWrap) TransactionContext(ctx context.Context, callback xdb.TxCallback, opts ...xdb.TxOption) error {
+	if !xdb.BeginsNewTx(ctx, d.connName, opts...) {
+		return xdb.RunTransaction(ctx, d.connName, d.Begin, callback, opts...)
 	}
+	info := &xdb.QueryInfo{ConnName: d.connName, Proto: d.tpl.Name(), Kind: xdb.QueryKindTransaction}
+	return d.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) error {
+		return d.retryer.Do(ctx, func() error {
+			return xdb.RunTransaction(ctx, d.connName, d.Begin, callback, opts...)
+		})
+	})
+}
 
-	query, execArgs, err := db.tpl.GetSQLContext(sql, dbParam)
+func (db *dbWrap) dbQuery(ctx context.Context, sql string, input any, callback implement.DbResolveMapValCallback) (result any, err error) {
+	rows, err := db.dbRows(ctx, sql, input)
 	if err != nil {
-		err = implement.GetError(err, sql, input)
 		return
 	}
+	defer rows.Close()
+	result, err = callback(rows)
+	return
+}
 
-	rows, err := db.gromDB.Raw(query, execArgs...).Rows()
+func (db *dbWrap) dbQueryAs(ctx context.Context, sql string, input any, result any, callback implement.DbResolveResultCallback) (err error) {
+	rows, err := db.dbRows(ctx, sql, input)
 	if err != nil {
-		err = implement.GetError(err, query, execArgs...)
 		return
 	}
 	defer rows.Close()
-	result, err = callback(rows)
+	err = callback(rows, result)
 	return
+}
 
+// dbRows 解析模板并执行查询,返回的rows由调用方关闭
+func (db *dbWrap) dbRows(ctx context.Context, sql string, input any) (rows *sql.Rows, err error) {
+	return db.dbKindRows(ctx, xdb.QueryKindQuery, sql, input)
 }
 
-func (db *dbWrap) dbQueryAs(ctx context.Context, sql string, input any, result any, callback implement.DbResolveResultCallback) (err error) {
+// dbKindRows 按kind经过拦截器执行返回结果集的语句,返回的rows由调用方关闭
+func (db *dbWrap) dbKindRows(ctx context.Context, kind string, sql string, input any) (rows *sql.Rows, err error) {
 	dbParam, err := implement.ResolveParams(input)
 	if err != nil {
 		return
@@ -178,12 +264,83 @@ func (db *dbWrap) dbQueryAs(ctx context.Context, sql string, input any, result a
 		return
 	}
 
-	rows, err := db.gromDB.Raw(query, execArgs...).Rows()
+	info := &xdb.QueryInfo{ConnName: db.connName, Proto: db.tpl.Name(), Kind: kind, SQL: query, Args: execArgs}
+	err = db.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) (err error) {
+		rows, err = db.gromDB.Raw(info.SQL, info.Args...).Rows()
+		return
+	})
 	if err != nil {
-		err = implement.GetError(err, query, execArgs...)
+		err = implement.GetError(err, info.SQL, info.Args...)
 		return
 	}
-	defer rows.Close()
-	err = callback(rows, result)
 	return
 }
+
+// QueryStream 流式查询数据
+func (db *dbWrap) QueryStream(ctx context.Context, sqls string, input any) (cursor xdb.Cursor, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.connName); ok {
+		return tx.QueryStream(ctx, sqls, input)
+	}
+	rows, err := db.dbRows(ctx, sqls, input)
+	if err != nil {
+		return
+	}
+	return implement.NewCursor(rows)
+}
+
+// BatchExec 批量执行
+func (db *dbWrap) BatchExec(ctx context.Context, sqls string, inputs []any, opts ...xdb.BatchOption) (r xdb.Result, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.connName); ok {
+		return tx.BatchExec(ctx, sqls, inputs, opts...)
+	}
+	batchOpts := xdb.NewBatchOptions(opts...)
+	dialect := contribxdb.GetDialect(db.tpl.Name())
+	if !batchOpts.Transaction {
+		return contribxdb.ExecBatch(ctx, dialect, db.Exec, sqls, inputs, batchOpts)
+	}
+	err = db.Transaction(func(tx xdb.Executer) (terr error) {
+		r, terr = contribxdb.ExecBatch(ctx, dialect, tx.Exec, sqls, inputs, batchOpts)
+		return
+	})
+	return
+}
+
+// ExecReturning 执行返回结果集的写语句(insert ... returning/output),按写操作经过拦截器
+func (db *dbWrap) ExecReturning(ctx context.Context, sqls string, input any) (data xdb.Rows, err error) {
+	if tx, ok := xdb.GetTx(ctx, db.connName); ok {
+		if rexec, ok := tx.(contribxdb.ReturningExecuter); ok {
+			return rexec.ExecReturning(ctx, sqls, input)
+		}
+	}
+	rows, err := db.dbKindRows(ctx, xdb.QueryKindExec, sqls, input)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	return implement.ResolveRows(rows)
+}
+
+// Insert 按struct插入一行数据,返回自增列的值
+func (db *dbWrap) Insert(ctx context.Context, obj any) (id int64, err error) {
+	return contribxdb.ModelInsert(ctx, db, contribxdb.GetDialect(db.tpl.Name()), obj)
+}
+
+// Update 按主键更新struct对应的行
+func (db *dbWrap) Update(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return contribxdb.ModelUpdate(ctx, db, obj)
+}
+
+// Upsert 按主键存在时更新,否则插入
+func (db *dbWrap) Upsert(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return contribxdb.ModelUpsert(ctx, db, contribxdb.GetDialect(db.tpl.Name()), obj)
+}
+
+// DeleteByKey 按主键删除struct对应的行
+func (db *dbWrap) DeleteByKey(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return contribxdb.ModelDeleteByKey(ctx, db, obj)
+}
+
+// GetByKey 按主键查询并填充struct
+func (db *dbWrap) GetByKey(ctx context.Context, obj any) (found bool, err error) {
+	return contribxdb.ModelGetByKey(ctx, db, obj)
+}
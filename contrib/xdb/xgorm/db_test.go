@@ -0,0 +1,62 @@
+package xgorm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
+	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
+	"github.com/zhiyunliu/glue/xdb"
+	"gorm.io/driver/sqlite"
+)
+
+type insertUser struct {
+	ID   int64  `db:"id,pk,auto"`
+	Name string `db:"name"`
+}
+
+func (insertUser) TableName() string {
+	return "users"
+}
+
+// TestInsert_LastInsertId 与gorm.mysql相同通过LastInsertId获取自增值
+func TestInsert_LastInsertId(t *testing.T) {
+	proto := "gorm.insert_test"
+	tpl.Register(tpl.NewFixed(proto, "?"))
+	contribxdb.RegisterDialect(proto, &contribxdb.Dialect{})
+	callbackCache[proto] = sqlite.Open
+
+	cfg := contribxdb.NewConfig("insert_test")
+	cfg.Cfg.Conn = filepath.Join(t.TempDir(), "insert_test.db")
+	gromDB, err := buildGormDB(proto, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbobj, err := newDBWrap(proto, cfg, gromDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbobj.Close()
+	ctx := context.Background()
+	if _, err = dbobj.Exec(ctx, "create table users(id integer primary key autoincrement,name text)", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := int64(1); i <= 2; i++ {
+		user := &insertUser{Name: "a"}
+		if id, err := dbobj.Insert(ctx, user); err != nil || id != i || user.ID != i {
+			t.Fatalf("Insert() = %v,%v,%v, want %d", id, user.ID, err, i)
+		}
+	}
+	err = dbobj.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+		user := &insertUser{Name: "b"}
+		if id, err := tx.Insert(ctx, user); err != nil || id != 3 || user.ID != 3 {
+			t.Errorf("Insert() in transaction = %v,%v,%v", id, user.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
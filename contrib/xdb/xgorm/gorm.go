@@ -1,12 +1,15 @@
 package xgorm
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"runtime"
 	"time"
 
 	xlog "github.com/zhiyunliu/glue/log"
+	"github.com/zhiyunliu/glue/secret"
 	"github.com/zhiyunliu/glue/xdb"
 
 	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
@@ -43,6 +46,10 @@ func buildGormDB(driverName string, cfg *contribxdb.Setting, opts ...xdb.Option)
 		cfg.Cfg.LongQueryTime = 500
 	}
 
+	//gorm使用单一连接池,不支持按secret_refresh切换连接
+	if cfg.Cfg.SecretRefresh > 0 && secret.IsReference(cfg.Cfg.Conn) {
+		xlog.Warnf("xdb.secret:%s,gorm连接不支持secret_refresh,连接字符串只在启动时解析", cfg.ConnName)
+	}
 	newCfg, err := xdb.DefaultRefactor(cfg.ConnName, cfg.Cfg)
 	if err != nil {
 		return
@@ -87,15 +94,7 @@ func buildGormDB(driverName string, cfg *contribxdb.Setting, opts ...xdb.Option)
 	return db, nil
 }
 
-type sqlResult struct {
-	lastInsertId int64
-	rowsAffected int64
-}
-
-func (r sqlResult) LastInsertId() (int64, error) {
-	return r.lastInsertId, nil
-}
-
-func (r *sqlResult) RowsAffected() (int64, error) {
-	return r.rowsAffected, nil
+// execResult 直接通过gorm的连接池(事务中为sql.Tx)执行语句,返回驱动的sql.Result以获取LastInsertId
+func execResult(ctx context.Context, db *gorm.DB, query string, args ...any) (sql.Result, error) {
+	return db.Statement.ConnPool.ExecContext(ctx, query, args...)
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/zhiyunliu/glue/config"
 	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
+	xsqlserver "github.com/zhiyunliu/glue/contrib/xdb/sqlserver"
 	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
 	"github.com/zhiyunliu/glue/xdb"
 	"gorm.io/driver/sqlserver"
@@ -14,12 +15,14 @@ func init() {
 
 	resolver := &mssqlResolver{Proto: "grom.mssql"}
 	xdb.Register(resolver)
-	tpl.Register(tpl.NewFixed(resolver.Proto, "?"))
+	tpl.Register(tpl.NewFixed(resolver.Proto, "?", tpl.NewPageSymbol(tpl.OffsetFetch)))
+	contribxdb.RegisterDialect(resolver.Proto, &contribxdb.Dialect{MaxParams: xsqlserver.MaxParams, MaxRows: xsqlserver.MaxRows, Retryable: xsqlserver.Retryable, Model: contribxdb.ModelDialect{Returning: contribxdb.ReturningOutput, Upsert: contribxdb.UpsertMerge}})
 	callbackCache[resolver.Proto] = sqlserver.Open
 
 	rresolver := &mssqlResolver{Proto: "gorm.mssql"}
 	xdb.Register(rresolver)
-	tpl.Register(tpl.NewFixed(rresolver.Proto, "?"))
+	tpl.Register(tpl.NewFixed(rresolver.Proto, "?", tpl.NewPageSymbol(tpl.OffsetFetch)))
+	contribxdb.RegisterDialect(rresolver.Proto, &contribxdb.Dialect{MaxParams: xsqlserver.MaxParams, MaxRows: xsqlserver.MaxRows, Retryable: xsqlserver.Retryable, Model: contribxdb.ModelDialect{Returning: contribxdb.ReturningOutput, Upsert: contribxdb.UpsertMerge}})
 	callbackCache[rresolver.Proto] = sqlserver.Open
 }
 
@@ -41,12 +44,5 @@ func (s *mssqlResolver) Resolve(connName string, setting config.Config, opts ...
 	if err != nil {
 		return nil, err
 	}
-	tpl, err := tpl.GetDBTemplate(s.Proto)
-	if err != nil {
-		return nil, err
-	}
-	return &dbWrap{
-		tpl:    tpl,
-		gromDB: gromDB,
-	}, nil
+	return newDBWrap(s.Proto, cfg, gromDB)
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/zhiyunliu/glue/config"
 	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
+	xmysql "github.com/zhiyunliu/glue/contrib/xdb/mysql"
 	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
 	"github.com/zhiyunliu/glue/xdb"
 	"gorm.io/driver/mysql"
@@ -15,11 +16,13 @@ func init() {
 	resolver := &mysqlResolver{Proto: "grom.mysql"}
 	xdb.Register(resolver)
 	tpl.Register(tpl.NewFixed(resolver.Proto, "?"))
+	contribxdb.RegisterDialect(resolver.Proto, &contribxdb.Dialect{MaxParams: xmysql.MaxParams, Retryable: xmysql.Retryable, Model: contribxdb.ModelDialect{Upsert: contribxdb.UpsertOnDuplicateKey}})
 	callbackCache[resolver.Proto] = mysql.Open
 
 	rresolver := &mysqlResolver{Proto: "gorm.mysql"}
 	xdb.Register(rresolver)
 	tpl.Register(tpl.NewFixed(rresolver.Proto, "?"))
+	contribxdb.RegisterDialect(rresolver.Proto, &contribxdb.Dialect{MaxParams: xmysql.MaxParams, Retryable: xmysql.Retryable, Model: contribxdb.ModelDialect{Upsert: contribxdb.UpsertOnDuplicateKey}})
 	callbackCache[rresolver.Proto] = mysql.Open
 
 }
@@ -42,12 +45,5 @@ func (s *mysqlResolver) Resolve(connName string, setting config.Config, opts ...
 	if err != nil {
 		return nil, err
 	}
-	tpl, err := tpl.GetDBTemplate(s.Proto)
-	if err != nil {
-		return nil, err
-	}
-	return &dbWrap{
-		tpl:    tpl,
-		gromDB: gromDB,
-	}, nil
+	return newDBWrap(s.Proto, cfg, gromDB)
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/zhiyunliu/glue/config"
 	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
+	xpostgres "github.com/zhiyunliu/glue/contrib/xdb/postgres"
 	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
 	"github.com/zhiyunliu/glue/xdb"
 	"gorm.io/driver/postgres"
@@ -15,11 +16,13 @@ func init() {
 	resolver := &postgresResolver{Proto: "grom.postgres"}
 	xdb.Register(resolver)
 	tpl.Register(tpl.NewFixed(resolver.Proto, "$"))
+	contribxdb.RegisterDialect(resolver.Proto, &contribxdb.Dialect{MaxParams: xpostgres.MaxParams, Retryable: xpostgres.Retryable, Model: contribxdb.ModelDialect{Returning: contribxdb.ReturningClause}})
 	callbackCache[resolver.Proto] = postgres.Open
 
 	rresolver := &postgresResolver{Proto: "gorm.postgres"}
 	xdb.Register(rresolver)
 	tpl.Register(tpl.NewFixed(rresolver.Proto, "$"))
+	contribxdb.RegisterDialect(rresolver.Proto, &contribxdb.Dialect{MaxParams: xpostgres.MaxParams, Retryable: xpostgres.Retryable, Model: contribxdb.ModelDialect{Returning: contribxdb.ReturningClause}})
 	callbackCache[rresolver.Proto] = postgres.Open
 }
 
@@ -41,12 +44,5 @@ func (s *postgresResolver) Resolve(connName string, setting config.Config, opts
 	if err != nil {
 		return nil, err
 	}
-	tpl, err := tpl.GetDBTemplate(s.Proto)
-	if err != nil {
-		return nil, err
-	}
-	return &dbWrap{
-		tpl:    tpl,
-		gromDB: gromDB,
-	}, nil
+	return newDBWrap(s.Proto, cfg, gromDB)
 }
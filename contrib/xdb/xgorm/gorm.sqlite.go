@@ -3,9 +3,9 @@ package xgorm
 import (
 	"fmt"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/zhiyunliu/glue/config"
 	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
+	xsqlite "github.com/zhiyunliu/glue/contrib/xdb/sqlite"
 	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
 	"github.com/zhiyunliu/glue/xdb"
 	"gorm.io/driver/sqlite"
@@ -16,11 +16,13 @@ func init() {
 	resolver := &sqliteResolver{Proto: "grom.sqlite"}
 	xdb.Register(resolver)
 	tpl.Register(tpl.NewFixed(resolver.Proto, "?"))
+	contribxdb.RegisterDialect(resolver.Proto, &contribxdb.Dialect{MaxParams: xsqlite.MaxParams, Retryable: xsqlite.Retryable, Model: contribxdb.ModelDialect{Returning: contribxdb.ReturningClause}})
 	callbackCache[resolver.Proto] = sqlite.Open
 
 	rresolver := &sqliteResolver{Proto: "gorm.sqlite"}
 	xdb.Register(rresolver)
 	tpl.Register(tpl.NewFixed(rresolver.Proto, "?"))
+	contribxdb.RegisterDialect(rresolver.Proto, &contribxdb.Dialect{MaxParams: xsqlite.MaxParams, Retryable: xsqlite.Retryable, Model: contribxdb.ModelDialect{Returning: contribxdb.ReturningClause}})
 	callbackCache[rresolver.Proto] = sqlite.Open
 }
 
@@ -42,12 +44,5 @@ func (s *sqliteResolver) Resolve(connName string, setting config.Config, opts ..
 	if err != nil {
 		return nil, err
 	}
-	tpl, err := tpl.GetDBTemplate(s.Proto)
-	if err != nil {
-		return nil, err
-	}
-	return &dbWrap{
-		tpl:    tpl,
-		gromDB: gromDB,
-	}, nil
+	return newDBWrap(s.Proto, cfg, gromDB)
 }
@@ -3,7 +3,9 @@ package xgorm
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
+	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
 	"github.com/zhiyunliu/glue/contrib/xdb/implement"
 	"github.com/zhiyunliu/glue/contrib/xdb/tpl"
 	"github.com/zhiyunliu/glue/xdb"
@@ -13,8 +15,12 @@ import (
 var _ xdb.ITrans = &transWrap{}
 
 type transWrap struct {
-	gromDB *gorm.DB
-	tpl    tpl.SQLTemplate
+	connName string
+	gromDB   *gorm.DB
+	tpl      tpl.SQLTemplate
+	spSeq    int //保存点序号
+
+	interceptors xdb.InterceptorChain
 }
 
 func (d *transWrap) Rollback() (err error) {
@@ -100,15 +106,19 @@ func (d *transWrap) Exec(ctx context.Context, sql string, input any) (r xdb.Resu
 		return
 	}
 
-	tmpDb := d.gromDB.Exec(query, execArgs...)
-	err = tmpDb.Error
+	info := &xdb.QueryInfo{ConnName: d.connName, Proto: d.tpl.Name(), Kind: xdb.QueryKindExec, SQL: query, Args: execArgs}
+	err = d.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) (err error) {
+		if r, err = execResult(ctx, d.gromDB, info.SQL, info.Args...); err != nil {
+			return
+		}
+		info.RowsAffected, _ = r.RowsAffected()
+		return
+	})
 	if err != nil {
-		err = implement.GetError(err, query, execArgs...)
+		err = implement.GetError(err, info.SQL, info.Args...)
 		return
 	}
-	return &sqlResult{
-		rowsAffected: tmpDb.RowsAffected,
-	}, err
+	return
 }
 
 // Query 查询数据
@@ -125,28 +135,32 @@ func (d *transWrap) FirstAs(ctx context.Context, sqls string, input any, result
 }
 
 func (db *transWrap) dbQuery(ctx context.Context, sql string, input any, callback implement.DbResolveMapValCallback) (result any, err error) {
-	dbParam, err := implement.ResolveParams(input)
+	rows, err := db.dbRows(ctx, sql, input)
 	if err != nil {
 		return
 	}
+	defer rows.Close()
+	result, err = callback(rows)
+	return
+}
 
-	query, execArgs, err := db.tpl.GetSQLContext(sql, dbParam)
-	if err != nil {
-		err = implement.GetError(err, sql, input)
-		return
-	}
-
-	rows, err := db.gromDB.Raw(query, execArgs...).Rows()
+func (db *transWrap) dbQueryAs(ctx context.Context, sql string, input any, result any, callback implement.DbResolveResultCallback) (err error) {
+	rows, err := db.dbRows(ctx, sql, input)
 	if err != nil {
-		err = implement.GetError(err, query, execArgs...)
 		return
 	}
 	defer rows.Close()
-	result, err = callback(rows)
+	err = callback(rows, result)
 	return
 }
 
-func (db *transWrap) dbQueryAs(ctx context.Context, sql string, input any, result any, callback implement.DbResolveResultCallback) (err error) {
+// dbRows 解析模板并执行查询,返回的rows由调用方关闭
+func (db *transWrap) dbRows(ctx context.Context, sql string, input any) (rows *sql.Rows, err error) {
+	return db.dbKindRows(ctx, xdb.QueryKindQuery, sql, input)
+}
+
+// dbKindRows 按kind经过拦截器执行返回结果集的语句,返回的rows由调用方关闭
+func (db *transWrap) dbKindRows(ctx context.Context, kind string, sql string, input any) (rows *sql.Rows, err error) {
 	dbParam, err := implement.ResolveParams(input)
 	if err != nil {
 		return
@@ -158,12 +172,98 @@ func (db *transWrap) dbQueryAs(ctx context.Context, sql string, input any, resul
 		return
 	}
 
-	rows, err := db.gromDB.Raw(query, execArgs...).Rows()
+	info := &xdb.QueryInfo{ConnName: db.connName, Proto: db.tpl.Name(), Kind: kind, SQL: query, Args: execArgs}
+	err = db.interceptors.Invoke(ctx, info, func(ctx context.Context, info *xdb.QueryInfo) (err error) {
+		rows, err = db.gromDB.Raw(info.SQL, info.Args...).Rows()
+		return
+	})
 	if err != nil {
-		err = implement.GetError(err, query, execArgs...)
+		err = implement.GetError(err, info.SQL, info.Args...)
 		return
 	}
-	defer rows.Close()
-	err = callback(rows, result)
 	return
 }
+
+// QueryStream 流式查询数据
+func (db *transWrap) QueryStream(ctx context.Context, sqls string, input any) (cursor xdb.Cursor, err error) {
+	rows, err := db.dbRows(ctx, sqls, input)
+	if err != nil {
+		return
+	}
+	return implement.NewCursor(rows)
+}
+
+// BatchExec 批量执行,事务内忽略Transaction选项
+func (db *transWrap) BatchExec(ctx context.Context, sqls string, inputs []any, opts ...xdb.BatchOption) (r xdb.Result, err error) {
+	batchOpts := xdb.NewBatchOptions(opts...)
+	return contribxdb.ExecBatch(ctx, contribxdb.GetDialect(db.tpl.Name()), db.Exec, sqls, inputs, batchOpts)
+}
+
+// ExecReturning 执行返回结果集的写语句(insert ... returning/output),按写操作经过拦截器
+func (db *transWrap) ExecReturning(ctx context.Context, sqls string, input any) (data xdb.Rows, err error) {
+	rows, err := db.dbKindRows(ctx, xdb.QueryKindExec, sqls, input)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	return implement.ResolveRows(rows)
+}
+
+// Insert 按struct插入一行数据,返回自增列的值
+func (db *transWrap) Insert(ctx context.Context, obj any) (id int64, err error) {
+	return contribxdb.ModelInsert(ctx, db, contribxdb.GetDialect(db.tpl.Name()), obj)
+}
+
+// Update 按主键更新struct对应的行
+func (db *transWrap) Update(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return contribxdb.ModelUpdate(ctx, db, obj)
+}
+
+// Upsert 按主键存在时更新,否则插入
+func (db *transWrap) Upsert(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return contribxdb.ModelUpsert(ctx, db, contribxdb.GetDialect(db.tpl.Name()), obj)
+}
+
+// DeleteByKey 按主键删除struct对应的行
+func (db *transWrap) DeleteByKey(ctx context.Context, obj any) (r xdb.Result, err error) {
+	return contribxdb.ModelDeleteByKey(ctx, db, obj)
+}
+
+// GetByKey 按主键查询并填充struct
+func (db *transWrap) GetByKey(ctx context.Context, obj any) (found bool, err error) {
+	return contribxdb.ModelGetByKey(ctx, db, obj)
+}
+
+// ConnName 事务所属的数据库连接名称
+func (d *transWrap) ConnName() string {
+	return d.connName
+}
+
+// Savepoint 在当前事务中创建保存点,保存点语句由gorm按数据库方言生成
+func (d *transWrap) Savepoint() (xdb.ITrans, error) {
+	d.spSeq++
+	sp := &savepointWrap{
+		transWrap: d,
+		name:      fmt.Sprintf("glue_sp_%d", d.spSeq),
+	}
+	if err := d.gromDB.SavePoint(sp.name).Error; err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// savepointWrap 保存点,与所属事务共用连接,回滚时回滚到保存点
+type savepointWrap struct {
+	*transWrap
+	name string
+}
+
+// Rollback 回滚到保存点
+func (s *savepointWrap) Rollback() error {
+	return s.gromDB.RollbackTo(s.name).Error
+}
+
+// Commit 保存点中的操作随所属事务提交
+func (s *savepointWrap) Commit() error {
+	return nil
+}
@@ -1,9 +1,11 @@
 package http
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/glue/secret"
 	_ "github.com/zhiyunliu/glue/selector/p2c"
 	_ "github.com/zhiyunliu/glue/selector/random"
 	_ "github.com/zhiyunliu/glue/selector/wrr"
@@ -33,6 +35,10 @@ func (s *httpResolver) Resolve(name string, cfg config.Config) (xhttp.Client, er
 	if err != nil {
 		return nil, fmt.Errorf("读取http配置:%w", err)
 	}
+	setval.ProxyURL, err = secret.Resolve(context.Background(), setval.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("读取http配置:%w", err)
+	}
 	return NewRequest(setval), nil
 }
 
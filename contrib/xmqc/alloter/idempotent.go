@@ -0,0 +1,263 @@
+package alloter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zhiyunliu/glue/cache"
+	"github.com/zhiyunliu/glue/metrics"
+	"github.com/zhiyunliu/glue/queue"
+	"github.com/zhiyunliu/glue/standard"
+	"github.com/zhiyunliu/glue/xdb"
+	"github.com/zhiyunliu/glue/xmqc"
+)
+
+const (
+	//处理中的消息标识默认过期时间(秒),任务设置了visibility_timeout时为其2倍
+	defaultClaimExpire = 60
+	//xdb存储清理过期标识的间隔(毫秒)
+	idempotentCleanupInterval = int64(time.Minute / time.Millisecond)
+
+	idempotentProcessing = 0
+	idempotentDone       = 1
+
+	//重新投递的消息保留去重标识,使用队列消息id作为标识时重新投递后的消息id会变化
+	headerIdempotentKey = "x-xmqc-idempotent-key"
+)
+
+var (
+	getCache = func(name string) cache.ICache {
+		return standard.GetInstance(cache.TypeNode).(cache.StandardCache).GetCache(name)
+	}
+	getDB = func(name string) xdb.IDB {
+		return standard.GetInstance(xdb.DbTypeNode).(xdb.StandardDB).GetDB(name)
+	}
+	getMetricProvider = func(proto string) metrics.Provider {
+		return standard.GetInstance(metrics.TypeNode).(metrics.StandardMetric).GetProvider(proto)
+	}
+)
+
+// idempotentStore 消息标识存储
+type idempotentStore interface {
+	//Claim 占用标识,标识已存在时返回false及其状态(处理中或已处理)
+	Claim(ctx context.Context, key string, expire int) (claimed bool, status int, err error)
+	//Done 处理成功后保留标识expire秒
+	Done(ctx context.Context, key string, expire int) error
+	//Release 处理失败后释放标识
+	Release(ctx context.Context, key string) error
+}
+
+// idempotentGuard 队列的消息去重,
+// 配置了metrics时通过计数器记录重复消息,标签依次为: mqc.idempotent,队列名称,skipped(已处理)或processing(处理中),空
+type idempotentGuard struct {
+	queue       string
+	cfg         *xmqc.Idempotent
+	store       idempotentStore
+	claimExpire int
+	provider    metrics.Provider
+}
+
+// newIdempotentGuard 任务未配置idempotent时返回nil;
+// 未配置key时使用队列的消息id作为标识,consumer的消息id在重新投递时会变化(未实现queue.IMessageIdMQC)时返回错误
+func newIdempotentGuard(task *xmqc.Task, consumer queue.IMQC) (guard *idempotentGuard, err error) {
+	cfg := task.Idempotent
+	if cfg == nil {
+		return nil, nil
+	}
+	if cfg.Key == "" {
+		if c, ok := consumer.(queue.IMessageIdMQC); !ok || !c.StableMessageId() {
+			return nil, fmt.Errorf("mqc队列[%s]的消息id在重新投递时会变化,idempotent需要配置key", task.Queue)
+		}
+	}
+	defer func() {
+		if obj := recover(); obj != nil {
+			err = fmt.Errorf("mqc队列[%s]的idempotent.store[%s]配置错误:%v", task.Queue, cfg.Store, obj)
+		}
+	}()
+	guard = &idempotentGuard{
+		queue:       task.Queue,
+		cfg:         cfg,
+		claimExpire: defaultClaimExpire,
+	}
+	if task.VisibilityTimeout > 0 {
+		guard.claimExpire = task.VisibilityTimeout * 2
+	}
+	proto, name, _ := strings.Cut(cfg.Store, "://")
+	switch proto {
+	case "cache":
+		guard.store = &cacheStore{cache: getCache(name)}
+	case "xdb":
+		guard.store, err = newXDBStore(getDB(name), cfg.GetTable())
+	default:
+		err = fmt.Errorf("mqc队列[%s]的idempotent.store[%s]不支持,格式为cache://name或xdb://name", task.Queue, cfg.Store)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Metrics != "" {
+		guard.provider = getMetricProvider(cfg.Metrics)
+	}
+	return guard, nil
+}
+
+// key 消息标识,为空时不去重;mqc服务重新投递的消息使用原消息的标识
+func (g *idempotentGuard) key(m queue.IMQCMessage) string {
+	if key := m.GetMessage().Header()[headerIdempotentKey]; key != "" {
+		return key
+	}
+	id := ""
+	if g.cfg.Key != "" {
+		id = m.GetMessage().Header()[g.cfg.Key]
+	}
+	if id == "" {
+		id = m.MessageId()
+	}
+	if id == "" {
+		return ""
+	}
+	return g.queue + ":" + id
+}
+
+// claim 占用消息标识,返回false表示重复消息,status为已有标识的状态
+func (g *idempotentGuard) claim(ctx context.Context, key string) (bool, int, error) {
+	claimed, status, err := g.store.Claim(ctx, key, g.claimExpire)
+	if err != nil || claimed {
+		return claimed, status, err
+	}
+	result := "skipped"
+	if status != idempotentDone {
+		result = "processing"
+	}
+	if g.provider != nil && g.provider.Counter() != nil {
+		g.provider.Counter().With("mqc.idempotent", g.queue, result, "").Inc()
+	}
+	return false, status, nil
+}
+
+// finish 处理成功时保留标识,否则释放
+func (g *idempotentGuard) finish(ctx context.Context, key string, success bool) error {
+	if success {
+		return g.store.Done(ctx, key, g.cfg.GetTTL())
+	}
+	return g.store.Release(ctx, key)
+}
+
+// cacheStore 基于cache的标识存储,key为 glue:mqc:idempotent:<队列名称>:<消息标识>
+type cacheStore struct {
+	cache cache.ICache
+}
+
+func (s *cacheStore) cacheKey(key string) string {
+	return "glue:mqc:idempotent:" + key
+}
+
+// Claim 写入处理中标识,已存在时读取其状态;读取前标识过期时再次尝试写入
+func (s *cacheStore) Claim(ctx context.Context, key string, expire int) (bool, int, error) {
+	cacheKey := s.cacheKey(key)
+	for i := 0; i < 2; i++ {
+		ok, err := s.cache.SetNX(ctx, cacheKey, idempotentProcessing, expire)
+		if err != nil || ok {
+			return ok, idempotentProcessing, err
+		}
+		val, err := s.cache.Get(ctx, cacheKey)
+		if errors.Is(err, cache.Nil) {
+			continue
+		}
+		if err != nil {
+			return false, idempotentProcessing, err
+		}
+		if val == strconv.Itoa(idempotentDone) {
+			return false, idempotentDone, nil
+		}
+		return false, idempotentProcessing, nil
+	}
+	return false, idempotentProcessing, nil
+}
+
+func (s *cacheStore) Done(ctx context.Context, key string, expire int) error {
+	return s.cache.Set(ctx, s.cacheKey(key), idempotentDone, expire)
+}
+
+func (s *cacheStore) Release(ctx context.Context, key string) error {
+	return s.cache.Del(ctx, s.cacheKey(key))
+}
+
+// xdbStore 基于数据库的标识存储,表不存在时自动创建;过期时间按应用服务器时间计算,过期的标识每分钟清理一次
+type xdbStore struct {
+	db          xdb.IDB
+	table       string
+	lastCleanup atomic.Int64
+}
+
+func newXDBStore(db xdb.IDB, table string) (*xdbStore, error) {
+	s := &xdbStore{db: db, table: table}
+	err := xdb.EnsureTable(context.Background(), db, table, fmt.Sprintf("create table %s(msg_key varchar(255) not null primary key,status int not null,expire_at numeric(19) not null)", table))
+	if err != nil {
+		return nil, fmt.Errorf("创建消息去重表[%s]出错:%w", table, err)
+	}
+	return s, nil
+}
+
+// Claim 插入标识,已存在时仅在标识过期后占用
+func (s *xdbStore) Claim(ctx context.Context, key string, expire int) (bool, int, error) {
+	now := time.Now().UnixMilli()
+	s.cleanup(ctx, now)
+	params := map[string]any{
+		"key":       key,
+		"status":    idempotentProcessing,
+		"now":       now,
+		"expire_at": now + int64(expire)*1000,
+	}
+	_, insertErr := s.db.Exec(ctx, fmt.Sprintf("insert into %s(msg_key,status,expire_at) values(@{key},@{status},@{expire_at})", s.table), params)
+	if insertErr == nil {
+		return true, idempotentProcessing, nil
+	}
+	result, err := s.db.Exec(ctx, fmt.Sprintf("update %s set status=@{status},expire_at=@{expire_at} where msg_key=@{key} and expire_at<@{now}", s.table), params)
+	if err != nil {
+		return false, idempotentProcessing, err
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		return true, idempotentProcessing, nil
+	}
+	//标识不存在时插入失败的原因不是重复
+	rows, err := s.db.Query(xdb.WithReadPrimary(ctx), fmt.Sprintf("select status from %s where msg_key=@{key}", s.table), params)
+	if err != nil {
+		return false, idempotentProcessing, err
+	}
+	if len(rows) == 0 {
+		return false, idempotentProcessing, insertErr
+	}
+	status, err := rows[0].GetInt("status")
+	return false, status, err
+}
+
+func (s *xdbStore) Done(ctx context.Context, key string, expire int) error {
+	_, err := s.db.Exec(ctx, fmt.Sprintf("update %s set status=@{status},expire_at=@{expire_at} where msg_key=@{key}", s.table), map[string]any{
+		"key":       key,
+		"status":    idempotentDone,
+		"expire_at": time.Now().UnixMilli() + int64(expire)*1000,
+	})
+	return err
+}
+
+func (s *xdbStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.Exec(ctx, fmt.Sprintf("delete from %s where msg_key=@{key} and status=@{status}", s.table), map[string]any{
+		"key":    key,
+		"status": idempotentProcessing,
+	})
+	return err
+}
+
+// cleanup 删除过期的标识,多个并发调用中只有一个执行
+func (s *xdbStore) cleanup(ctx context.Context, now int64) {
+	last := s.lastCleanup.Load()
+	if now-last < idempotentCleanupInterval || !s.lastCleanup.CompareAndSwap(last, now) {
+		return
+	}
+	s.db.Exec(ctx, fmt.Sprintf("delete from %s where expire_at<@{now}", s.table), map[string]any{"now": now})
+}
@@ -0,0 +1,190 @@
+package alloter
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhiyunliu/glue/cache"
+	cachememory "github.com/zhiyunliu/glue/contrib/cache/memory"
+	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
+	_ "github.com/zhiyunliu/glue/contrib/xdb/sqlite"
+	"github.com/zhiyunliu/glue/metrics"
+	"github.com/zhiyunliu/glue/queue"
+	"github.com/zhiyunliu/glue/xmqc"
+)
+
+type testCounter struct {
+	lock   *sync.Mutex
+	lvs    []string
+	values map[string]float64
+}
+
+func (c *testCounter) With(lvs ...string) metrics.Counter {
+	return &testCounter{lock: c.lock, lvs: lvs, values: c.values}
+}
+func (c *testCounter) Inc() { c.Add(1) }
+func (c *testCounter) Add(delta float64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.values[fmt.Sprint(c.lvs)] += delta
+}
+
+type testProvider struct {
+	counter *testCounter
+}
+
+func (p *testProvider) Name() string               { return "test" }
+func (p *testProvider) Counter() metrics.Counter   { return p.counter }
+func (p *testProvider) Observer() metrics.Observer { return nil }
+func (p *testProvider) Gauge() metrics.Gauge       { return nil }
+func (p *testProvider) GetImpl() interface{}       { return nil }
+
+func TestIdempotentSkipDuplicate(t *testing.T) {
+	counter := &testCounter{lock: &sync.Mutex{}, values: map[string]float64{}}
+	origCache, origProvider := getCache, getMetricProvider
+	getCache = func(name string) cache.ICache { return cachememory.New(nil) }
+	getMetricProvider = func(proto string) metrics.Provider { return &testProvider{counter: counter} }
+	defer func() { getCache, getMetricProvider = origCache, origProvider }()
+
+	tasks := []*xmqc.Task{{
+		Queue:      "order",
+		Service:    "/order",
+		Idempotent: &xmqc.Idempotent{Store: "cache://local", Key: "x-order-id", Metrics: "test"},
+	}}
+	mqp, deliveries := newTestProcessor(t, tasks, func(header map[string]string) int {
+		//订单2首次处理失败,重试时成功
+		if header["x-order-id"] == "2" && header[headerRetryCount] == "0" {
+			return 500
+		}
+		return 200
+	})
+	mqp.Push("order", queue.NewMsg("{}", queue.WithHeader("x-order-id", "1")))
+	nextDelivery(t, deliveries, time.Second)
+	mqp.Push("order", queue.NewMsg("{}", queue.WithHeader("x-order-id", "1")))
+	noDelivery(t, deliveries, 100*time.Millisecond)
+
+	//处理失败时释放标识,重试的消息可以再次处理
+	mqp.Push("order", queue.NewMsg("{}", queue.WithHeader("x-order-id", "2")))
+	nextDelivery(t, deliveries, time.Second)
+	d := nextDelivery(t, deliveries, 2*time.Second)
+	if d.header["x-order-id"] != "2" || d.header[headerRetryCount] != "1" {
+		t.Fatalf("retry delivery = %+v", d)
+	}
+	if v := counter.values["[mqc.idempotent order skipped ]"]; v != 1 {
+		t.Fatalf("skipped = %v, want 1", v)
+	}
+}
+
+func TestIdempotentXDBStore(t *testing.T) {
+	setting := contribxdb.NewConfig("idempotent_test")
+	setting.Cfg.Conn = filepath.Join(t.TempDir(), "idempotent.db")
+	db, err := contribxdb.NewDB("sqlite", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store, err := newXDBStore(db, xmqc.DefaultIdempotentTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if ok, _, err := store.Claim(ctx, "q:1", 60); !ok || err != nil {
+		t.Fatalf("Claim() = %v, %v", ok, err)
+	}
+	if ok, status, err := store.Claim(ctx, "q:1", 60); ok || status != idempotentProcessing || err != nil {
+		t.Fatalf("Claim() processing = %v, %d, %v, want false", ok, status, err)
+	}
+	if err = store.Release(ctx, "q:1"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _, _ := store.Claim(ctx, "q:1", 60); !ok {
+		t.Fatal("Claim() after Release should succeed")
+	}
+	if err = store.Done(ctx, "q:1", 60); err != nil {
+		t.Fatal(err)
+	}
+	store.Release(ctx, "q:1")
+	if ok, status, _ := store.Claim(ctx, "q:1", 60); ok || status != idempotentDone {
+		t.Fatalf("Claim() after Done = %v, %d, want false, done", ok, status)
+	}
+
+	//过期后可以再次占用
+	if ok, _, _ := store.Claim(ctx, "q:2", 0); !ok {
+		t.Fatal("Claim() q:2 should succeed")
+	}
+	time.Sleep(2 * time.Millisecond)
+	if ok, _, err := store.Claim(ctx, "q:2", 60); !ok || err != nil {
+		t.Fatalf("Claim() expired = %v, %v", ok, err)
+	}
+}
+
+func TestIdempotentCacheStoreStatus(t *testing.T) {
+	store := &cacheStore{cache: cachememory.New(nil)}
+	ctx := context.Background()
+	if ok, _, err := store.Claim(ctx, "q:1", 60); !ok || err != nil {
+		t.Fatalf("Claim() = %v, %v", ok, err)
+	}
+	if ok, status, err := store.Claim(ctx, "q:1", 60); ok || status != idempotentProcessing || err != nil {
+		t.Fatalf("Claim() processing = %v, %d, %v", ok, status, err)
+	}
+	store.Done(ctx, "q:1", 60)
+	if ok, status, err := store.Claim(ctx, "q:1", 60); ok || status != idempotentDone || err != nil {
+		t.Fatalf("Claim() done = %v, %d, %v", ok, status, err)
+	}
+}
+
+// TestIdempotentProcessingRedelivery 处理超时重新投递的消息标识仍在处理中时不能确认,原处理中断后标识过期再次处理
+func TestIdempotentProcessingRedelivery(t *testing.T) {
+	origCache := getCache
+	store := cachememory.New(nil)
+	getCache = func(name string) cache.ICache { return store }
+	defer func() { getCache = origCache }()
+
+	tasks := []*xmqc.Task{{
+		Queue:             "order",
+		Service:           "/order",
+		VisibilityTimeout: 1,
+		Retry:             &xmqc.RetryPolicy{MaxAttempts: 10},
+		Idempotent:        &xmqc.Idempotent{Store: "cache://local"},
+	}}
+	release := make(chan struct{})
+	mqp, deliveries := newTestProcessor(t, tasks, func(header map[string]string) int {
+		//首次处理一直阻塞,模拟处理中断
+		if header[headerRetryCount] == "" || header[headerRetryCount] == "0" {
+			<-release
+		}
+		return 200
+	})
+	t.Cleanup(func() { close(release) })
+	mqp.Push("order", queue.NewMsg("{}"))
+
+	first := nextDelivery(t, deliveries, time.Second)
+	second := nextDelivery(t, deliveries, 6*time.Second)
+	if second.header[headerIdempotentKey] != "order:"+first.header[headerMsgId] {
+		t.Fatalf("second delivery = %+v, first = %+v", second, first)
+	}
+	noDelivery(t, deliveries, 1500*time.Millisecond)
+}
+
+type testConsumer struct {
+	queue.IMQC
+}
+
+func TestIdempotentRequireKey(t *testing.T) {
+	task := &xmqc.Task{Queue: "order", Idempotent: &xmqc.Idempotent{Store: "cache://local"}}
+	if _, err := newIdempotentGuard(task, testConsumer{}); err == nil {
+		t.Fatal("newIdempotentGuard() without key should fail when message id is not stable")
+	}
+	origCache := getCache
+	getCache = func(name string) cache.ICache { return cachememory.New(nil) }
+	defer func() { getCache = origCache }()
+	task.Idempotent.Key = "x-order-id"
+	if _, err := newIdempotentGuard(task, testConsumer{}); err != nil {
+		t.Fatal(err)
+	}
+}
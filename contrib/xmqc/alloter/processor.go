@@ -3,11 +3,13 @@ package alloter
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
 
 	cmap "github.com/orcaman/concurrent-map"
 	"github.com/zhiyunliu/glue/config"
 	"github.com/zhiyunliu/glue/contrib/alloter"
+	"github.com/zhiyunliu/glue/dlocker"
 	"github.com/zhiyunliu/glue/engine"
 	"github.com/zhiyunliu/glue/log"
 	"github.com/zhiyunliu/glue/queue"
@@ -21,11 +23,14 @@ type processor struct {
 	lock       sync.Mutex
 	closeChan  chan struct{}
 	queues     cmap.ConcurrentMap
+	guards     cmap.ConcurrentMap
 	consumer   queue.IMQC
+	producer   queue.IMQP
 	status     engine.RunStatus
 	engine     *alloter.Engine
 	onceLock   sync.Once
 	configName string
+	leader     *dlocker.LazyElection
 }
 
 // NewProcessor 创建processor
@@ -35,14 +40,21 @@ func newProcessor(ctx context.Context, alloterEngine *alloter.Engine, proto, con
 		status:     engine.Unstarted,
 		closeChan:  make(chan struct{}),
 		queues:     cmap.New(),
+		guards:     cmap.New(),
 		engine:     alloterEngine,
 		configName: configName,
 	}
+	p.leader = dlocker.NewLazyElection(dlocker.LeaderKey("mqc", configName), nil, p.onElected, p.onRevoked)
 
 	p.consumer, err = queue.NewMQC(proto, configName, setting)
 	if err != nil {
 		return nil, fmt.Errorf("构建mqc服务失败:%v", err)
 	}
+	//失败消息的重新投递及死信写入
+	p.producer, err = queue.NewMQP(proto, setting)
+	if err != nil {
+		return nil, fmt.Errorf("构建mqc重试生产者失败:%v", err)
+	}
 	return p, nil
 }
 
@@ -69,6 +81,16 @@ func (s *processor) Add(tasks ...*xmqc.Task) error {
 		if task.Disable {
 			continue
 		}
+		if err := s.checkLeader(task); err != nil {
+			return err
+		}
+		guard, err := newIdempotentGuard(task, s.consumer)
+		if err != nil {
+			return err
+		}
+		if guard != nil {
+			s.guards.Set(task.Queue, guard)
+		}
 		if ok := s.queues.SetIfAbsent(task.Queue, task); ok && s.status == engine.Running {
 			if err := s.consume(task); err != nil {
 				return err
@@ -83,6 +105,7 @@ func (s *processor) Remove(tasks ...*xmqc.Task) error {
 	for _, t := range tasks {
 		s.consumer.Unconsume(t.Queue)
 		s.queues.Remove(t.Queue)
+		s.guards.Remove(t.Queue)
 	}
 	return nil
 }
@@ -121,6 +144,17 @@ func (s *processor) Resume() (bool, error) {
 	return false, nil
 }
 func (s *processor) consume(task *xmqc.Task) error {
+	//leader_only队列在当选后才订阅
+	if task.IsLeaderOnly() && !s.leader.IsLeader() {
+		return nil
+	}
+	if task.IsBatch() {
+		//队列原生支持批量消费时直接使用,否则将批量消费转换为单条消费
+		if batchConsumer, ok := s.consumer.(queue.IBatchMQC); ok {
+			return batchConsumer.ConsumeBatch(task, s.handleBatchCallback(task))
+		}
+		return s.consumer.Consume(queue.NewBatchConsumer(task, s.handleBatchCallback(task)))
+	}
 	return s.consumer.Consume(task, s.handleCallback(task))
 }
 
@@ -129,27 +163,168 @@ func (s *processor) Close() error {
 	s.onceLock.Do(func() {
 		close(s.closeChan)
 		s.Pause()
+		s.leader.Stop()
+		s.producer.Close()
 	})
 	return nil
 }
 
+// checkLeader 存在leader_only队列时参与leader竞选,同一processor只竞选一次
+func (s *processor) checkLeader(task *xmqc.Task) error {
+	if !task.IsLeaderOnly() {
+		return nil
+	}
+	if err := s.leader.Start(s.ctx); err != nil {
+		return fmt.Errorf("mqc队列包含leader_only时需要提供dlocker的配置:%v", err)
+	}
+	return nil
+}
+
+// onElected 当选后订阅leader_only队列
+func (s *processor) onElected(ctx context.Context) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.status != engine.Running {
+		return
+	}
+	for _, v := range s.queues.Items() {
+		task := v.(*xmqc.Task)
+		if !task.IsLeaderOnly() {
+			continue
+		}
+		if err := s.consume(task); err != nil {
+			log.Errorf("mqc.leader.consume:%s,error:%+v", task.Queue, err)
+		}
+	}
+}
+
+// onRevoked 卸任后取消leader_only队列的订阅
+func (s *processor) onRevoked() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, v := range s.queues.Items() {
+		task := v.(*xmqc.Task)
+		if task.IsLeaderOnly() {
+			s.consumer.Unconsume(task.Queue)
+		}
+	}
+}
+
 func (s *processor) handleCallback(task *xmqc.Task) func(queue.IMQCMessage) {
 	return func(m queue.IMQCMessage) {
-
+		retries := retryCount(m)
+		key, ok := s.claim(task, m, retries)
+		if !ok {
+			return
+		}
+		status := http.StatusInternalServerError
 		defer func() {
 			if obj := recover(); obj != nil {
 				log.Panicf("mqc.handleCallback.Queue:%s,data:%s, error:%+v. stack:%s", task.Queue, m.Original(), obj, xstack.GetStack(1))
 			}
+			s.complete(task, m, key, retries, status)
 		}()
 
 		req := newRequest(task, m)
 		req.ctx = context.Background()
-		resp := newResponse(task, m)
+		resp := newResponse()
 
 		err := s.engine.HandleRequest(req, resp)
 		if err != nil {
-			m.Nack(err)
 			panic(err)
 		}
+		status = resp.Status()
+	}
+}
+
+// handleBatchCallback 批量消费,一批消息作为一个请求处理,各消息按批次中的状态分别确认或重试
+func (s *processor) handleBatchCallback(task *xmqc.Task) queue.BatchConsumeCallback {
+	return func(msgs []queue.IMQCMessage) {
+		items := make([]queue.IMQCMessage, 0, len(msgs))
+		keys := make([]string, 0, len(msgs))
+		retries := make([]int64, 0, len(msgs))
+		for _, m := range msgs {
+			retry := retryCount(m)
+			key, ok := s.claim(task, m, retry)
+			if !ok {
+				continue
+			}
+			items = append(items, m)
+			keys = append(keys, key)
+			retries = append(retries, retry)
+		}
+		if len(items) == 0 {
+			return
+		}
+
+		batch := xmqc.NewBatch(items)
+		status := http.StatusInternalServerError
+		defer func() {
+			if obj := recover(); obj != nil {
+				log.Panicf("mqc.handleBatchCallback.Queue:%s,size:%d, error:%+v. stack:%s", task.Queue, len(items), obj, xstack.GetStack(1))
+			}
+			for i, m := range items {
+				s.complete(task, m, keys[i], retries[i], batch.Status(i, status))
+			}
+		}()
+
+		req := newBatchRequest(task, batch)
+		resp := newResponse()
+
+		err := s.engine.HandleRequest(req, resp)
+		if err != nil {
+			panic(err)
+		}
+		status = resp.Status()
+	}
+}
+
+// claim 任务配置了idempotent时占用消息标识,返回false时不再调用handler:
+// 已处理的重复消息直接确认;标识处理中(如处理超时重新投递,原处理可能已中断)或占用出错时按失败处理,由重试策略重新投递
+func (s *processor) claim(task *xmqc.Task, m queue.IMQCMessage, retries int64) (key string, ok bool) {
+	guard, key := s.getGuard(task, m)
+	if key == "" {
+		return "", true
+	}
+	claimed, status, err := guard.claim(context.Background(), key)
+	if err != nil {
+		log.Errorf("mqc.idempotent.claim.queue:%s,key:%s,error:%+v", task.Queue, key, err)
+		s.settle(task, m, key, retries, http.StatusInternalServerError)
+		return "", false
+	}
+	if claimed {
+		return key, true
+	}
+	if status == idempotentDone {
+		log.Infof("mqc.idempotent.skip.queue:%s,key:%s", task.Queue, key)
+		m.Ack()
+		return "", false
+	}
+	log.Warnf("mqc.idempotent.processing.queue:%s,key:%s", task.Queue, key)
+	s.settle(task, m, key, retries, http.StatusInternalServerError)
+	return "", false
+}
+
+// complete 记录去重标识的处理结果并确认消息;
+// 先记录处理结果再确认消息,确认失败后重新投递的消息可以被识别为重复消息
+func (s *processor) complete(task *xmqc.Task, m queue.IMQCMessage, key string, retries int64, status int) {
+	if key != "" {
+		guard, _ := s.getGuard(task, m)
+		if guard != nil {
+			if err := guard.finish(context.Background(), key, status == _sucessStatus); err != nil {
+				log.Errorf("mqc.idempotent.finish.queue:%s,key:%s,error:%+v", task.Queue, key, err)
+			}
+		}
+	}
+	s.settle(task, m, key, retries, status)
+}
+
+// getGuard 任务配置了idempotent时返回去重对象及消息标识,标识为空时不去重
+func (s *processor) getGuard(task *xmqc.Task, m queue.IMQCMessage) (*idempotentGuard, string) {
+	obj, ok := s.guards.Get(task.Queue)
+	if !ok {
+		return nil, ""
 	}
+	guard := obj.(*idempotentGuard)
+	return guard, guard.key(m)
 }
@@ -0,0 +1,228 @@
+package alloter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/glue/contrib/alloter"
+	_ "github.com/zhiyunliu/glue/contrib/queue/memory"
+	_ "github.com/zhiyunliu/glue/encoding/binding"
+	"github.com/zhiyunliu/glue/queue"
+	"github.com/zhiyunliu/glue/xmqc"
+)
+
+type delivery struct {
+	header map[string]string
+	body   string
+}
+
+// newTestProcessor 基于memory队列构建processor,handler按投递次序返回状态码
+func newTestProcessor(t *testing.T, tasks []*xmqc.Task, status func(header map[string]string) int) (queue.IMQP, chan delivery) {
+	deliveries := make(chan delivery, 10)
+	mqp := newTestProcessorWithHandler(t, tasks, func(c *alloter.Context) {
+		header := map[string]string{}
+		for k, v := range c.Request.GetHeader() {
+			header[k] = v
+		}
+		deliveries <- delivery{header: header, body: string(c.Request.Body())}
+		c.AbortWithStatus(status(header))
+	})
+	return mqp, deliveries
+}
+
+func newTestProcessorWithHandler(t *testing.T, tasks []*xmqc.Task, handler alloter.HandlerFunc) queue.IMQP {
+	cfg := config.New(config.WithSource(config.NewStrSource(fmt.Sprintf(
+		`{"queues":{"mq":{"proto":"memory","addr":"memory://%s-%d"}}}`, t.Name(), time.Now().UnixNano()))))
+	if err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	setting := cfg.Get(queue.TypeNode).Get("mq")
+
+	engine := alloter.New()
+	engine.POST("/order", handler)
+
+	p, err := newProcessor(context.Background(), engine, "memory", "mq", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Add(tasks...); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+
+	mqp, err := queue.NewMQP("memory", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mqp
+}
+
+func nextDelivery(t *testing.T, ch chan delivery, timeout time.Duration) delivery {
+	t.Helper()
+	select {
+	case d := <-ch:
+		return d
+	case <-time.After(timeout):
+		t.Fatal("message not delivered before timeout")
+		return delivery{}
+	}
+}
+
+func noDelivery(t *testing.T, ch chan delivery, wait time.Duration) {
+	t.Helper()
+	select {
+	case d := <-ch:
+		t.Fatalf("unexpected delivery:%+v", d)
+	case <-time.After(wait):
+	}
+}
+
+func TestRetryThenDeadLetter(t *testing.T) {
+	tasks := []*xmqc.Task{
+		{Queue: "order", Service: "/order", Retry: &xmqc.RetryPolicy{MaxAttempts: 3}, DeadLetter: "order.dead"},
+		{Queue: "order.dead", Service: "/order"},
+	}
+	mqp, deliveries := newTestProcessor(t, tasks, func(header map[string]string) int {
+		if header[headerDeadLetterQueue] != "" {
+			return 200
+		}
+		return 500
+	})
+	mqp.Push("order", queue.NewMsg(map[string]int{"id": 1}))
+
+	for i := 0; i < 3; i++ {
+		d := nextDelivery(t, deliveries, 2*time.Second)
+		if d.header[headerRetryCount] != fmt.Sprint(i) || d.body != `{"id":1}` {
+			t.Fatalf("delivery %d = %+v", i, d)
+		}
+	}
+	d := nextDelivery(t, deliveries, time.Second)
+	if d.header[headerDeadLetterQueue] != "order" || d.header[headerDeadLetterStatus] != "500" || d.body != `{"id":1}` {
+		t.Fatalf("dead letter = %+v", d)
+	}
+	noDelivery(t, deliveries, 50*time.Millisecond)
+}
+
+func TestNonRetriableCode(t *testing.T) {
+	tasks := []*xmqc.Task{
+		{Queue: "order", Service: "/order", DeadLetter: "order.dead"},
+		{Queue: "order.dead", Service: "/order"},
+	}
+	mqp, deliveries := newTestProcessor(t, tasks, func(header map[string]string) int {
+		if header[headerDeadLetterQueue] != "" {
+			return 200
+		}
+		return 400
+	})
+	mqp.Push("order", queue.NewMsg("{}"))
+
+	nextDelivery(t, deliveries, time.Second)
+	d := nextDelivery(t, deliveries, time.Second)
+	if d.header[headerDeadLetterStatus] != "400" || d.header[headerRetryCount] != "0" {
+		t.Fatalf("4xx should go to dead letter without retry, got %+v", d)
+	}
+}
+
+func TestSuccessAck(t *testing.T) {
+	tasks := []*xmqc.Task{{Queue: "order", Service: "/order"}}
+	mqp, deliveries := newTestProcessor(t, tasks, func(map[string]string) int { return 200 })
+	mqp.Push("order", queue.NewMsg("{}"))
+
+	nextDelivery(t, deliveries, time.Second)
+	noDelivery(t, deliveries, 1500*time.Millisecond)
+}
+
+func TestBatchConsume(t *testing.T) {
+	tasks := []*xmqc.Task{{Queue: "order", Service: "/order", Batch: &xmqc.BatchOptions{MaxSize: 3, MaxWait: 100}}}
+	deliveries := make(chan delivery, 10)
+	mqp := newTestProcessorWithHandler(t, tasks, func(c *alloter.Context) {
+		batch, ok := xmqc.GetBatch(c.Request.Context())
+		if !ok {
+			c.AbortWithStatus(500)
+			return
+		}
+		//第二条消息单独失败,按重试策略重新投递
+		for i, m := range batch.Messages() {
+			if string(m.GetMessage().Body()) == "2" && retryCount(m) == 0 {
+				batch.Nack(i, 500)
+			}
+		}
+		deliveries <- delivery{header: c.Request.GetHeader(), body: string(c.Request.Body())}
+	})
+	for i := 1; i <= 3; i++ {
+		mqp.Push("order", queue.NewMsg(fmt.Sprint(i)))
+	}
+
+	d := nextDelivery(t, deliveries, time.Second)
+	if d.header[headerBatchSize] != "3" || len(d.body) != len("[1,2,3]") {
+		t.Fatalf("batch delivery = %+v", d)
+	}
+	d = nextDelivery(t, deliveries, 3*time.Second)
+	if d.header[headerBatchSize] != "1" || d.body != "[2]" {
+		t.Fatalf("retry delivery = %+v", d)
+	}
+	noDelivery(t, deliveries, 1500*time.Millisecond)
+}
+
+type testMessage struct {
+	acked  bool
+	nacked bool
+}
+
+func (m *testMessage) MessageId() string         { return "1" }
+func (m *testMessage) RetryCount() int64         { return 0 }
+func (m *testMessage) Ack() error                { m.acked = true; return nil }
+func (m *testMessage) Nack(error) error          { m.nacked = true; return nil }
+func (m *testMessage) Original() string          { return `{"header":{},"body":{}}` }
+func (m *testMessage) GetMessage() queue.Message { return queue.NewMsg("{}") }
+
+// testProducer DelayPush总是失败,failPush为true时Push也失败
+type testProducer struct {
+	failPush bool
+	pushed   []string
+}
+
+func (p *testProducer) Push(key string, msg queue.Message) error {
+	if p.failPush {
+		return errors.New("push failed")
+	}
+	p.pushed = append(p.pushed, key)
+	return nil
+}
+func (p *testProducer) DelayPush(string, queue.Message, int64) error {
+	return errors.New("delay push failed")
+}
+func (p *testProducer) Close() error { return nil }
+
+func TestSettleFallback(t *testing.T) {
+	task := &xmqc.Task{Queue: "order", DeadLetter: "order.dead"}
+	producer := &testProducer{}
+	p := &processor{producer: producer}
+
+	//延迟投递失败时立即投递
+	m := &testMessage{}
+	p.settle(task, m, "", 0, 500)
+	if !m.acked || len(producer.pushed) != 1 || producer.pushed[0] != "order" {
+		t.Fatalf("acked = %v, pushed = %v", m.acked, producer.pushed)
+	}
+
+	//无法投递时不确认消息
+	producer.failPush = true
+	m = &testMessage{}
+	p.settle(task, m, "", 0, 500)
+	if m.acked || !m.nacked {
+		t.Fatalf("retry push failed, acked = %v, nacked = %v", m.acked, m.nacked)
+	}
+	m = &testMessage{}
+	p.settle(task, m, "", 0, 400)
+	if m.acked || !m.nacked {
+		t.Fatalf("dead letter push failed, acked = %v, nacked = %v", m.acked, m.nacked)
+	}
+}
@@ -45,13 +45,45 @@ func newRequest(task *xmqc.Task, m queue.IMQCMessage) (r *Request) {
 	r.header = message.Header()
 	r.body = message.Body()
 	r.ctx = sctx.Background()
-	r.header["retry_count"] = strconv.FormatInt(m.RetryCount(), 10)
-	r.header["x-xmqc-msg-id"] = m.MessageId()
+	r.header[headerRetryCount] = strconv.FormatInt(retryCount(m), 10)
+	r.header[headerMsgId] = m.MessageId()
 	r.header[constants.ContentTypeName] = constants.ContentTypeApplicationJSON
 
 	return r
 }
 
+// newBatchRequest 构建批量消费请求,请求体为各消息体组成的JSON数组,上下文中绑定了批次
+func newBatchRequest(task *xmqc.Task, batch *xmqc.Batch) (r *Request) {
+	msgs := batch.Messages()
+	r = &Request{
+		IMQCMessage: msgs[0],
+		task:        task,
+		method:      string(engine.MethodPost),
+		params:      make(map[string]string),
+	}
+
+	bodies := make([][]byte, len(msgs))
+	for i, m := range msgs {
+		bodies[i] = m.GetMessage().Body()
+		if len(bodies[i]) == 0 {
+			bodies[i] = []byte("null")
+		}
+	}
+	body := make([]byte, 0, 2)
+	body = append(body, '[')
+	body = append(body, bytes.Join(bodies, []byte(","))...)
+	r.body = append(body, ']')
+	r.header = map[string]string{
+		headerBatchSize:           strconv.Itoa(len(msgs)),
+		constants.ContentTypeName: constants.ContentTypeApplicationJSON,
+	}
+	if sid := msgs[0].GetMessage().Header()[constants.HeaderRequestId]; sid != "" {
+		r.header[constants.HeaderRequestId] = sid
+	}
+	r.ctx = xmqc.WithBatch(sctx.Background(), batch)
+	return r
+}
+
 func (m Request) GetSid() string {
 	if m.header[constants.HeaderRequestId] == "" {
 		m.header[constants.HeaderRequestId] = session.Create()
@@ -1,12 +1,9 @@
 package alloter
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/zhiyunliu/glue/contrib/alloter"
-	"github.com/zhiyunliu/glue/queue"
-	"github.com/zhiyunliu/glue/xmqc"
 	"github.com/zhiyunliu/golibs/xtypes"
 )
 
@@ -22,17 +19,15 @@ type Response struct {
 	status int
 	size   int
 	header xtypes.SMap
-	msg    queue.IMQCMessage
 	//stream *bufio.Writer
 }
 
 // newResponse 构建任务请求
-func newResponse(task *xmqc.Task, msg queue.IMQCMessage) (r *Response) {
+func newResponse() (r *Response) {
 	r = &Response{
 		header: make(xtypes.SMap),
 		size:   noWritten,
 		status: _sucessStatus,
-		msg:    msg,
 		//stream: bufio.NewWriter(os.Stdout),
 	}
 	return r
@@ -71,9 +66,7 @@ func (r *Response) WriteString(s string) (n int, err error) {
 	return
 }
 
+// Flush 消息的确认由processor根据Status及任务的重试策略统一处理
 func (r *Response) Flush() error {
-	if r.status == _sucessStatus {
-		return r.msg.Ack()
-	}
-	return r.msg.Nack(fmt.Errorf("Status:%d", r.status))
+	return nil
 }
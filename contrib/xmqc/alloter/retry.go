@@ -0,0 +1,90 @@
+package alloter
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/zhiyunliu/glue/log"
+	"github.com/zhiyunliu/glue/queue"
+	"github.com/zhiyunliu/glue/xmqc"
+	"github.com/zhiyunliu/golibs/xtypes"
+)
+
+const (
+	headerRetryCount       = "retry_count"
+	headerMsgId            = "x-xmqc-msg-id"
+	headerDeadLetterQueue  = "x-xmqc-deadletter-queue"
+	headerDeadLetterStatus = "x-xmqc-deadletter-status"
+	headerBatchSize        = "x-xmqc-batch-size"
+)
+
+// retryCount 已重试次数,优先使用重新投递时写入的消息头,兼容各队列自身的计数
+func retryCount(m queue.IMQCMessage) int64 {
+	cnt := m.RetryCount()
+	if val, err := strconv.ParseInt(m.GetMessage().Header()[headerRetryCount], 10, 64); err == nil && val > cnt {
+		cnt = val
+	}
+	return cnt
+}
+
+// settle 根据处理状态确认消息:
+// 成功时Ack;失败且可重试时按重试策略延迟重新投递,重新投递的消息保留去重标识key;否则写入死信队列;
+// 延迟投递失败时改为立即投递,仍失败或写入死信队列失败时不确认消息,记录消息内容并Nack
+func (s *processor) settle(task *xmqc.Task, m queue.IMQCMessage, key string, retries int64, status int) {
+	if status == _sucessStatus {
+		m.Ack()
+		return
+	}
+	failErr := fmt.Errorf("Status:%d", status)
+	attempt := int(retries) + 1
+	policy := task.Retry
+	if policy.Retriable(status) && attempt < policy.GetMaxAttempts() {
+		delay := policy.NextDelay(attempt)
+		msg := copyMessage(m, headerRetryCount, strconv.Itoa(attempt))
+		if key != "" {
+			msg.HeaderMap[headerIdempotentKey] = key
+		}
+		if err := s.producer.DelayPush(task.Queue, msg, delay); err != nil {
+			log.Errorf("mqc.retry.queue:%s,attempt:%d,error:%+v", task.Queue, attempt, err)
+			if err = s.producer.Push(task.Queue, msg); err != nil {
+				s.keep(task.Queue, m, failErr, err)
+				return
+			}
+		}
+		m.Ack()
+		return
+	}
+	if task.DeadLetter != "" && task.DeadLetter != task.Queue {
+		msg := copyMessage(m, headerDeadLetterQueue, task.Queue)
+		msg.HeaderMap[headerDeadLetterStatus] = strconv.Itoa(status)
+		if err := s.producer.Push(task.DeadLetter, msg); err != nil {
+			log.Errorf("mqc.deadletter.queue:%s,deadletter:%s,error:%+v", task.Queue, task.DeadLetter, err)
+			s.keep(task.Queue, m, failErr, err)
+			return
+		}
+	} else {
+		log.Warnf("mqc.discard.queue:%s,attempt:%d,status:%d,msg:%s", task.Queue, attempt, status, m.Original())
+	}
+	m.Ack()
+}
+
+// keep 消息无法重新投递时不确认,记录消息内容;队列不支持Nack重新投递(如redis)时可根据日志恢复
+func (s *processor) keep(queueName string, m queue.IMQCMessage, failErr, err error) {
+	log.Errorf("mqc.unsettled.queue:%s,msg:%s,error:%+v", queueName, m.Original(), err)
+	m.Nack(failErr)
+}
+
+// copyMessage 复制消息并设置消息头,去掉本次投递相关的消息头
+func copyMessage(m queue.IMQCMessage, key, val string) *queue.MsgItem {
+	msg := m.GetMessage()
+	header := make(xtypes.SMap, len(msg.Header())+1)
+	for k, v := range msg.Header() {
+		header[k] = v
+	}
+	delete(header, headerMsgId)
+	header[key] = val
+	return &queue.MsgItem{
+		HeaderMap: header,
+		BodyBytes: msg.Body(),
+	}
+}
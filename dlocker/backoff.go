@@ -0,0 +1,41 @@
+package dlocker
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// WaitAcquire 按退避策略重复调用acquire,直到获取成功、返回错误或ctx结束
+func WaitAcquire(ctx context.Context, opts *Options, acquire func() (bool, error)) error {
+	delay := opts.MinBackoff
+	if delay <= 0 {
+		delay = DefaultMinBackoff
+	}
+	maxDelay := opts.MaxBackoff
+	if maxDelay < delay {
+		maxDelay = delay
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+	for {
+		ok, err := acquire()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		//随机抖动,避免等待者同时重试
+		timer.Reset(delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
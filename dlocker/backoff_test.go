@@ -0,0 +1,38 @@
+package dlocker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitAcquire(t *testing.T) {
+	opts := NewOptions(WithBackoff(time.Millisecond, 4*time.Millisecond))
+
+	calls := 0
+	err := WaitAcquire(context.Background(), opts, func() (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("WaitAcquire = %v, calls = %d, want nil, 3", err, calls)
+	}
+
+	errFail := errors.New("fail")
+	err = WaitAcquire(context.Background(), opts, func() (bool, error) {
+		return false, errFail
+	})
+	if err != errFail {
+		t.Fatalf("WaitAcquire err = %v, want %v", err, errFail)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = WaitAcquire(ctx, opts, func() (bool, error) {
+		return false, nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("WaitAcquire err = %v, want deadline exceeded", err)
+	}
+}
@@ -1,21 +1,44 @@
 package dlocker
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/zhiyunliu/glue/config"
 )
 
+// ErrNotHeld 续期时锁已不再由当前持有者持有(已过期或被释放)
+var ErrNotHeld = errors.New("dlocker: 锁未被当前持有者持有")
+
 type DLocker interface {
 	//expire 秒
 	Acquire(expire int) (bool, error)
+	//AcquireCtx 按退避策略重试直到获取锁或ctx结束,返回fencing token
+	AcquireCtx(ctx context.Context, expire int) (int64, error)
 	Release() (bool, error)
-	//expire 秒
+	//expire 秒,锁已不再由当前持有者持有时返回ErrNotHeld
 	Renewal(expire int) error
+	//Token 最近一次获取锁时的fencing token,同一key的token单调递增,
+	//下游存储可据此拒绝已过期持有者的写入
+	Token() int64
+}
+
+// HolderReader 可查询当前写锁持有者的锁,持有者即WithData设置的数据,未被持有时返回空字符串
+type HolderReader interface {
+	Holder() (string, error)
+}
+
+// RWLocker 读写锁,读锁之间共享,写锁与读锁、写锁互斥
+type RWLocker interface {
+	RLocker() DLocker
+	WLocker() DLocker
 }
 
 type DLockerBuilder interface {
 	Build(key string, opts ...Option) DLocker
+	//BuildRW 构建读写锁,写锁与Build构建的同名锁互斥
+	BuildRW(key string, opts ...Option) RWLocker
 }
 
 // cacheResover 定义配置文件转换方法
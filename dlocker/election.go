@@ -0,0 +1,246 @@
+package dlocker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zhiyunliu/glue/global"
+	"github.com/zhiyunliu/glue/log"
+)
+
+// DefaultElectionTTL leader锁默认过期时间(秒)
+const DefaultElectionTTL = 15
+
+// ErrHolderUnsupported 锁实现不支持查询持有者
+var ErrHolderUnsupported = errors.New("dlocker: 锁不支持查询持有者")
+
+// errRenewalTimeout 续期未在卸任期限前返回
+var errRenewalTimeout = errors.New("dlocker: 续期超时")
+
+type electionOptions struct {
+	ttl      int
+	identity string
+	lockOpts []Option
+}
+
+type ElectionOption func(opts *electionOptions)
+
+// WithTTL leader锁过期时间(秒),leader每隔TTL的1/3续期一次,超过TTL的2/3未能续期时卸任,失联超过TTL后由其他节点接任
+func WithTTL(ttl int) ElectionOption {
+	return func(opts *electionOptions) {
+		opts.ttl = ttl
+	}
+}
+
+// WithIdentity 当前节点标识,作为锁的Data保存,默认为 ip:pid
+func WithIdentity(identity string) ElectionOption {
+	return func(opts *electionOptions) {
+		opts.identity = identity
+	}
+}
+
+// WithLockOptions 构建leader锁时附加的锁配置,如WithBackoff
+func WithLockOptions(opts ...Option) ElectionOption {
+	return func(o *electionOptions) {
+		o.lockOpts = append(o.lockOpts, opts...)
+	}
+}
+
+// Election 基于分布式锁的leader选举,
+// 获取锁即当选,之后按TTL的1/3周期续期;锁已被他人持有或超过TTL的2/3未能续期(每次续期不超过该期限)时卸任并重新竞选,
+// 保证锁过期、其他节点当选前当前节点已卸任
+type Election struct {
+	key      string
+	ttl      int
+	identity string
+	locker   DLocker
+	leader   atomic.Bool
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Campaign 在后台参与key的leader竞选,直到ctx结束或调用Stop;
+// onElected 当选时在独立的goroutine中调用,其ctx在卸任时取消,ctx取消后需尽快返回,否则可能与新的leader同时执行;
+// onRevoked 卸任时调用,调用前会等待onElected返回;两个回调均可为nil;
+// 卸任时IsLeader立即返回false,不等待onElected返回
+func Campaign(ctx context.Context, builder DLockerBuilder, key string, onElected func(ctx context.Context), onRevoked func(), opts ...ElectionOption) *Election {
+	opt := &electionOptions{
+		ttl:      DefaultElectionTTL,
+		identity: fmt.Sprintf("%s:%d", global.LocalIp, os.Getpid()),
+	}
+	for i := range opts {
+		opts[i](opt)
+	}
+	if opt.ttl < 3 {
+		opt.ttl = 3
+	}
+	//未当选时最长每个续期周期重试一次
+	lockOpts := append([]Option{
+		WithData(opt.identity),
+		WithBackoff(DefaultMinBackoff, time.Duration(opt.ttl)*time.Second/3),
+	}, opt.lockOpts...)
+
+	e := &Election{
+		key:      key,
+		ttl:      opt.ttl,
+		identity: opt.identity,
+		locker:   builder.Build(key, lockOpts...),
+		done:     make(chan struct{}),
+	}
+	ctx, e.cancel = context.WithCancel(ctx)
+	go e.run(ctx, onElected, onRevoked)
+	return e
+}
+
+// Key 竞选的key
+func (e *Election) Key() string {
+	return e.key
+}
+
+// Identity 当前节点标识
+func (e *Election) Identity() string {
+	return e.identity
+}
+
+// IsLeader 当前节点是否为leader
+func (e *Election) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Leader 当前leader的标识,没有leader时返回空字符串
+func (e *Election) Leader() (string, error) {
+	if e.IsLeader() {
+		return e.identity, nil
+	}
+	reader, ok := e.locker.(HolderReader)
+	if !ok {
+		return "", ErrHolderUnsupported
+	}
+	return reader.Holder()
+}
+
+// Stop 退出竞选,当前为leader时卸任并释放锁,等待后台流程结束后返回
+func (e *Election) Stop() {
+	e.stopOnce.Do(e.cancel)
+	<-e.done
+}
+
+// Done 竞选结束时关闭
+func (e *Election) Done() <-chan struct{} {
+	return e.done
+}
+
+func (e *Election) run(ctx context.Context, onElected func(ctx context.Context), onRevoked func()) {
+	defer close(e.done)
+	retry := e.interval()
+	for {
+		if _, err := e.locker.AcquireCtx(ctx, e.ttl); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warnf("dlocker.election:%s,acquire error:%+v", e.key, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retry):
+			}
+			continue
+		}
+		e.lead(ctx, onElected, onRevoked)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// lead 当选后续期直到失去锁或ctx结束
+func (e *Election) lead(ctx context.Context, onElected func(ctx context.Context), onRevoked func()) {
+	e.leader.Store(true)
+	leadCtx, cancel := context.WithCancel(ctx)
+	elected := make(chan struct{})
+	go func() {
+		defer close(elected)
+		if onElected != nil {
+			onElected(leadCtx)
+		}
+	}()
+
+	lost := e.keep(ctx)
+
+	e.leader.Store(false)
+	cancel()
+	<-elected
+	if onRevoked != nil {
+		onRevoked()
+	}
+	//续期超时卸任时锁可能仍由当前节点持有,释放后其他节点可立即接任
+	if !lost {
+		if _, err := e.locker.Release(); err != nil {
+			log.Warnf("dlocker.election:%s,release error:%+v", e.key, err)
+		}
+	}
+}
+
+// keep 周期续期,ctx结束、锁已被他人持有(lost为true)或超过TTL的2/3未能续期时返回
+func (e *Election) keep(ctx context.Context) (lost bool) {
+	ticker := time.NewTicker(e.interval())
+	defer ticker.Stop()
+	//以发起续期的时间计算卸任期限,保证在锁过期前卸任
+	deadline := time.Now().Add(e.stepDown())
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+		start := time.Now()
+		err := e.renewal(deadline.Sub(start))
+		if err == nil {
+			deadline = start.Add(e.stepDown())
+			continue
+		}
+		if errors.Is(err, ErrNotHeld) {
+			log.Warnf("dlocker.election:%s,leadership lost", e.key)
+			return true
+		}
+		log.Warnf("dlocker.election:%s,renewal error:%+v", e.key, err)
+		if !time.Now().Before(deadline) {
+			log.Warnf("dlocker.election:%s,step down", e.key)
+			return false
+		}
+	}
+}
+
+// renewal 续期,超过timeout未返回时视为失败
+func (e *Election) renewal(timeout time.Duration) error {
+	if timeout <= 0 {
+		return errRenewalTimeout
+	}
+	result := make(chan error, 1)
+	go func() {
+		result <- e.locker.Renewal(e.ttl)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return errRenewalTimeout
+	}
+}
+
+func (e *Election) interval() time.Duration {
+	return time.Duration(e.ttl) * time.Second / 3
+}
+
+// stepDown 最后一次续期成功后未能续期时的卸任期限
+func (e *Election) stepDown() time.Duration {
+	return time.Duration(e.ttl) * time.Second * 2 / 3
+}
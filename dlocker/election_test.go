@@ -0,0 +1,174 @@
+package dlocker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zhiyunliu/glue/contrib/dlocker/memory"
+	"github.com/zhiyunliu/glue/dlocker"
+)
+
+// waitFor 轮询直到cond成立或超时
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCampaignFailover(t *testing.T) {
+	m := memory.New()
+	opts := []dlocker.ElectionOption{
+		dlocker.WithTTL(3),
+		dlocker.WithLockOptions(dlocker.WithBackoff(time.Millisecond, 10*time.Millisecond)),
+	}
+	elected := make(chan string, 2)
+	revoked := make(chan string, 2)
+	campaign := func(id string) *dlocker.Election {
+		return dlocker.Campaign(context.Background(), m, "leader", func(ctx context.Context) {
+			elected <- id
+			<-ctx.Done()
+		}, func() {
+			revoked <- id
+		}, append(opts, dlocker.WithIdentity(id))...)
+	}
+
+	a := campaign("a")
+	if id := <-elected; id != "a" {
+		t.Fatalf("elected = %s, want a", id)
+	}
+	b := campaign("b")
+	defer b.Stop()
+	time.Sleep(50 * time.Millisecond)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("a.IsLeader = %v, b.IsLeader = %v", a.IsLeader(), b.IsLeader())
+	}
+	if leader, err := b.Leader(); err != nil || leader != "a" {
+		t.Fatalf("b.Leader = %q, %v, want a", leader, err)
+	}
+
+	a.Stop()
+	if id := <-revoked; id != "a" {
+		t.Fatalf("revoked = %s, want a", id)
+	}
+	select {
+	case id := <-elected:
+		if id != "b" {
+			t.Fatalf("elected = %s, want b", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("b should be elected after a stops")
+	}
+	if leader, _ := b.Leader(); leader != "b" {
+		t.Fatalf("b.Leader = %q, want b", leader)
+	}
+}
+
+func TestCampaignLost(t *testing.T) {
+	m := memory.New()
+	revoked := make(chan struct{}, 1)
+	e := dlocker.Campaign(context.Background(), m, "leader", nil, func() {
+		revoked <- struct{}{}
+	}, dlocker.WithTTL(3), dlocker.WithIdentity("a"))
+	defer e.Stop()
+	waitFor(t, time.Second, e.IsLeader)
+
+	//以相同持有者释放锁,模拟锁过期或被清理
+	m.Build("leader", dlocker.WithData("a")).Release()
+	select {
+	case <-revoked:
+	case <-time.After(3 * time.Second):
+		t.Fatal("leader should be revoked after the lock is lost")
+	}
+	//卸任后重新竞选
+	waitFor(t, 2*time.Second, e.IsLeader)
+}
+
+func TestCampaignCtxDone(t *testing.T) {
+	m := memory.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	e := dlocker.Campaign(ctx, m, "leader", nil, nil, dlocker.WithTTL(3))
+	waitFor(t, time.Second, e.IsLeader)
+	cancel()
+	<-e.Done()
+	if e.IsLeader() {
+		t.Fatal("election should step down when ctx is done")
+	}
+	if ok, _ := m.Build("leader").Acquire(3); !ok {
+		t.Fatal("lock should be released when ctx is done")
+	}
+}
+
+// blockingBuilder 构建的锁续期时阻塞,模拟锁服务无响应
+type blockingBuilder struct {
+	dlocker.DLockerBuilder
+	block chan struct{}
+}
+
+func (b *blockingBuilder) Build(key string, opts ...dlocker.Option) dlocker.DLocker {
+	return &blockingLocker{DLocker: b.DLockerBuilder.Build(key, opts...), block: b.block}
+}
+
+type blockingLocker struct {
+	dlocker.DLocker
+	block chan struct{}
+}
+
+func (l *blockingLocker) Renewal(expire int) error {
+	<-l.block
+	return l.DLocker.Renewal(expire)
+}
+
+func TestCampaignStepDownBeforeExpire(t *testing.T) {
+	builder := &blockingBuilder{DLockerBuilder: memory.New(), block: make(chan struct{})}
+	defer close(builder.block)
+	e := dlocker.Campaign(context.Background(), builder, "leader", func(ctx context.Context) {
+		<-ctx.Done()
+		//onElected未及时返回时IsLeader已为false
+		time.Sleep(time.Second)
+	}, nil, dlocker.WithTTL(3))
+	defer e.Stop()
+	waitFor(t, time.Second, e.IsLeader)
+	start := time.Now()
+	waitFor(t, 3*time.Second, func() bool { return !e.IsLeader() })
+	if elapsed := time.Since(start); elapsed >= 2500*time.Millisecond {
+		t.Fatalf("step down after %v, want before the lock expires", elapsed)
+	}
+}
+
+func TestLazyElection(t *testing.T) {
+	m := memory.New()
+	elected := make(chan struct{}, 1)
+	l := dlocker.NewLazyElection(dlocker.LeaderKey("cron"), func() dlocker.DLockerBuilder { return m }, func(ctx context.Context) {
+		elected <- struct{}{}
+	}, nil)
+	if l.IsLeader() {
+		t.Fatal("IsLeader before Start")
+	}
+	for i := 0; i < 2; i++ {
+		if err := l.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-elected
+	waitFor(t, time.Second, l.IsLeader)
+	l.Stop()
+	if l.IsLeader() {
+		t.Fatal("IsLeader after Stop")
+	}
+	select {
+	case <-elected:
+		t.Fatal("Start should campaign only once")
+	default:
+	}
+
+	failed := dlocker.NewLazyElection("key", func() dlocker.DLockerBuilder { panic("dlocker未配置") }, nil, nil)
+	if err := failed.Start(context.Background()); err == nil {
+		t.Fatal("Start() should return error when builder is unavailable")
+	}
+}
@@ -0,0 +1,83 @@
+package dlocker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/zhiyunliu/glue/global"
+	"github.com/zhiyunliu/glue/standard"
+)
+
+// LeaderKey 服务的leader竞选key,格式为 glue:{server}:leader:{appname}[:{names}]
+func LeaderKey(server string, names ...string) string {
+	return strings.Join(append([]string{"glue", server, "leader", global.AppName}, names...), ":")
+}
+
+// LazyElection 按需参与的leader选举,用于只有部分任务需要leader的服务(如leader_only的cron任务、mqc队列):
+// 首次调用Start时开始竞选,重复调用不会重复竞选,Stop后可再次Start
+type LazyElection struct {
+	key        string
+	getBuilder func() DLockerBuilder
+	onElected  func(ctx context.Context)
+	onRevoked  func()
+	opts       []ElectionOption
+	lock       sync.Mutex
+	election   *Election
+}
+
+// NewLazyElection 构建按需竞选的选举对象,getBuilder为nil时使用dlocker组件(dlocker配置);回调说明见Campaign
+func NewLazyElection(key string, getBuilder func() DLockerBuilder, onElected func(ctx context.Context), onRevoked func(), opts ...ElectionOption) *LazyElection {
+	if getBuilder == nil {
+		getBuilder = func() DLockerBuilder {
+			return standard.GetInstance(TypeNode).(StandardLocker).GetDLocker()
+		}
+	}
+	return &LazyElection{
+		key:        key,
+		getBuilder: getBuilder,
+		onElected:  onElected,
+		onRevoked:  onRevoked,
+		opts:       opts,
+	}
+}
+
+// Key 竞选的key
+func (l *LazyElection) Key() string {
+	return l.key
+}
+
+// Start 未参与竞选时开始竞选,获取锁组件失败(如未配置dlocker)时返回错误
+func (l *LazyElection) Start(ctx context.Context) (err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.election != nil {
+		return nil
+	}
+	defer func() {
+		if obj := recover(); obj != nil {
+			err = fmt.Errorf("%v", obj)
+		}
+	}()
+	l.election = Campaign(ctx, l.getBuilder(), l.key, l.onElected, l.onRevoked, l.opts...)
+	return nil
+}
+
+// IsLeader 已参与竞选且当前节点为leader
+func (l *LazyElection) IsLeader() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.election != nil && l.election.IsLeader()
+}
+
+// Stop 退出竞选,未参与竞选时直接返回
+func (l *LazyElection) Stop() {
+	l.lock.Lock()
+	election := l.election
+	l.election = nil
+	l.lock.Unlock()
+	if election != nil {
+		election.Stop()
+	}
+}
@@ -1,22 +1,71 @@
 package dlocker
 
+import "time"
+
+const (
+	//DefaultMinBackoff AcquireCtx 首次重试间隔
+	DefaultMinBackoff = 50 * time.Millisecond
+	//DefaultMaxBackoff AcquireCtx 最大重试间隔
+	DefaultMaxBackoff = time.Second
+)
+
 type Options struct {
-	Data string
-	AutoRenewal bool 
+	Data        string
+	AutoRenewal bool
+	//Reentrant 同一持有者(Data)可重复获取,释放次数与获取次数一致时才真正释放
+	Reentrant bool
+	//Fair 按等待顺序获取锁,仅对写锁生效
+	Fair       bool
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
 }
 
 type Option func(opts *Options)
 
-//设置数据
+// NewOptions 构建锁配置
+func NewOptions(opts ...Option) *Options {
+	opt := &Options{
+		MinBackoff: DefaultMinBackoff,
+		MaxBackoff: DefaultMaxBackoff,
+	}
+	for i := range opts {
+		opts[i](opt)
+	}
+	return opt
+}
+
+// 设置数据,同时作为锁持有者标识
 func WithData(data string) Option {
 	return func(opts *Options) {
 		opts.Data = data
 	}
 }
 
-//自动续期
+// 自动续期
 func WithAutoRenewal() Option {
 	return func(opts *Options) {
 		opts.AutoRenewal = true
 	}
 }
+
+// 可重入,需配合WithData指定持有者
+func WithReentrant() Option {
+	return func(opts *Options) {
+		opts.Reentrant = true
+	}
+}
+
+// 公平锁,等待者按先后顺序获取
+func WithFair() Option {
+	return func(opts *Options) {
+		opts.Fair = true
+	}
+}
+
+// AcquireCtx 重试间隔,从min开始逐次翻倍至max
+func WithBackoff(min, max time.Duration) Option {
+	return func(opts *Options) {
+		opts.MinBackoff = min
+		opts.MaxBackoff = max
+	}
+}
@@ -0,0 +1,50 @@
+package dlocker
+
+import (
+	"sync"
+	"time"
+)
+
+// Renewer 锁自动续期,获取锁后按过期时间周期续期,释放锁后停止
+type Renewer struct {
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// NewRenewer 构建自动续期
+func NewRenewer() *Renewer {
+	return &Renewer{}
+}
+
+// Start 开始续期,已在续期中时忽略
+func (r *Renewer) Start(expire int, renewal func(expire int) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	r.stop = stop
+	go func() {
+		ticker := time.NewTicker(time.Second * time.Duration(expire))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				renewal(expire)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止续期
+func (r *Renewer) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+}
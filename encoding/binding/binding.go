@@ -72,4 +72,6 @@ func init() {
 	encoding.RegisterCodec(yamlBinding{})
 	encoding.RegisterCodec(xyamlBinding{})
 	encoding.RegisterCodec(tomlBinding{})
+	encoding.RegisterCodec(msgpackBinding{})
+	encoding.RegisterCodec(xmsgpackBinding{})
 }
@@ -0,0 +1,33 @@
+//go:build !nomsgpack
+
+package binding
+
+import (
+	"github.com/ugorji/go/codec"
+)
+
+var msgpackHandle = &codec.MsgpackHandle{}
+
+type msgpackBinding struct{}
+
+type xmsgpackBinding struct {
+	msgpackBinding
+}
+
+func (xmsgpackBinding) Name() string {
+	return "x-msgpack"
+}
+
+func (msgpackBinding) Name() string {
+	return "msgpack"
+}
+
+func (msgpackBinding) Marshal(v interface{}) ([]byte, error) {
+	var body []byte
+	err := codec.NewEncoderBytes(&body, msgpackHandle).Encode(v)
+	return body, err
+}
+
+func (msgpackBinding) Unmarshal(body []byte, obj interface{}) error {
+	return codec.NewDecoderBytes(body, msgpackHandle).Decode(obj)
+}
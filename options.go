@@ -1,6 +1,11 @@
 package glue
 
-import "github.com/zhiyunliu/glue/cli"
+import (
+	"context"
+
+	"github.com/zhiyunliu/glue/cli"
+	"github.com/zhiyunliu/glue/dlocker"
+)
 
 // Option is an application option.
 type Option = cli.Option
@@ -36,5 +41,24 @@ var (
 	LogParams           = cli.LogParams
 	StartingHook        = cli.StartingHook
 	StartedHook         = cli.StartedHook
+	StoppingHook        = cli.StoppingHook
 	Command             = cli.Command
+	Migrations          = cli.Migrations
 )
+
+// Leader 应用启动后参与key的leader竞选,应用停止时卸任并释放锁,回调说明见 dlocker.Campaign
+func Leader(key string, onElected func(ctx context.Context), onRevoked func(), opts ...dlocker.ElectionOption) Option {
+	return func(o *cli.Options) {
+		var election *dlocker.Election
+		o.StartedHooks = append(o.StartedHooks, func(ctx context.Context) error {
+			election = Campaign(ctx, key, onElected, onRevoked, opts...)
+			return nil
+		})
+		o.StoppingHooks = append(o.StoppingHooks, func(ctx context.Context) error {
+			if election != nil {
+				election.Stop()
+			}
+			return nil
+		})
+	}
+}
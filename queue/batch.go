@@ -0,0 +1,116 @@
+package queue
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zhiyunliu/glue/log"
+)
+
+// DefaultBatchWait 批量消费默认的最长等待时间
+var DefaultBatchWait = time.Second
+
+// BatchTaskInfo 批量消费的任务信息
+type BatchTaskInfo interface {
+	TaskInfo
+	//GetBatchSize 每批最大消息数
+	GetBatchSize() int
+	//GetBatchWait 凑批的最长等待时间,从批次的第一条消息开始计算
+	GetBatchWait() time.Duration
+}
+
+// BatchConsumeCallback 批量消费回调,回调返回后各消息按自身的Ack/Nack结果确认
+type BatchConsumeCallback func([]IMQCMessage)
+
+// IBatchMQC 原生支持批量消费的consumer;未实现时mqc通过NewBatchConsumer转换为单条消费
+type IBatchMQC interface {
+	ConsumeBatch(task BatchTaskInfo, callback BatchConsumeCallback) (err error)
+}
+
+// NewBatchConsumer 将批量消费转换为单条消费:
+// 单条消息的回调加入当前批次并阻塞到批次处理完成,使consumer仍按单条消息的处理结果确认;
+// 批次凑满或等待超时后调用callback,并发数小于批次大小时按批次大小处理
+func NewBatchConsumer(task BatchTaskInfo, callback BatchConsumeCallback) (TaskInfo, ConsumeCallback) {
+	b := &batcher{
+		queue:    task.GetQueue(),
+		size:     task.GetBatchSize(),
+		wait:     task.GetBatchWait(),
+		callback: callback,
+	}
+	if b.size <= 0 {
+		b.size = 1
+	}
+	if b.wait <= 0 {
+		b.wait = DefaultBatchWait
+	}
+	return batchTask{BatchTaskInfo: task}, b.add
+}
+
+type batchTask struct {
+	BatchTaskInfo
+}
+
+// GetConcurrency 每条消息在批次处理完成前占用一个并发,并发数不能小于批次大小
+func (t batchTask) GetConcurrency() int {
+	if concurrency := t.BatchTaskInfo.GetConcurrency(); concurrency > t.GetBatchSize() {
+		return concurrency
+	}
+	return t.GetBatchSize()
+}
+
+type batch struct {
+	msgs  []IMQCMessage
+	timer *time.Timer
+	done  chan struct{}
+}
+
+type batcher struct {
+	queue    string
+	size     int
+	wait     time.Duration
+	callback BatchConsumeCallback
+	lock     sync.Mutex
+	current  *batch
+}
+
+func (b *batcher) add(m IMQCMessage) {
+	b.lock.Lock()
+	cur := b.current
+	if cur == nil {
+		cur = &batch{done: make(chan struct{})}
+		cur.timer = time.AfterFunc(b.wait, func() { b.flush(cur) })
+		b.current = cur
+	}
+	cur.msgs = append(cur.msgs, m)
+	full := len(cur.msgs) >= b.size
+	b.lock.Unlock()
+
+	if full {
+		b.flush(cur)
+	}
+	<-cur.done
+}
+
+// flush 处理批次,凑满与等待超时同时发生时只处理一次
+func (b *batcher) flush(cur *batch) {
+	b.lock.Lock()
+	if b.current != cur {
+		b.lock.Unlock()
+		return
+	}
+	b.current = nil
+	cur.timer.Stop()
+	b.lock.Unlock()
+
+	defer func() {
+		if obj := recover(); obj != nil {
+			log.Errorf("queue.batch:%s,size:%d,panic:%+v", b.queue, len(cur.msgs), obj)
+			for _, m := range cur.msgs {
+				m.Nack(fmt.Errorf("%+v", obj))
+			}
+		}
+		close(cur.done)
+	}()
+	b.callback(cur.msgs)
+}
@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"sync"
+
+	"github.com/zhiyunliu/glue/metrics"
+	"github.com/zhiyunliu/glue/standard"
+)
+
+var getMetricProvider = func(proto string) metrics.Provider {
+	return standard.GetInstance(metrics.TypeNode).(metrics.StandardMetric).GetProvider(proto)
+}
+
+// outboxMetrics 设置了metrics时记录:
+// 计数器标签依次为: outbox.<write|publish|cleanup>,表名,ok/error,队列名称;
+// 投递延迟(秒,写入到投递成功)标签依次为: outbox.publish,表名;
+// 待投递消息数(Gauge)标签依次为: outbox.pending,表名
+type outboxMetrics struct {
+	table    string
+	proto    string
+	once     sync.Once
+	provider metrics.Provider
+}
+
+// getProvider 首次使用时获取指标提供程序,未设置metrics时返回nil
+func (m *outboxMetrics) getProvider() metrics.Provider {
+	if m.proto == "" {
+		return nil
+	}
+	m.once.Do(func() {
+		m.provider = getMetricProvider(m.proto)
+	})
+	return m.provider
+}
+
+func (m *outboxMetrics) count(kind string, err error, key string) {
+	provider := m.getProvider()
+	if provider == nil || provider.Counter() == nil {
+		return
+	}
+	code := "ok"
+	if err != nil {
+		code = "error"
+	}
+	provider.Counter().With(kind, m.table, code, key).Inc()
+}
+
+func (m *outboxMetrics) observe(kind string, seconds float64) {
+	provider := m.getProvider()
+	if provider == nil || provider.Observer() == nil {
+		return
+	}
+	provider.Observer().With(kind, m.table).Observe(seconds)
+}
+
+func (m *outboxMetrics) gauge(kind string, value float64) {
+	provider := m.getProvider()
+	if provider == nil || provider.Gauge() == nil {
+		return
+	}
+	provider.Gauge().With(kind, m.table).Set(value)
+}
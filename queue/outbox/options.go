@@ -0,0 +1,113 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/zhiyunliu/glue/dlocker"
+)
+
+const (
+	//DefaultTable 默认的outbox表
+	DefaultTable = "queue_outbox"
+	//默认每批投递的消息数
+	defaultBatchSize = 100
+	//默认轮询间隔
+	defaultInterval = time.Second
+	//默认已投递消息保留时间
+	defaultRetention = 24 * time.Hour
+	//默认清理间隔
+	defaultCleanupInterval = time.Minute
+	//默认投递失败后的首次重试间隔
+	defaultRetryDelay = time.Second
+	//默认投递失败后的最大重试间隔
+	defaultRetryMaxDelay = time.Minute
+)
+
+type Options struct {
+	//Table outbox表名
+	Table string
+	//BatchSize 每批投递的消息数
+	BatchSize int
+	//Interval 没有待投递消息时的轮询间隔
+	Interval time.Duration
+	//Retention 已投递消息的保留时间,超过后由清理任务删除
+	Retention time.Duration
+	//CleanupInterval 清理已投递消息的间隔
+	CleanupInterval time.Duration
+	//MaxAttempts 最大投递次数,超过后消息标记为失败不再投递,<=0时不限制
+	MaxAttempts int
+	//RetryDelay 投递失败后的首次重试间隔,之后按2的指数增长
+	RetryDelay time.Duration
+	//RetryMaxDelay 投递失败后的最大重试间隔
+	RetryMaxDelay time.Duration
+	//Locker 分布式锁,用于选举唯一的投递实例;未设置时每个实例都会投递
+	Locker dlocker.DLockerBuilder
+	//RelayKey 选举投递实例的key,默认为 glue:outbox:relay:<Table>
+	RelayKey string
+	//ElectionOptions 选举配置
+	ElectionOptions []dlocker.ElectionOption
+	//Metrics metrics组件名称,设置后记录写入、投递、清理的次数及投递延迟
+	Metrics string
+}
+
+type Option func(opts *Options)
+
+// WithTable 设置outbox表名
+func WithTable(table string) Option {
+	return func(opts *Options) {
+		opts.Table = table
+	}
+}
+
+// WithBatchSize 设置每批投递的消息数
+func WithBatchSize(size int) Option {
+	return func(opts *Options) {
+		opts.BatchSize = size
+	}
+}
+
+// WithInterval 设置轮询间隔
+func WithInterval(interval time.Duration) Option {
+	return func(opts *Options) {
+		opts.Interval = interval
+	}
+}
+
+// WithRetention 设置已投递消息的保留时间及清理间隔
+func WithRetention(retention, cleanupInterval time.Duration) Option {
+	return func(opts *Options) {
+		opts.Retention = retention
+		opts.CleanupInterval = cleanupInterval
+	}
+}
+
+// WithRetry 设置最大投递次数及重试间隔
+func WithRetry(maxAttempts int, delay, maxDelay time.Duration) Option {
+	return func(opts *Options) {
+		opts.MaxAttempts = maxAttempts
+		opts.RetryDelay = delay
+		opts.RetryMaxDelay = maxDelay
+	}
+}
+
+// WithLocker 设置分布式锁,多个实例中只有当选的实例投递消息
+func WithLocker(locker dlocker.DLockerBuilder, opts ...dlocker.ElectionOption) Option {
+	return func(o *Options) {
+		o.Locker = locker
+		o.ElectionOptions = append(o.ElectionOptions, opts...)
+	}
+}
+
+// WithRelayKey 设置选举投递实例的key
+func WithRelayKey(key string) Option {
+	return func(opts *Options) {
+		opts.RelayKey = key
+	}
+}
+
+// WithMetrics 设置metrics组件名称
+func WithMetrics(proto string) Option {
+	return func(opts *Options) {
+		opts.Metrics = proto
+	}
+}
@@ -0,0 +1,152 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhiyunliu/glue/constants"
+	"github.com/zhiyunliu/glue/dlocker"
+	"github.com/zhiyunliu/glue/global"
+	"github.com/zhiyunliu/glue/queue"
+	"github.com/zhiyunliu/glue/session"
+	"github.com/zhiyunliu/glue/xdb"
+	"github.com/zhiyunliu/golibs/xrandom"
+)
+
+const (
+	//StatusPending 待投递
+	StatusPending = 0
+	//StatusSent 已投递
+	StatusSent = 1
+	//StatusFailed 超过最大投递次数或消息无法解析,不再投递
+	StatusFailed = 2
+)
+
+var _ queue.IQueue = (*Outbox)(nil)
+
+// Outbox 事务消息发件箱:
+// Send 将消息写入数据库的outbox表,ctx中绑定了当前连接的事务(xdb.WithTx/TransactionContext)时与业务数据在同一事务中提交;
+// Start 启动投递流程,将待投递的消息通过队列发送,发送成功后标记为已投递,保证消息至少投递一次
+type Outbox struct {
+	db       xdb.IDB
+	connName string
+	producer queue.IMQP
+	opts     *Options
+	metrics  *outboxMetrics
+
+	lock     sync.Mutex
+	election *dlocker.Election
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// New 构建发件箱,connName为db的连接名称(dbs.<name>),用于查找ctx中绑定的事务;outbox表不存在时自动创建
+func New(db xdb.IDB, connName string, producer queue.IMQP, opts ...Option) (*Outbox, error) {
+	outboxOpts := &Options{
+		Table:           DefaultTable,
+		BatchSize:       defaultBatchSize,
+		Interval:        defaultInterval,
+		Retention:       defaultRetention,
+		CleanupInterval: defaultCleanupInterval,
+		RetryDelay:      defaultRetryDelay,
+		RetryMaxDelay:   defaultRetryMaxDelay,
+	}
+	for i := range opts {
+		opts[i](outboxOpts)
+	}
+	if outboxOpts.BatchSize <= 0 {
+		outboxOpts.BatchSize = defaultBatchSize
+	}
+	if outboxOpts.Interval <= 0 {
+		outboxOpts.Interval = defaultInterval
+	}
+	if outboxOpts.CleanupInterval <= 0 {
+		outboxOpts.CleanupInterval = defaultCleanupInterval
+	}
+	if outboxOpts.RelayKey == "" {
+		outboxOpts.RelayKey = fmt.Sprintf("glue:outbox:relay:%s", outboxOpts.Table)
+	}
+	o := &Outbox{
+		db:       db,
+		connName: connName,
+		producer: producer,
+		opts:     outboxOpts,
+		metrics:  &outboxMetrics{table: outboxOpts.Table, proto: outboxOpts.Metrics},
+	}
+	if err := o.ensureTable(context.Background()); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Send 写入待投递消息,ctx中没有当前连接的事务时直接写入
+func (o *Outbox) Send(ctx context.Context, key string, value interface{}) error {
+	return o.DelaySend(ctx, key, value, 0)
+}
+
+// DelaySend 写入延迟消息,投递时按剩余的延迟时间调用队列的DelayPush
+func (o *Outbox) DelaySend(ctx context.Context, key string, value interface{}, delaySeconds int64) error {
+	var executer xdb.Executer = o.db
+	if tx, ok := xdb.GetTx(ctx, o.connName); ok {
+		executer = tx
+	}
+	return o.Write(ctx, executer, key, value, delaySeconds)
+}
+
+// Write 通过指定的事务写入消息,用于 IDB.Transaction 等未将事务绑定到ctx的场景
+func (o *Outbox) Write(ctx context.Context, tx xdb.Executer, key string, value interface{}, delaySeconds int64) (err error) {
+	defer func() {
+		o.metrics.count("outbox.write", err, key)
+	}()
+	if len(strings.TrimSpace(key)) == 0 {
+		return errors.New("outbox.Send,queue name can't be empty")
+	}
+	msg, ok := value.(queue.Message)
+	if !ok {
+		msg = queue.NewMsg(value)
+	}
+	if sid, ok := session.FromContext(ctx); ok {
+		msg.Header()[constants.HeaderRequestId] = sid
+	}
+	msg.Header()[constants.HeaderSourceIp] = global.LocalIp
+	msg.Header()[constants.HeaderSourceName] = global.AppName
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("outbox: 消息序列化出错:%w", err)
+	}
+	if len(data) > MaxMessageSize {
+		return fmt.Errorf("outbox: 消息长度%d超过限制%d", len(data), MaxMessageSize)
+	}
+	if delaySeconds < 0 {
+		delaySeconds = 0
+	}
+	now := time.Now().UnixMilli()
+	_, err = tx.Exec(ctx, fmt.Sprintf(insertSQL, o.opts.Table), map[string]any{
+		"id":            xrandom.Str(32),
+		"queue_key":     key,
+		"message":       string(data),
+		"delay_seconds": delaySeconds,
+		"status":        StatusPending,
+		"created_at":    now,
+	})
+	if err != nil {
+		return fmt.Errorf("outbox: 写入消息出错:%w", err)
+	}
+	return nil
+}
+
+// ensureTable outbox表不存在时创建;使用各数据库通用的字段类型,时间字段保存为毫秒时间戳
+func (o *Outbox) ensureTable(ctx context.Context) error {
+	ddl := make([]string, 0, len(createSQL))
+	for _, stmt := range createSQL {
+		ddl = append(ddl, strings.ReplaceAll(stmt, "{table}", o.opts.Table))
+	}
+	if err := xdb.EnsureTable(ctx, o.db, o.opts.Table, ddl...); err != nil {
+		return fmt.Errorf("outbox: 创建表[%s]出错:%w", o.opts.Table, err)
+	}
+	return nil
+}
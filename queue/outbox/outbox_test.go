@@ -0,0 +1,315 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhiyunliu/glue/config"
+	"github.com/zhiyunliu/glue/constants"
+	dlockermemory "github.com/zhiyunliu/glue/contrib/dlocker/memory"
+	queuememory "github.com/zhiyunliu/glue/contrib/queue/memory"
+	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
+	_ "github.com/zhiyunliu/glue/contrib/xdb/sqlite"
+	"github.com/zhiyunliu/glue/dlocker"
+	_ "github.com/zhiyunliu/glue/encoding/binding"
+	"github.com/zhiyunliu/glue/global"
+	"github.com/zhiyunliu/glue/metrics"
+	"github.com/zhiyunliu/glue/queue"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+const testConnName = "outbox_test"
+
+type pushed struct {
+	key   string
+	msg   queue.Message
+	delay int64
+}
+
+// testProducer 记录投递的消息,err不为nil时投递失败
+type testProducer struct {
+	lock   sync.Mutex
+	err    error
+	pushed []pushed
+}
+
+func (p *testProducer) Push(key string, msg queue.Message) error {
+	return p.DelayPush(key, msg, 0)
+}
+
+func (p *testProducer) DelayPush(key string, msg queue.Message, delaySeconds int64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.pushed = append(p.pushed, pushed{key: key, msg: msg, delay: delaySeconds})
+	return nil
+}
+
+func (p *testProducer) Close() error { return nil }
+
+type testCounter struct {
+	lvs    []string
+	values map[string]float64
+}
+
+func (c *testCounter) With(lvs ...string) metrics.Counter {
+	return &testCounter{lvs: lvs, values: c.values}
+}
+func (c *testCounter) Inc()              { c.Add(1) }
+func (c *testCounter) Add(delta float64) { c.values[fmt.Sprint(c.lvs)] += delta }
+
+type testProvider struct {
+	counter *testCounter
+}
+
+func (p *testProvider) Name() string               { return "test" }
+func (p *testProvider) Counter() metrics.Counter   { return p.counter }
+func (p *testProvider) Observer() metrics.Observer { return nil }
+func (p *testProvider) Gauge() metrics.Gauge       { return nil }
+func (p *testProvider) GetImpl() interface{}       { return nil }
+
+func newTestDB(t *testing.T) xdb.IDB {
+	setting := contribxdb.NewConfig(testConnName)
+	setting.Cfg.Conn = filepath.Join(t.TempDir(), "outbox.db")
+	dbobj, err := contribxdb.NewDB("sqlite", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dbobj.Close() })
+	return dbobj
+}
+
+func newTestOutbox(t *testing.T, db xdb.IDB, producer queue.IMQP, opts ...Option) *Outbox {
+	o, err := New(db, testConnName, producer, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { o.Close() })
+	return o
+}
+
+func countStatus(t *testing.T, db xdb.IDB, status int) int {
+	t.Helper()
+	rows, err := db.Query(context.Background(), fmt.Sprintf("select id from %s where status=@{status}", DefaultTable), map[string]any{"status": status})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(rows)
+}
+
+func TestSendInTransaction(t *testing.T) {
+	db := newTestDB(t)
+	producer := &testProducer{}
+	o := newTestOutbox(t, db, producer)
+	ctx := context.Background()
+
+	err := db.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+		return o.Send(ctx, "order", map[string]int{"id": 1})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rollback := errors.New("rollback")
+	err = db.TransactionContext(ctx, func(ctx context.Context, tx xdb.Executer) error {
+		if err := o.Send(ctx, "order", map[string]int{"id": 2}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("TransactionContext() error = %v", err)
+	}
+	err = db.Transaction(func(tx xdb.Executer) error {
+		if err := o.Write(ctx, tx, "order", map[string]int{"id": 3}, 0); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+	if n := countStatus(t, db, StatusPending); n != 1 {
+		t.Fatalf("pending = %d, want 1", n)
+	}
+
+	sent, err := o.Publish(ctx)
+	if err != nil || sent != 1 {
+		t.Fatalf("Publish() = %d, %v", sent, err)
+	}
+	if len(producer.pushed) != 1 {
+		t.Fatalf("pushed = %d, want 1", len(producer.pushed))
+	}
+	p := producer.pushed[0]
+	if p.key != "order" || p.delay != 0 || string(p.msg.Body()) != `{"id":1}` || p.msg.Header()[constants.HeaderSourceName] != global.AppName {
+		t.Fatalf("pushed = %+v, header = %v, body = %s", p, p.msg.Header(), p.msg.Body())
+	}
+	if n := countStatus(t, db, StatusSent); n != 1 {
+		t.Fatalf("sent = %d, want 1", n)
+	}
+	if sent, _ = o.Publish(ctx); sent != 0 {
+		t.Fatalf("Publish() again = %d, want 0", sent)
+	}
+}
+
+func TestDelaySend(t *testing.T) {
+	db := newTestDB(t)
+	producer := &testProducer{}
+	o := newTestOutbox(t, db, producer)
+	ctx := context.Background()
+
+	if err := o.DelaySend(ctx, "order", "{}", 30); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Publish(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(producer.pushed) != 1 || producer.pushed[0].delay < 29 || producer.pushed[0].delay > 30 {
+		t.Fatalf("pushed = %+v", producer.pushed)
+	}
+}
+
+func TestPublishBatchSize(t *testing.T) {
+	db := newTestDB(t)
+	producer := &testProducer{}
+	o := newTestOutbox(t, db, producer, WithBatchSize(2))
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		o.Send(ctx, "order", map[string]int{"id": i})
+	}
+	if sent, err := o.Publish(ctx); err != nil || sent != 2 {
+		t.Fatalf("Publish() = %d, %v, want 2", sent, err)
+	}
+	if sent, _ := o.Publish(ctx); sent != 1 {
+		t.Fatalf("Publish() remaining = %d, want 1", sent)
+	}
+	if err := o.Send(ctx, "order", strings.Repeat("a", MaxMessageSize)); err == nil {
+		t.Fatal("Send() oversized message should fail")
+	}
+}
+
+func TestPublishRetry(t *testing.T) {
+	db := newTestDB(t)
+	producer := &testProducer{err: errors.New("broker down")}
+	o := newTestOutbox(t, db, producer, WithRetry(2, 10*time.Millisecond, time.Second))
+	ctx := context.Background()
+
+	if err := o.Send(ctx, "order", "{}"); err != nil {
+		t.Fatal(err)
+	}
+	if sent, err := o.Publish(ctx); err != nil || sent != 0 {
+		t.Fatalf("Publish() = %d, %v", sent, err)
+	}
+	row, err := db.First(ctx, fmt.Sprintf("select status,attempts,last_error from %s", DefaultTable), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.GetString("status") != "0" || row.GetString("attempts") != "1" || row.GetString("last_error") != "broker down" {
+		t.Fatalf("row = %v", row)
+	}
+	//未到重试时间不投递
+	if sent, _ := o.Publish(ctx); sent != 0 || countStatus(t, db, StatusPending) != 1 {
+		t.Fatalf("Publish() before next_at = %d", sent)
+	}
+	time.Sleep(20 * time.Millisecond)
+	o.Publish(ctx)
+	if n := countStatus(t, db, StatusFailed); n != 1 {
+		t.Fatalf("failed = %d, want 1", n)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	counter := &testCounter{values: map[string]float64{}}
+	origProvider := getMetricProvider
+	getMetricProvider = func(proto string) metrics.Provider { return &testProvider{counter: counter} }
+	defer func() { getMetricProvider = origProvider }()
+
+	db := newTestDB(t)
+	o := newTestOutbox(t, db, &testProducer{}, WithRetention(0, time.Minute), WithMetrics("test"))
+	ctx := context.Background()
+	o.Send(ctx, "order", "{}")
+	o.Send(ctx, "order", "{}")
+	o.Publish(ctx)
+	o.Send(ctx, "order", "{}")
+
+	time.Sleep(2 * time.Millisecond)
+	deleted, err := o.Cleanup(ctx)
+	if err != nil || deleted != 2 {
+		t.Fatalf("Cleanup() = %d, %v", deleted, err)
+	}
+	if n := countStatus(t, db, StatusPending); n != 1 {
+		t.Fatalf("pending = %d, want 1", n)
+	}
+	want := map[string]float64{
+		"[outbox.write queue_outbox ok order]":   3,
+		"[outbox.publish queue_outbox ok order]": 2,
+		"[outbox.cleanup queue_outbox ok ]":      1,
+	}
+	for k, v := range want {
+		if counter.values[k] != v {
+			t.Errorf("counter %s = %v, want %v, all = %v", k, counter.values[k], v, counter.values)
+		}
+	}
+}
+
+func TestRelayElection(t *testing.T) {
+	name := fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+	cfg := config.New(config.WithSource(config.NewStrSource(`{"queues":{"mq":{"proto":"memory","addr":"memory://` + name + `"}}}`)))
+	if err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	mqp, err := queue.NewMQP(queuememory.Proto, cfg.Get(queue.TypeNode).Get("mq"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker := queuememory.GetBroker(name)
+
+	db := newTestDB(t)
+	locker := dlockermemory.New()
+	//同一进程中模拟两个实例,需要使用不同的节点标识
+	newRelay := func(identity string) *Outbox {
+		return newTestOutbox(t, db, mqp, WithInterval(10*time.Millisecond), WithLocker(locker,
+			dlocker.WithTTL(3),
+			dlocker.WithIdentity(identity),
+			dlocker.WithLockOptions(dlocker.WithBackoff(time.Millisecond, 10*time.Millisecond))))
+	}
+	first := newRelay("first")
+	second := newRelay("second")
+	first.Start()
+	waitFor(t, 2*time.Second, first.IsRelay)
+	second.Start()
+
+	ctx := context.Background()
+	first.Send(ctx, "order", "{}")
+	waitFor(t, 2*time.Second, func() bool { return broker.Len("order") == 1 })
+	if second.IsRelay() {
+		t.Fatal("only one instance should relay")
+	}
+
+	//投递实例退出后由其他实例接任
+	first.Close()
+	waitFor(t, 2*time.Second, second.IsRelay)
+	second.Send(ctx, "order", "{}")
+	waitFor(t, 2*time.Second, func() bool { return broker.Len("order") == 2 })
+	//投递到队列后才更新状态,等待状态更新完成
+	waitFor(t, 2*time.Second, func() bool { return countStatus(t, db, StatusSent) == 2 })
+}
+
+// waitFor 轮询直到cond成立或超时
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
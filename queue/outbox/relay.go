@@ -0,0 +1,260 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zhiyunliu/glue/dlocker"
+	"github.com/zhiyunliu/glue/log"
+	"github.com/zhiyunliu/glue/queue"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// 失败原因最大保存长度
+const maxErrorLen = 500
+
+type pendingItem struct {
+	id        string
+	key       string
+	message   string
+	delay     int64
+	attempts  int
+	createdAt int64
+}
+
+// Start 启动投递流程;设置了Locker时参与选举,只有当选的实例投递消息
+func (o *Outbox) Start() error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.cancel != nil {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	o.cancel = cancel
+	if o.opts.Locker != nil {
+		o.election = dlocker.Campaign(ctx, o.opts.Locker, o.opts.RelayKey, o.relay, nil, o.opts.ElectionOptions...)
+		return nil
+	}
+	o.done = make(chan struct{})
+	go func() {
+		defer close(o.done)
+		o.relay(ctx)
+	}()
+	return nil
+}
+
+// Close 停止投递流程,等待投递中的消息完成;队列生产者由调用方关闭
+func (o *Outbox) Close() error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.cancel == nil {
+		return nil
+	}
+	o.cancel()
+	if o.election != nil {
+		o.election.Stop()
+	} else {
+		<-o.done
+	}
+	o.cancel, o.election, o.done = nil, nil, nil
+	return nil
+}
+
+// IsRelay 当前实例是否正在投递消息
+func (o *Outbox) IsRelay() bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.election != nil {
+		return o.election.IsLeader()
+	}
+	return o.cancel != nil
+}
+
+func (o *Outbox) relay(ctx context.Context) {
+	cleanup := time.NewTicker(o.opts.CleanupInterval)
+	defer cleanup.Stop()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cleanup.C:
+			if _, err := o.Cleanup(ctx); err != nil {
+				log.Errorf("outbox.cleanup.table:%s,error:%+v", o.opts.Table, err)
+			}
+		case <-timer.C:
+			fetched, _, err := o.publish(ctx)
+			if err != nil {
+				log.Errorf("outbox.relay.table:%s,error:%+v", o.opts.Table, err)
+			}
+			//取满一批时立即处理下一批
+			wait := o.opts.Interval
+			if err == nil && fetched >= o.opts.BatchSize {
+				wait = 0
+			}
+			timer.Reset(wait)
+		}
+	}
+}
+
+// Publish 投递一批到期的待投递消息,返回投递成功的消息数;
+// 投递成功但标记失败时消息会被再次投递,消费方需要按消息内容做幂等处理
+func (o *Outbox) Publish(ctx context.Context) (sent int, err error) {
+	_, sent, err = o.publish(ctx)
+	return
+}
+
+func (o *Outbox) publish(ctx context.Context) (fetched, sent int, err error) {
+	now := time.Now().UnixMilli()
+	items, err := o.pending(ctx, now)
+	if err != nil {
+		return
+	}
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		if o.send(ctx, item, now) {
+			sent++
+		}
+	}
+	return len(items), sent, nil
+}
+
+// pending 读取一批到期的待投递消息,读取完成后再投递,避免投递过程中占用查询连接
+func (o *Outbox) pending(ctx context.Context, now int64) (items []*pendingItem, err error) {
+	cursor, err := o.db.QueryStream(xdb.WithReadPrimary(ctx), fmt.Sprintf(pendingSQL, o.opts.Table), map[string]any{
+		"status": StatusPending,
+		"now":    now,
+		"limit":  o.opts.BatchSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("outbox: 查询待投递消息出错:%w", err)
+	}
+	defer cursor.Close()
+	items = make([]*pendingItem, 0, o.opts.BatchSize)
+	for len(items) < o.opts.BatchSize && cursor.Next() {
+		row, err := cursor.ScanRow()
+		if err != nil {
+			return nil, fmt.Errorf("outbox: 读取待投递消息出错:%w", err)
+		}
+		item := &pendingItem{
+			id:      row.GetString("id"),
+			key:     row.GetString("queue_key"),
+			message: row.GetString("message"),
+		}
+		item.delay, _ = xdb.ToInt64(row["delay_seconds"])
+		item.attempts, _ = row.GetInt("attempts")
+		item.createdAt, _ = xdb.ToInt64(row["created_at"])
+		items = append(items, item)
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, fmt.Errorf("outbox: 读取待投递消息出错:%w", err)
+	}
+	return items, nil
+}
+
+// send 投递单条消息,延迟消息按剩余的延迟时间投递;失败时按重试间隔推迟下次投递
+func (o *Outbox) send(ctx context.Context, item *pendingItem, now int64) bool {
+	msg := &queue.MsgItem{}
+	if err := json.Unmarshal([]byte(item.message), msg); err != nil {
+		o.metrics.count("outbox.publish", err, item.key)
+		o.retry(ctx, item, now, StatusFailed, err)
+		return false
+	}
+	var err error
+	if remain := item.delay - (now-item.createdAt)/1000; remain > 0 {
+		err = o.producer.DelayPush(item.key, msg, remain)
+	} else {
+		err = o.producer.Push(item.key, msg)
+	}
+	o.metrics.count("outbox.publish", err, item.key)
+	if err != nil {
+		status := StatusPending
+		if o.opts.MaxAttempts > 0 && item.attempts+1 >= o.opts.MaxAttempts {
+			status = StatusFailed
+		}
+		o.retry(ctx, item, now, status, err)
+		return false
+	}
+	o.metrics.observe("outbox.publish", float64(now-item.createdAt)/1000)
+	_, err = o.db.Exec(ctx, fmt.Sprintf(sentSQL, o.opts.Table), map[string]any{
+		"id":      item.id,
+		"sent":    StatusSent,
+		"pending": StatusPending,
+		"now":     time.Now().UnixMilli(),
+	})
+	if err != nil {
+		log.Errorf("outbox.sent.table:%s,id:%s,error:%+v", o.opts.Table, item.id, err)
+	}
+	return true
+}
+
+// retry 记录投递失败,status为StatusFailed时不再投递
+func (o *Outbox) retry(ctx context.Context, item *pendingItem, now int64, status int, cause error) {
+	attempts := item.attempts + 1
+	lastError := cause.Error()
+	if len(lastError) > maxErrorLen {
+		lastError = lastError[:maxErrorLen]
+	}
+	if status == StatusFailed {
+		log.Errorf("outbox.failed.table:%s,id:%s,queue:%s,attempts:%d,error:%+v", o.opts.Table, item.id, item.key, attempts, cause)
+	}
+	_, err := o.db.Exec(ctx, fmt.Sprintf(retrySQL, o.opts.Table), map[string]any{
+		"id":         item.id,
+		"status":     status,
+		"pending":    StatusPending,
+		"attempts":   attempts,
+		"next_at":    now + o.backoff(attempts).Milliseconds(),
+		"last_error": lastError,
+	})
+	if err != nil {
+		log.Errorf("outbox.retry.table:%s,id:%s,error:%+v", o.opts.Table, item.id, err)
+	}
+}
+
+// backoff 第attempts次投递失败后的重试间隔,按2的指数增长
+func (o *Outbox) backoff(attempts int) time.Duration {
+	delay, maxDelay := o.opts.RetryDelay, o.opts.RetryMaxDelay
+	if maxDelay < delay {
+		maxDelay = delay
+	}
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// Cleanup 删除超过保留时间的已投递消息,并记录待投递消息数
+func (o *Outbox) Cleanup(ctx context.Context) (deleted int64, err error) {
+	defer func() {
+		o.metrics.count("outbox.cleanup", err, "")
+	}()
+	result, err := o.db.Exec(ctx, fmt.Sprintf(cleanupSQL, o.opts.Table), map[string]any{
+		"status": StatusSent,
+		"before": time.Now().Add(-o.opts.Retention).UnixMilli(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("outbox: 清理已投递消息出错:%w", err)
+	}
+	deleted, _ = result.RowsAffected()
+	if o.metrics.getProvider() == nil {
+		return
+	}
+	count, err := o.db.Scalar(xdb.WithReadPrimary(ctx), fmt.Sprintf(countSQL, o.opts.Table), map[string]any{
+		"status": StatusPending,
+	})
+	if err != nil {
+		return deleted, fmt.Errorf("outbox: 查询待投递消息数出错:%w", err)
+	}
+	var pending float64
+	fmt.Sscan(fmt.Sprint(count), &pending)
+	o.metrics.gauge("outbox.pending", pending)
+	return
+}
@@ -0,0 +1,25 @@
+package outbox
+
+// MaxMessageSize 序列化后的消息最大长度,受各数据库varchar字段长度的限制
+const MaxMessageSize = 4000
+
+// 字段类型使用各数据库通用的写法,时间字段为毫秒时间戳
+var createSQL = []string{
+	"create table {table}(id varchar(64) not null primary key,queue_key varchar(255) not null,message varchar(4000) not null,delay_seconds numeric(19) not null,status int not null,attempts int not null,last_error varchar(500),created_at numeric(19) not null,next_at numeric(19) not null,sent_at numeric(19))",
+	"create index idx_{table}_status on {table}(status,next_at)",
+}
+
+const (
+	insertSQL = "insert into %s(id,queue_key,message,delay_seconds,status,attempts,created_at,next_at) values(@{id},@{queue_key},@{message},@{delay_seconds},@{status},0,@{created_at},@{created_at})"
+
+	//按(status,next_at)索引顺序读取,通过各数据库的分页语法限制条数
+	pendingSQL = "select id,queue_key,message,delay_seconds,attempts,created_at from %s where status=@{status} and next_at<=@{now} order by next_at,id %%{limit}"
+
+	sentSQL = "update %s set status=@{sent},sent_at=@{now} where id=@{id} and status=@{pending}"
+
+	retrySQL = "update %s set status=@{status},attempts=@{attempts},next_at=@{next_at},last_error=@{last_error} where id=@{id} and status=@{pending}"
+
+	cleanupSQL = "delete from %s where status=@{status} and sent_at<@{before}"
+
+	countSQL = "select count(1) from %s where status=@{status}"
+)
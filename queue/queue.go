@@ -47,6 +47,11 @@ type Message interface {
 
 type ConsumeCallback func(IMQCMessage)
 
+// IMessageIdMQC consumer的消息标识在重新投递时是否保持不变,保持不变时可以通过IMQCMessage.MessageId识别重复消息
+type IMessageIdMQC interface {
+	StableMessageId() bool
+}
+
 // IMQC consumer接口
 type IMQC interface {
 	Connect() error
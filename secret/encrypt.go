@@ -0,0 +1,37 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/zhiyunliu/glue/global"
+	"github.com/zhiyunliu/golibs/xsecurity/aes"
+)
+
+var (
+	SecretKey         = "glue.xdb12345678"
+	SecretMode        = "cbc/pkcs7"
+	BaseSecretEnvName = "BASE_SECRET_ENV_NAME"
+)
+
+// encryptProvider encrypt://密文,密钥由 app.BASE_SECRET_ENV_NAME 指定的环境变量提供(使用SecretKey加密)
+type encryptProvider struct{}
+
+func (encryptProvider) Scheme() string {
+	return "encrypt"
+}
+
+func (encryptProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	envName := global.Config.Get("app").Value(BaseSecretEnvName)
+	if envName.String() == "" {
+		return "", fmt.Errorf("配置为加密模式,但 app.%s 值为空", BaseSecretEnvName)
+	}
+	secretKey := os.Getenv(envName.String())
+
+	orgKey, err := aes.Decrypt(secretKey, SecretKey, SecretMode)
+	if err != nil {
+		return "", err
+	}
+	return aes.Decrypt(ref, orgKey, SecretMode)
+}
@@ -0,0 +1,38 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envProvider env://NAME 读取环境变量
+type envProvider struct{}
+
+func (envProvider) Scheme() string {
+	return "env"
+}
+
+func (envProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	val, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("环境变量[%s]未设置", ref)
+	}
+	return val, nil
+}
+
+// fileProvider secret:file:///path 读取文件内容,去掉首尾空白;不带secret:前缀的file://按普通值处理
+type fileProvider struct{}
+
+func (fileProvider) Scheme() string {
+	return "file"
+}
+
+func (fileProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	buff, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buff)), nil
+}
@@ -0,0 +1,92 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const schemeSeparator = "://"
+
+// ReferencePrefix 显式密钥引用前缀,secret:scheme://ref 总是按scheme解析
+const ReferencePrefix = "secret:"
+
+// ExplicitSchemes 与普通配置值冲突的scheme(如sqlite连接字符串 file:///data/app.db?cache=shared),
+// 只有带ReferencePrefix时才作为密钥引用解析
+var ExplicitSchemes = map[string]bool{"file": true}
+
+// Provider 密钥提供程序,按scheme解析形如 scheme://ref 的配置值
+type Provider interface {
+	Scheme() string
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+var providers sync.Map
+
+// Register 注册密钥提供程序
+func Register(provider Provider) {
+	scheme := provider.Scheme()
+	if _, loaded := providers.LoadOrStore(scheme, provider); loaded {
+		panic(fmt.Errorf("secret: 不能重复注册:%s", scheme))
+	}
+}
+
+// Deregister 清理密钥提供程序
+func Deregister(scheme string) {
+	providers.Delete(scheme)
+}
+
+// GetProvider 获取value对应的密钥提供程序
+func GetProvider(value string) (provider Provider, ref string, ok bool) {
+	explicit := strings.HasPrefix(value, ReferencePrefix)
+	value = strings.TrimPrefix(value, ReferencePrefix)
+	scheme, ref, found := strings.Cut(value, schemeSeparator)
+	if !found || (!explicit && ExplicitSchemes[scheme]) {
+		return nil, "", false
+	}
+	val, ok := providers.Load(scheme)
+	if !ok {
+		return nil, "", false
+	}
+	return val.(Provider), ref, true
+}
+
+// IsReference value是否为已注册scheme的密钥引用
+func IsReference(value string) bool {
+	_, _, ok := GetProvider(value)
+	return ok
+}
+
+// Resolve 解析密钥引用,未注册的scheme(如 mysql://)及普通值原样返回;带ReferencePrefix的值必须是已注册的引用
+func Resolve(ctx context.Context, value string) (string, error) {
+	provider, ref, ok := GetProvider(value)
+	if !ok {
+		if strings.HasPrefix(value, ReferencePrefix) {
+			return "", fmt.Errorf("secret: 未注册的密钥引用:%s", value)
+		}
+		return value, nil
+	}
+	result, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("secret: 解析[%s://]失败:%w", provider.Scheme(), err)
+	}
+	return result, nil
+}
+
+// ResolveAll 依次解析values中的密钥引用,结果写回原位置
+func ResolveAll(ctx context.Context, values ...*string) (err error) {
+	for _, val := range values {
+		if *val, err = Resolve(ctx, *val); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func init() {
+	Register(&envProvider{})
+	Register(&fileProvider{})
+	Register(&encryptProvider{})
+	Register(NewVaultProvider("", ""))
+}
@@ -0,0 +1,69 @@
+package secret
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("SECRET_TEST_CONN", "root:123456@tcp(localhost)/demo")
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	kv := NewLocalKV("token")
+	kv.Set("secret/data/db", "password", "vault-secret")
+	server := httptest.NewServer(kv)
+	defer server.Close()
+	Register(&namedProvider{Provider: NewVaultProvider(server.URL, "token"), scheme: "vault_test"})
+	Register(&namedProvider{Provider: NewVaultProvider(server.URL, "wrong"), scheme: "vault_forbidden"})
+	t.Cleanup(func() {
+		Deregister("vault_test")
+		Deregister("vault_forbidden")
+	})
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "root:123456@tcp(localhost)/demo", want: "root:123456@tcp(localhost)/demo"},
+		{value: "postgres://root@localhost/demo", want: "postgres://root@localhost/demo"},
+		{value: "env://SECRET_TEST_CONN", want: "root:123456@tcp(localhost)/demo"},
+		{value: "env://SECRET_TEST_NONE", wantErr: true},
+		{value: "secret:file://" + file, want: "file-secret"},
+		{value: "file://" + file, want: "file://" + file},
+		{value: "file:///data/app.db?cache=shared", want: "file:///data/app.db?cache=shared"},
+		{value: "secret:env://SECRET_TEST_CONN", want: "root:123456@tcp(localhost)/demo"},
+		{value: "secret:none://a", wantErr: true},
+		{value: "vault_test://secret/data/db#password", want: "vault-secret"},
+		{value: "vault_test://secret/data/db", want: "vault-secret"},
+		{value: "vault_test://secret/data/db#none", wantErr: true},
+		{value: "vault_test://secret/data/none#password", wantErr: true},
+		{value: "vault_forbidden://secret/data/db#password", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Resolve(context.Background(), tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type namedProvider struct {
+	Provider
+	scheme string
+}
+
+func (p *namedProvider) Scheme() string {
+	return p.scheme
+}
@@ -0,0 +1,140 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	vaultAddrEnv  = "VAULT_ADDR"
+	vaultTokenEnv = "VAULT_TOKEN"
+	vaultTimeout  = 5 * time.Second
+)
+
+// vaultProvider vault://path#key 通过HTTP KV接口(兼容vault kv v1/v2)读取密钥:
+// GET {addr}/v1/{path},请求头X-Vault-Token,返回 data.data[key](v2) 或 data[key](v1)
+type vaultProvider struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// NewVaultProvider 构建vault密钥提供程序,addr,token为空时读取环境变量VAULT_ADDR,VAULT_TOKEN
+func NewVaultProvider(addr, token string) Provider {
+	return &vaultProvider{
+		addr:   addr,
+		token:  token,
+		client: &http.Client{Timeout: vaultTimeout},
+	}
+}
+
+func (p *vaultProvider) Scheme() string {
+	return "vault"
+}
+
+func (p *vaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	addr, token := p.addr, p.token
+	if addr == "" {
+		addr = os.Getenv(vaultAddrEnv)
+	}
+	if token == "" {
+		token = os.Getenv(vaultTokenEnv)
+	}
+	if addr == "" {
+		return "", fmt.Errorf("未设置vault地址(%s)", vaultAddrEnv)
+	}
+	path, key, _ := strings.Cut(ref, "#")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("读取[%s]失败,状态码:%d", path, resp.StatusCode)
+	}
+	body := struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	data := body.Data
+	if nested, ok := data["data"]; ok {
+		data = map[string]json.RawMessage{}
+		if err = json.Unmarshal(nested, &data); err != nil {
+			return "", err
+		}
+	}
+	if key == "" && len(data) == 1 {
+		for k := range data {
+			key = k
+		}
+	}
+	raw, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("[%s]中不存在[%s]", path, key)
+	}
+	var val string
+	if err = json.Unmarshal(raw, &val); err != nil {
+		return strings.TrimSpace(string(raw)), nil
+	}
+	return val, nil
+}
+
+// LocalKV 本地KV服务,与vault kv v2的读取接口一致,用于开发、测试环境替代vault
+type LocalKV struct {
+	token string
+	mu    sync.RWMutex
+	data  map[string]map[string]string
+}
+
+// NewLocalKV 构建本地KV服务,token不为空时校验X-Vault-Token
+func NewLocalKV(token string) *LocalKV {
+	return &LocalKV{token: token, data: map[string]map[string]string{}}
+}
+
+// Set 设置path下key的值
+func (kv *LocalKV) Set(path, key, value string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	path = strings.Trim(path, "/")
+	if kv.data[path] == nil {
+		kv.data[path] = map[string]string{}
+	}
+	kv.data[path][key] = value
+}
+
+func (kv *LocalKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if kv.token != "" && r.Header.Get("X-Vault-Token") != kv.token {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+	kv.mu.RLock()
+	data, ok := kv.data[path]
+	var body []byte
+	if ok {
+		body, _ = json.Marshal(map[string]any{"data": map[string]any{"data": data}})
+	}
+	kv.mu.RUnlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
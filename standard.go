@@ -1,6 +1,8 @@
 package glue
 
 import (
+	"context"
+
 	"github.com/zhiyunliu/glue/cache"
 	"github.com/zhiyunliu/glue/circuitbreaker"
 	"github.com/zhiyunliu/glue/dlocker"
@@ -49,6 +51,18 @@ func DLocker(key string, opts ...dlocker.Option) dlocker.DLocker {
 	return obj.(dlocker.StandardLocker).GetDLocker().Build(key, opts...)
 }
 
+// DRWLocker 获取读写锁
+func DRWLocker(key string, opts ...dlocker.Option) dlocker.RWLocker {
+	obj := standard.GetInstance(dlocker.TypeNode)
+	return obj.(dlocker.StandardLocker).GetDLocker().BuildRW(key, opts...)
+}
+
+// Campaign 基于dlocker参与key的leader竞选,ctx结束或调用Stop时卸任并释放锁
+func Campaign(ctx context.Context, key string, onElected func(ctx context.Context), onRevoked func(), opts ...dlocker.ElectionOption) *dlocker.Election {
+	obj := standard.GetInstance(dlocker.TypeNode)
+	return dlocker.Campaign(ctx, obj.(dlocker.StandardLocker).GetDLocker(), key, onElected, onRevoked, opts...)
+}
+
 // 暂时没考虑用泛型
 func Custom(name string) interface{} {
 	obj := standard.GetInstance(name)
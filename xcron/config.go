@@ -26,6 +26,7 @@ type Job struct {
 	Disable             bool              `json:"disable"`
 	Immediately         bool              `json:"immediately"`
 	Monopoly            bool              `json:"monopoly"`
+	LeaderOnly          bool              `json:"leader_only"`
 	WithSeconds         bool              `json:"with_seconds"`
 	Meta                metadata.Metadata `json:"meta,omitempty"`
 	schedule            cron.Schedule     `json:"-"`
@@ -75,6 +76,11 @@ func (t *Job) IsMonopoly() bool {
 	return t.Monopoly
 }
 
+// 是否仅在leader节点执行
+func (t *Job) IsLeaderOnly() bool {
+	return t.LeaderOnly
+}
+
 // NextTime 下次执行时间
 func (m *Job) NextTime(t time.Time) (nextTime time.Time) {
 	if m.IsImmediately() && !m.immediatelyExecuted {
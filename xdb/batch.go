@@ -0,0 +1,35 @@
+package xdb
+
+// BatchOptions 批量执行选项
+type BatchOptions struct {
+	//Transaction 所有分片在同一事务中执行,任一分片失败则全部回滚
+	Transaction bool
+	//ChunkSize 每个分片的最大数据条数,小于等于0时按数据库参数及行数上限计算
+	ChunkSize int
+}
+
+// BatchOption 批量执行配置选项
+type BatchOption func(*BatchOptions)
+
+// WithBatchTransaction 在同一事务中执行所有分片
+func WithBatchTransaction() BatchOption {
+	return func(o *BatchOptions) {
+		o.Transaction = true
+	}
+}
+
+// WithBatchChunkSize 设置每个分片的最大数据条数
+func WithBatchChunkSize(size int) BatchOption {
+	return func(o *BatchOptions) {
+		o.ChunkSize = size
+	}
+}
+
+// NewBatchOptions 构建批量执行选项
+func NewBatchOptions(opts ...BatchOption) *BatchOptions {
+	batchOpts := &BatchOptions{}
+	for i := range opts {
+		opts[i](batchOpts)
+	}
+	return batchOpts
+}
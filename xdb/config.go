@@ -17,6 +17,35 @@ type Config struct {
 	LoggerName    string      `json:"logger_name" label:"日志提供程序"`
 	Debug         bool        `json:"debug" label:"调试模式"`
 	changefields  xtypes.XMap `json:"-"`
+
+	Replicas             []*ReplicaConfig `json:"replicas" label:"只读副本列表"`
+	ReplicaBalancer      string           `json:"replica_balancer" label:"副本负载均衡(wrr,p2c,random)"`
+	ReplicaCheckInterval int              `json:"replica_check_interval" label:"副本健康检查间隔(秒)"`
+	ReplicaLagTolerance  int              `json:"replica_lag_tolerance" label:"写后读主库时长(毫秒)"`
+
+	QueryCache *QueryCacheConfig `json:"query_cache" label:"查询结果缓存"`
+	Retry      *RetryPolicy      `json:"retry" label:"事务重试策略"`
+
+	Interceptors []string `json:"interceptors" label:"语句拦截器(按顺序执行)"`
+
+	Tracing         bool   `json:"tracing" label:"开启链路追踪"`
+	Metrics         string `json:"metrics" label:"指标提供程序"`
+	MetricsInterval int    `json:"metrics_interval" label:"连接池指标采集间隔(秒)"`
+
+	SecretRefresh int `json:"secret_refresh" label:"连接字符串密钥刷新间隔(秒)"`
+}
+
+// ReplicaConfig 只读副本配置
+type ReplicaConfig struct {
+	Conn   string `json:"conn" valid:"required" label:"连接字符串"`
+	Weight int    `json:"weight" label:"权重"`
+}
+
+// QueryCacheConfig 查询结果缓存配置,需要在上下文中通过WithCache开启
+type QueryCacheConfig struct {
+	Cache  string   `json:"cache" label:"缓存名称(caches节点)"`
+	TTL    int      `json:"ttl" label:"默认缓存时长(秒)"`
+	Tables []string `json:"tables" label:"写操作时失效缓存的表"`
 }
 
 func (c *Config) saveChangeField(field string, val any) {
@@ -44,6 +73,12 @@ var Default = &Config{
 	LongQueryTime: 500,
 	LoggerName:    "dbslowsql",
 	Debug:         false,
+
+	ReplicaBalancer:      "wrr",
+	ReplicaCheckInterval: 10,
+	ReplicaLagTolerance:  1000,
+
+	MetricsInterval: 15,
 }
 
 // Option 配置选项
@@ -100,3 +135,79 @@ func WithLoggerName(name string) Option {
 		a.saveChangeField("LoggerName", name)
 	}
 }
+
+func WithReplicaBalancer(name string) Option {
+	return func(a *Config) {
+		if name == "" {
+			return
+		}
+		a.ReplicaBalancer = name
+		a.saveChangeField("ReplicaBalancer", name)
+	}
+}
+
+func WithReplicaLagTolerance(millis int) Option {
+	return func(a *Config) {
+		if millis < 0 {
+			return
+		}
+		a.ReplicaLagTolerance = millis
+		a.saveChangeField("ReplicaLagTolerance", millis)
+	}
+}
+
+// WithQueryCache 设置查询结果缓存,cacheName为caches节点下的缓存名称,
+// tables中的表执行写操作时失效相关的查询缓存
+func WithQueryCache(cacheName string, ttl int, tables ...string) Option {
+	return func(a *Config) {
+		a.QueryCache = &QueryCacheConfig{
+			Cache:  cacheName,
+			TTL:    ttl,
+			Tables: tables,
+		}
+		a.saveChangeField("QueryCache", fmt.Sprint(cacheName, ttl, tables))
+	}
+}
+
+// WithRetry 设置事务重试策略,maxAttempts为最大执行次数(含首次),backoff为首次重试等待时长(毫秒)
+func WithRetry(maxAttempts int, backoff int) Option {
+	return func(a *Config) {
+		a.Retry = &RetryPolicy{
+			MaxAttempts: maxAttempts,
+			Backoff:     backoff,
+		}
+		a.saveChangeField("Retry", fmt.Sprint(maxAttempts, backoff))
+	}
+}
+
+// WithInterceptors 设置语句拦截器,在DefaultInterceptors之后按names顺序执行
+func WithInterceptors(names ...string) Option {
+	return func(a *Config) {
+		a.Interceptors = names
+		a.saveChangeField("Interceptors", fmt.Sprint(names))
+	}
+}
+
+// WithTracing 开启链路追踪,每次查询、执行及事务创建子span
+func WithTracing(enable bool) Option {
+	return func(a *Config) {
+		a.Tracing = enable
+		a.saveChangeField("Tracing", enable)
+	}
+}
+
+// WithMetrics 设置指标提供程序,记录语句耗时、错误数及连接池状态
+func WithMetrics(proto string) Option {
+	return func(a *Config) {
+		a.Metrics = proto
+		a.saveChangeField("Metrics", proto)
+	}
+}
+
+// WithSecretRefresh 连接字符串为密钥引用时,每隔seconds秒重新解析,变化后切换到新的连接池
+func WithSecretRefresh(seconds int) Option {
+	return func(a *Config) {
+		a.SecretRefresh = seconds
+		a.saveChangeField("SecretRefresh", seconds)
+	}
+}
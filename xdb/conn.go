@@ -10,6 +10,12 @@ func DefaultRefactor(connName string, cfg *Config) (newcfg *Config, err error) {
 		if err != nil {
 			return
 		}
+		for i := range newcfg.Replicas {
+			newcfg.Replicas[i].Conn, err = DecryptConn(connName, newcfg.Replicas[i].Conn)
+			if err != nil {
+				return
+			}
+		}
 	}
 	if ConnRefactor != nil {
 		newcfg, err = ConnRefactor(connName, newcfg)
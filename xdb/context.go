@@ -0,0 +1,123 @@
+package xdb
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type readPrimaryKey struct{}
+type writeTrackerKey struct{}
+
+// WithReadPrimary 强制当前上下文中的读请求路由到主库
+func WithReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readPrimaryKey{}, true)
+}
+
+// IsReadPrimary 当前上下文是否强制读主库
+func IsReadPrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	val, _ := ctx.Value(readPrimaryKey{}).(bool)
+	return val
+}
+
+// WriteTracker 记录上下文中最后一次写操作的时间
+type WriteTracker struct {
+	lastWrite int64
+}
+
+// MarkWrite 记录一次写操作
+func (t *WriteTracker) MarkWrite() {
+	atomic.StoreInt64(&t.lastWrite, time.Now().UnixNano())
+}
+
+// WrittenWithin 最近一次写操作是否在指定时长内
+func (t *WriteTracker) WrittenWithin(d time.Duration) bool {
+	last := atomic.LoadInt64(&t.lastWrite)
+	if last == 0 {
+		return false
+	}
+	return time.Since(time.Unix(0, last)) <= d
+}
+
+// WithReadYourWrites 开启写后读模式:
+// 同一上下文中执行写操作后, replica_lag_tolerance 时长内的读请求路由到主库
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := GetWriteTracker(ctx); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, writeTrackerKey{}, &WriteTracker{})
+}
+
+// GetWriteTracker 获取上下文中的写操作记录
+func GetWriteTracker(ctx context.Context) (*WriteTracker, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tracker, ok := ctx.Value(writeTrackerKey{}).(*WriteTracker)
+	return tracker, ok
+}
+
+type queryCacheKey struct{}
+type cacheEvictKey struct{}
+
+// CacheOptions 单次查询的缓存选项
+type CacheOptions struct {
+	TTL  int
+	Tags []string
+}
+
+// CacheOption 查询缓存选项
+type CacheOption func(*CacheOptions)
+
+// CacheTTL 缓存时长(秒),未设置时使用query_cache.ttl
+func CacheTTL(seconds int) CacheOption {
+	return func(o *CacheOptions) {
+		o.TTL = seconds
+	}
+}
+
+// CacheTags 缓存标签,WithCacheEvict失效标签时同时失效当前查询的缓存
+func CacheTags(tags ...string) CacheOption {
+	return func(o *CacheOptions) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+// WithCache 开启当前上下文中 Query/First/Scalar 的查询结果缓存,
+// 数据库未配置query_cache时不生效
+func WithCache(ctx context.Context, opts ...CacheOption) context.Context {
+	cacheOpts := &CacheOptions{}
+	for i := range opts {
+		opts[i](cacheOpts)
+	}
+	return context.WithValue(ctx, queryCacheKey{}, cacheOpts)
+}
+
+// GetCacheOptions 获取上下文中的查询缓存选项
+func GetCacheOptions(ctx context.Context) (*CacheOptions, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	opts, ok := ctx.Value(queryCacheKey{}).(*CacheOptions)
+	return opts, ok
+}
+
+// WithCacheEvict 当前上下文中的写操作成功后,额外失效指定标签的查询缓存
+func WithCacheEvict(ctx context.Context, tags ...string) context.Context {
+	parent := GetCacheEvict(ctx)
+	evictTags := make([]string, 0, len(parent)+len(tags))
+	evictTags = append(evictTags, parent...)
+	return context.WithValue(ctx, cacheEvictKey{}, append(evictTags, tags...))
+}
+
+// GetCacheEvict 获取上下文中需要失效的缓存标签
+func GetCacheEvict(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	tags, _ := ctx.Value(cacheEvictKey{}).([]string)
+	return tags
+}
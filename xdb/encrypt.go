@@ -1,43 +1,51 @@
 package xdb
 
 import (
-	"fmt"
-	"os"
-	"strings"
+	"context"
+	"sync"
 
-	"github.com/zhiyunliu/glue/global"
-	"github.com/zhiyunliu/golibs/xsecurity/aes"
+	"github.com/zhiyunliu/glue/secret"
 )
 
 var (
-	SecretKey         = "glue.xdb12345678"
-	SecretMode        = "cbc/pkcs7"
-	BaseSecretEnvName = "BASE_SECRET_ENV_NAME"
+	// SecretKey encrypt://的密钥加密key
+	//
+	// Deprecated: 使用secret.SecretKey,修改后的值在解析数据库连接字符串前写入secret包
+	SecretKey = secret.SecretKey
+	// SecretMode encrypt://的加密模式
+	//
+	// Deprecated: 使用secret.SecretMode,修改后的值在解析数据库连接字符串前写入secret包
+	SecretMode = secret.SecretMode
+	// BaseSecretEnvName app配置中提供密钥环境变量名称的配置项
+	//
+	// Deprecated: 使用secret.BaseSecretEnvName,修改后的值在解析数据库连接字符串前写入secret包
+	BaseSecretEnvName = secret.BaseSecretEnvName
 )
 
-const (
-	_connPrefix = "encrypt://"
+var (
+	legacyLock     sync.Mutex
+	legacyDefaults = [3]string{secret.SecretKey, secret.SecretMode, secret.BaseSecretEnvName}
 )
 
+// DecryptConn 解析连接字符串中的密钥引用(encrypt://,env://,secret:file://,vault:// 等,见secret包)
 var DecryptConn func(connName, conn string) (newConn string, err error) = defaultDecryptConn
 
 func defaultDecryptConn(connName, conn string) (newConn string, err error) {
-	if !strings.HasPrefix(conn, _connPrefix) {
-		newConn = conn
-		return
+	syncLegacySecret()
+	return secret.Resolve(context.Background(), conn)
+}
+
+// syncLegacySecret 将修改过的xdb.SecretKey等写入secret包,未修改的保持secret包中的配置
+func syncLegacySecret() {
+	legacyLock.Lock()
+	defer legacyLock.Unlock()
+	if SecretKey != legacyDefaults[0] {
+		secret.SecretKey = SecretKey
 	}
-	envName := global.Config.Get("app").Value(BaseSecretEnvName)
-	if envName.String() == "" {
-		err = fmt.Errorf("数据库配置为加密模式,但 app.%s 值为空", BaseSecretEnvName)
-		return
+	if SecretMode != legacyDefaults[1] {
+		secret.SecretMode = SecretMode
 	}
-	secretKey := os.Getenv(envName.String())
-
-	orgKey, err := aes.Decrypt(secretKey, SecretKey, SecretMode)
-	if err != nil {
-		return
+	if BaseSecretEnvName != legacyDefaults[2] {
+		secret.BaseSecretEnvName = BaseSecretEnvName
 	}
-	tmpConn := strings.TrimPrefix(conn, _connPrefix)
-	newConn, err = aes.Decrypt(tmpConn, orgKey, SecretMode)
-	return
 }
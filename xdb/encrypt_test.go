@@ -0,0 +1,30 @@
+package xdb
+
+import (
+	"testing"
+
+	"github.com/zhiyunliu/glue/secret"
+)
+
+func TestLegacySecretVars(t *testing.T) {
+	origKey, origEnv := secret.SecretKey, secret.BaseSecretEnvName
+	defer func() {
+		secret.SecretKey, secret.BaseSecretEnvName = origKey, origEnv
+		SecretKey, BaseSecretEnvName = origKey, origEnv
+	}()
+
+	//secret包中直接设置的值不会被未修改的xdb变量覆盖
+	secret.BaseSecretEnvName = "APP_SECRET_ENV"
+	SecretKey = "legacy.key123456"
+	syncLegacySecret()
+	if secret.SecretKey != "legacy.key123456" || secret.BaseSecretEnvName != "APP_SECRET_ENV" {
+		t.Errorf("secret = %s,%s", secret.SecretKey, secret.BaseSecretEnvName)
+	}
+}
+
+func TestDecryptConn_FileURI(t *testing.T) {
+	conn := "file:///data/app.db?cache=shared"
+	if got, err := DecryptConn("sqlite", conn); err != nil || got != conn {
+		t.Errorf("DecryptConn() = %v,%v, want %s", got, err, conn)
+	}
+}
@@ -7,8 +7,9 @@ import (
 )
 
 const (
-	MissTypeParam = "param"
-	MissTypeOper  = "oper"
+	MissTypeParam   = "param"
+	MissTypeOper    = "oper"
+	MissTypeInvalid = "invalid"
 )
 
 type DbError interface {
@@ -33,6 +34,19 @@ type PanicError interface {
 	StackTrace() string
 }
 
+// BatchChunkError 批量执行时单个分片的错误
+type BatchChunkError struct {
+	Chunk  int //分片序号
+	Offset int //分片第一条数据在inputs中的下标
+	Size   int //分片数据条数
+	Err    error
+}
+
+type BatchError interface {
+	Error() string
+	Chunks() []BatchChunkError
+}
+
 type InvalidArgTypeError struct {
 	Type reflect.Type
 }
@@ -99,10 +113,34 @@ func NewMissOperError(name string) MissError {
 	}
 }
 
+type xInvalidParamError struct {
+	paramName string
+}
+
+func (e xInvalidParamError) Error() string {
+	return fmt.Sprintf("SQL参数值不合法:[%s]", e.paramName)
+}
+
+func (e xInvalidParamError) Name() string {
+	return e.paramName
+}
+
+func (e xInvalidParamError) Type() string {
+	return MissTypeInvalid
+}
+
+// NewInvalidParamError 参数值不在白名单中或格式错误
+func NewInvalidParamError(name string) MissError {
+	return &xInvalidParamError{
+		paramName: name,
+	}
+}
+
 type xMissParamsError struct {
-	paramList []string
-	operList  []string
-	otherList []string
+	paramList   []string
+	operList    []string
+	invalidList []string
+	otherList   []string
 }
 
 func (e xMissParamsError) Error() string {
@@ -113,6 +151,9 @@ func (e xMissParamsError) Error() string {
 	if len(e.operList) > 0 {
 		msgList = append(msgList, fmt.Sprintf("缺少Operator定义:[%s]", strings.Join(e.operList, ",")))
 	}
+	if len(e.invalidList) > 0 {
+		msgList = append(msgList, fmt.Sprintf("SQL参数值不合法:[%s]", strings.Join(e.invalidList, ",")))
+	}
 	if len(e.otherList) > 0 {
 		msgList = append(msgList, fmt.Sprintf("缺少类型:[%s]", strings.Join(e.otherList, ",")))
 	}
@@ -122,20 +163,24 @@ func (e xMissParamsError) Error() string {
 func NewMissListError(errList ...MissError) MissListError {
 	paramList := []string{}
 	operList := []string{}
+	invalidList := []string{}
 	otherList := []string{}
 	for i := range errList {
 		if strings.EqualFold(errList[i].Type(), MissTypeParam) {
 			paramList = append(paramList, errList[i].Name())
 		} else if strings.EqualFold(errList[i].Type(), MissTypeOper) {
 			operList = append(operList, errList[i].Name())
+		} else if strings.EqualFold(errList[i].Type(), MissTypeInvalid) {
+			invalidList = append(invalidList, errList[i].Name())
 		} else {
 			otherList = append(otherList, fmt.Sprintf("%s:%s", errList[i].Type(), errList[i].Name()))
 		}
 	}
 	return &xMissParamsError{
-		paramList: paramList,
-		operList:  operList,
-		otherList: otherList,
+		paramList:   paramList,
+		operList:    operList,
+		invalidList: invalidList,
+		otherList:   otherList,
 	}
 }
 
@@ -155,6 +200,11 @@ func (e xDBError) Inner() error {
 	return e.innerError
 }
 
+// Unwrap 支持errors.Is/errors.As判断驱动返回的原始错误
+func (e xDBError) Unwrap() error {
+	return e.innerError
+}
+
 func (e xDBError) SQL() string {
 	return e.sql
 }
@@ -173,3 +223,26 @@ func NewPanicError(err error, strace string) error {
 		stackTrace: strace,
 	}
 }
+
+type xBatchError struct {
+	chunks []BatchChunkError
+}
+
+func (e xBatchError) Error() string {
+	msgList := make([]string, len(e.chunks))
+	for i := range e.chunks {
+		c := e.chunks[i]
+		msgList[i] = fmt.Sprintf("分片[%d](offset:%d,size:%d):%v", c.Chunk, c.Offset, c.Size, c.Err)
+	}
+	return strings.Join(msgList, "\r\n")
+}
+
+func (e xBatchError) Chunks() []BatchChunkError {
+	return e.chunks
+}
+
+func NewBatchError(chunks ...BatchChunkError) BatchError {
+	return &xBatchError{
+		chunks: chunks,
+	}
+}
@@ -0,0 +1,131 @@
+package xdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	//QueryKindQuery 查询语句
+	QueryKindQuery = "query"
+	//QueryKindExec 执行语句
+	QueryKindExec = "exec"
+	//QueryKindTransaction 事务,SQL为空,事务中的语句在Before返回的ctx中执行
+	QueryKindTransaction = "transaction"
+)
+
+// DefaultInterceptors 默认启用的拦截器,配置的interceptors追加在其后执行;各拦截器按自身的配置项(debug、show_query_log、tracing、metrics)决定是否生效
+var DefaultInterceptors = []string{"debug", "slowquery", "tracing", "metrics"}
+
+// ErrQueryBlocked 语句被拦截器拒绝执行
+var ErrQueryBlocked = errors.New("xdb: 语句被拦截器拒绝执行")
+
+// QueryInfo 语句执行信息,Before中可以修改SQL及Args,After中可以读取执行结果
+type QueryInfo struct {
+	ConnName     string
+	Proto        string
+	Kind         string
+	SQL          string
+	Args         []any
+	Start        time.Time
+	Duration     time.Duration
+	RowsAffected int64
+	Err          error
+}
+
+// Interceptor 语句拦截器,Before返回错误时语句不执行;
+// 已执行Before的拦截器都会按相反顺序执行After,After的ctx为该拦截器Before返回的ctx
+type Interceptor interface {
+	Before(ctx context.Context, info *QueryInfo) (context.Context, error)
+	After(ctx context.Context, info *QueryInfo)
+}
+
+// InterceptorFactory 按数据库连接配置创建拦截器
+type InterceptorFactory func(connName string, cfg *Config) (Interceptor, error)
+
+// InterceptorFuncs 使用函数构建拦截器,未设置的函数不执行
+type InterceptorFuncs struct {
+	BeforeFunc func(ctx context.Context, info *QueryInfo) (context.Context, error)
+	AfterFunc  func(ctx context.Context, info *QueryInfo)
+}
+
+func (f InterceptorFuncs) Before(ctx context.Context, info *QueryInfo) (context.Context, error) {
+	if f.BeforeFunc == nil {
+		return ctx, nil
+	}
+	return f.BeforeFunc(ctx, info)
+}
+
+func (f InterceptorFuncs) After(ctx context.Context, info *QueryInfo) {
+	if f.AfterFunc != nil {
+		f.AfterFunc(ctx, info)
+	}
+}
+
+var interceptorFactories = make(map[string]InterceptorFactory)
+
+// RegisterInterceptor 注册拦截器,通过dbs.<name>.interceptors按名称启用
+func RegisterInterceptor(name string, factory InterceptorFactory) {
+	if _, ok := interceptorFactories[name]; ok {
+		panic(fmt.Errorf("db: 拦截器不能重复注册:%s", name))
+	}
+	interceptorFactories[name] = factory
+}
+
+// InterceptorChain 拦截器链
+type InterceptorChain []Interceptor
+
+// BuildInterceptors 创建拦截器链,依次为DefaultInterceptors及配置的拦截器,已在默认列表中的名称不重复添加
+func BuildInterceptors(connName string, cfg *Config) (chain InterceptorChain, err error) {
+	names := make([]string, 0, len(DefaultInterceptors)+len(cfg.Interceptors))
+	exists := make(map[string]bool)
+	for _, name := range append(append([]string{}, DefaultInterceptors...), cfg.Interceptors...) {
+		if exists[name] {
+			continue
+		}
+		exists[name] = true
+		names = append(names, name)
+	}
+	chain = make(InterceptorChain, 0, len(names))
+	for _, name := range names {
+		factory, ok := interceptorFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("db: 未注册的拦截器:%s", name)
+		}
+		interceptor, err := factory(connName, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("db: 创建拦截器[%s]失败:%w", name, err)
+		}
+		if interceptor != nil {
+			chain = append(chain, interceptor)
+		}
+	}
+	return chain, nil
+}
+
+// Invoke 依次执行Before,全部通过后执行fn,最后按相反顺序执行After
+func (c InterceptorChain) Invoke(ctx context.Context, info *QueryInfo, fn func(ctx context.Context, info *QueryInfo) error) error {
+	info.Start = time.Now()
+	var err error
+	ctxs := make([]context.Context, 0, len(c))
+	for _, interceptor := range c {
+		nctx, berr := interceptor.Before(ctx, info)
+		if berr != nil {
+			err = berr
+			break
+		}
+		ctx = nctx
+		ctxs = append(ctxs, ctx)
+	}
+	if err == nil {
+		err = fn(ctx, info)
+	}
+	info.Duration = time.Since(info.Start)
+	info.Err = err
+	for i := len(ctxs) - 1; i >= 0; i-- {
+		c[i].After(ctxs[i], info)
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package xdb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestBuildInterceptorsKeepDefaults(t *testing.T) {
+	var names []string
+	origDefaults, origFactories := DefaultInterceptors, interceptorFactories
+	defer func() { DefaultInterceptors, interceptorFactories = origDefaults, origFactories }()
+	interceptorFactories = make(map[string]InterceptorFactory)
+	for _, name := range []string{"a", "b", "c"} {
+		name := name
+		RegisterInterceptor(name, func(connName string, cfg *Config) (Interceptor, error) {
+			return InterceptorFuncs{BeforeFunc: func(ctx context.Context, info *QueryInfo) (context.Context, error) {
+				names = append(names, name)
+				return ctx, nil
+			}}, nil
+		})
+	}
+	DefaultInterceptors = []string{"a", "b"}
+
+	chain, err := BuildInterceptors("test", &Config{Interceptors: []string{"c", "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.Invoke(context.Background(), &QueryInfo{}, func(ctx context.Context, info *QueryInfo) error { return nil })
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("interceptors = %v, want %v", names, want)
+	}
+}
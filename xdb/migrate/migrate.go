@@ -0,0 +1,271 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"time"
+
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+// ErrLocked 其他实例正在执行迁移
+var ErrLocked = errors.New("migrate: 其他实例正在执行迁移")
+
+// Status 迁移版本状态
+type Status struct {
+	*Migration
+	Applied   bool
+	AppliedAt string
+}
+
+// Migrator 数据库迁移,版本记录保存在当前连接(dbs.<name>)的版本表中
+type Migrator struct {
+	db         xdb.IDB
+	migrations []*Migration
+	opts       *Options
+}
+
+// New 构建迁移对象,迁移脚本从fsys的dir目录中加载
+func New(db xdb.IDB, fsys fs.FS, dir string, opts ...Option) (*Migrator, error) {
+	migrations, err := Load(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithMigrations(db, migrations, opts...), nil
+}
+
+// NewWithMigrations 使用已加载的迁移脚本构建迁移对象
+func NewWithMigrations(db xdb.IDB, migrations []*Migration, opts ...Option) *Migrator {
+	migrateOpts := &Options{
+		Table:      DefaultTable,
+		LockExpire: defaultLockExpire,
+	}
+	for i := range opts {
+		opts[i](migrateOpts)
+	}
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		opts:       migrateOpts,
+	}
+}
+
+// Up 按版本升序执行未执行的迁移,steps<=0时执行全部
+func (m *Migrator) Up(ctx context.Context, steps int) (migrations []*Migration, err error) {
+	err = m.withLock(ctx, func(ctx context.Context, applied map[int64]string) error {
+		for _, item := range m.migrations {
+			if steps > 0 && len(migrations) >= steps {
+				break
+			}
+			if _, ok := applied[item.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, item, true); err != nil {
+				return err
+			}
+			migrations = append(migrations, item)
+		}
+		return nil
+	})
+	return
+}
+
+// Down 按版本降序回滚已执行的迁移,steps<=0时回滚全部
+func (m *Migrator) Down(ctx context.Context, steps int) (migrations []*Migration, err error) {
+	err = m.withLock(ctx, func(ctx context.Context, applied map[int64]string) error {
+		var err error
+		migrations, err = m.down(ctx, applied, steps)
+		return err
+	})
+	return
+}
+
+// Redo 回滚最后一个已执行的版本并重新执行
+func (m *Migrator) Redo(ctx context.Context) (migration *Migration, err error) {
+	err = m.withLock(ctx, func(ctx context.Context, applied map[int64]string) error {
+		migrations, err := m.down(ctx, applied, 1)
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			return nil
+		}
+		migration = migrations[0]
+		return m.apply(ctx, migration, true)
+	})
+	return
+}
+
+// Status 获取所有版本的执行状态
+func (m *Migrator) Status(ctx context.Context) (list []*Status, err error) {
+	if err = m.ensureTable(ctx); err != nil {
+		return
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return
+	}
+	list = make([]*Status, 0, len(m.migrations))
+	for _, item := range m.migrations {
+		appliedAt, ok := applied[item.Version]
+		list = append(list, &Status{Migration: item, Applied: ok, AppliedAt: appliedAt})
+	}
+	return list, nil
+}
+
+func (m *Migrator) down(ctx context.Context, applied map[int64]string, steps int) (migrations []*Migration, err error) {
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if steps > 0 && len(migrations) >= steps {
+			break
+		}
+		item := m.migrations[i]
+		if _, ok := applied[item.Version]; !ok {
+			continue
+		}
+		if err = m.apply(ctx, item, false); err != nil {
+			return
+		}
+		migrations = append(migrations, item)
+	}
+	return
+}
+
+// apply 在事务中执行脚本并更新版本表;ctx取消(如锁续期失败)时停止执行并回滚当前版本
+func (m *Migrator) apply(ctx context.Context, item *Migration, up bool) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("migrate: 版本[%d_%s]未执行:%w", item.Version, item.Name, err)
+	}
+	script := item.Up
+	if !up {
+		script = item.Down
+		if script == "" {
+			return fmt.Errorf("migrate: 版本[%d_%s]缺少down脚本", item.Version, item.Name)
+		}
+	}
+	err := m.db.Transaction(func(tx xdb.Executer) error {
+		for _, stmt := range splitStatements(script) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, stmt, nil); err != nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		params := map[string]any{
+			"version":    strconv.FormatInt(item.Version, 10),
+			"name":       item.Name,
+			"applied_at": time.Now().Format(xdb.DateFormat),
+		}
+		var err error
+		if up {
+			_, err = tx.Exec(ctx, fmt.Sprintf("insert into %s(version,name,applied_at) values(@{version},@{name},@{applied_at})", m.opts.Table), params)
+		} else {
+			_, err = tx.Exec(ctx, fmt.Sprintf("delete from %s where version=@{version}", m.opts.Table), params)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migrate: 执行版本[%d_%s]出错:%w", item.Version, item.Name, err)
+	}
+	return nil
+}
+
+// withLock 获取锁并读取已执行版本后执行callback;
+// 执行期间每隔LockExpire/3续期,续期失败时取消callback的ctx,未完成的版本回滚,避免锁过期后其他实例重复执行
+func (m *Migrator) withLock(ctx context.Context, callback func(ctx context.Context, applied map[int64]string) error) (err error) {
+	if m.opts.Locker != nil {
+		ok, err := m.opts.Locker.Acquire(m.opts.LockExpire)
+		if err != nil {
+			return fmt.Errorf("migrate: 获取锁出错:%w", err)
+		}
+		if !ok {
+			return ErrLocked
+		}
+		defer m.opts.Locker.Release()
+		var stop func() error
+		ctx, stop = m.keepLock(ctx)
+		defer func() {
+			if renewErr := stop(); renewErr != nil && err != nil {
+				err = fmt.Errorf("%w,锁续期失败:%v", err, renewErr)
+			}
+		}()
+	}
+	if err = m.ensureTable(ctx); err != nil {
+		return
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return
+	}
+	return callback(ctx, applied)
+}
+
+// newRenewTicker 续期定时器,返回定时通道及停止函数
+var newRenewTicker = func(interval time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// keepLock 定时续期迁移锁,返回的ctx在续期失败时取消;stop停止续期并返回续期错误
+func (m *Migrator) keepLock(ctx context.Context) (context.Context, func() error) {
+	lockCtx, cancel := context.WithCancel(ctx)
+	interval := time.Duration(m.opts.LockExpire) * time.Second / 3
+	if interval <= 0 {
+		interval = time.Second
+	}
+	done := make(chan struct{})
+	var renewErr error
+	go func() {
+		defer close(done)
+		tick, stopTicker := newRenewTicker(interval)
+		defer stopTicker()
+		for {
+			select {
+			case <-lockCtx.Done():
+				return
+			case <-tick:
+				if renewErr = m.opts.Locker.Renewal(m.opts.LockExpire); renewErr != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return lockCtx, func() error {
+		cancel()
+		<-done
+		return renewErr
+	}
+}
+
+// ensureTable 版本表不存在时创建;使用各数据库通用的字段类型
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	err := xdb.EnsureTable(ctx, m.db, m.opts.Table, fmt.Sprintf("create table %s(version varchar(32) not null primary key,name varchar(255) not null,applied_at varchar(32) not null)", m.opts.Table))
+	if err != nil {
+		return fmt.Errorf("migrate: 创建版本表[%s]出错:%w", m.opts.Table, err)
+	}
+	return nil
+}
+
+// applied 已执行的版本及执行时间
+func (m *Migrator) applied(ctx context.Context) (map[int64]string, error) {
+	rows, err := m.db.Query(xdb.WithReadPrimary(ctx), fmt.Sprintf("select version,applied_at from %s", m.opts.Table), nil)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: 查询版本表[%s]出错:%w", m.opts.Table, err)
+	}
+	applied := make(map[int64]string, len(rows))
+	for _, row := range rows {
+		version, err := strconv.ParseInt(row.GetString("version"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: 版本表[%s]数据错误:%w", m.opts.Table, err)
+		}
+		applied[version] = row.GetString("applied_at")
+	}
+	return applied, nil
+}
@@ -0,0 +1,267 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	contribxdb "github.com/zhiyunliu/glue/contrib/xdb"
+	_ "github.com/zhiyunliu/glue/contrib/xdb/sqlite"
+	"github.com/zhiyunliu/glue/dlocker"
+	"github.com/zhiyunliu/glue/xdb"
+)
+
+var testFS = fstest.MapFS{
+	"migrations/1_create_users.up.sql":      {Data: []byte("create table users(id integer primary key, name varchar(20));\n-- 初始数据\ninsert into users(id,name) values(1,'admin');")},
+	"migrations/1_create_users.down.sql":    {Data: []byte("drop table users;")},
+	"migrations/2_create_orders.up.sql":     {Data: []byte("create table orders(id integer primary key);")},
+	"migrations/2_create_orders.down.sql":   {Data: []byte("drop table orders;")},
+	"migrations/10_add_user_email.up.sql":   {Data: []byte("alter table users add column email varchar(50);")},
+	"migrations/10_add_user_email.down.sql": {Data: []byte("create table users_bak(id integer primary key, name varchar(20));\ninsert into users_bak select id,name from users;\ndrop table users;\nalter table users_bak rename to users;")},
+	"migrations/README.md":                  {Data: []byte("ignored")},
+}
+
+func newTestDB(t *testing.T) xdb.IDB {
+	setting := contribxdb.NewConfig("migrate_test")
+	setting.Cfg.Conn = filepath.Join(t.TempDir(), "migrate.db")
+	dbobj, err := contribxdb.NewDB("sqlite", setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dbobj.Close() })
+	return dbobj
+}
+
+func versions(migrations []*Migration) []int64 {
+	list := make([]int64, 0, len(migrations))
+	for _, item := range migrations {
+		list = append(list, item.Version)
+	}
+	return list
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testFS, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(migrations); !reflect.DeepEqual(got, []int64{1, 2, 10}) {
+		t.Errorf("Load() versions = %v", got)
+	}
+	if migrations[0].Name != "create_users" || migrations[0].Down == "" {
+		t.Errorf("Load() migration = %+v", migrations[0])
+	}
+
+	_, err = Load(fstest.MapFS{"1_a.down.sql": {Data: []byte("drop table a;")}}, ".")
+	if err == nil {
+		t.Errorf("Load() without up script should return error")
+	}
+}
+
+func Test_splitStatements(t *testing.T) {
+	got := splitStatements("create table a(id int);\n-- comment\ninsert into a values(1);\n\n-- tail comment\n")
+	want := []string{"create table a(id int)", "-- comment\ninsert into a values(1)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements() = %q, want %q", got, want)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	dbobj := newTestDB(t)
+	m, err := New(dbobj, testFS, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("Up(2) = %v", got)
+	}
+	applied, err = m.Up(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, []int64{10}) {
+		t.Errorf("Up(0) = %v", got)
+	}
+	if _, err = dbobj.Exec(ctx, "update users set email='a@b.c' where id=1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	redo, err := m.Redo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redo == nil || redo.Version != 10 {
+		t.Errorf("Redo() = %+v", redo)
+	}
+
+	reverted, err := m.Down(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(reverted); !reflect.DeepEqual(got, []int64{10, 2}) {
+		t.Errorf("Down(2) = %v", got)
+	}
+
+	list, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := make([]bool, 0, len(list))
+	for _, item := range list {
+		status = append(status, item.Applied)
+	}
+	if !reflect.DeepEqual(status, []bool{true, false, false}) || list[0].AppliedAt == "" {
+		t.Errorf("Status() = %v", status)
+	}
+	row, err := dbobj.First(ctx, "select name from users where id=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := row.GetString("name"); got != "admin" {
+		t.Errorf("users.name = %v, want admin", got)
+	}
+}
+
+func TestMigrator_Failed(t *testing.T) {
+	ctx := context.Background()
+	dbobj := newTestDB(t)
+	m := NewWithMigrations(dbobj, []*Migration{
+		{Version: 1, Name: "ok", Up: "create table a(id integer);"},
+		{Version: 2, Name: "broken", Up: "create table b(id integer);\ncreate tabel c(id integer);"},
+	}, WithTable("db_versions"))
+
+	applied, err := m.Up(ctx, 0)
+	if err == nil {
+		t.Fatal("Up() err = nil, want error")
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("Up() applied = %v", got)
+	}
+	if _, err = dbobj.Query(ctx, "select id from b", nil); err == nil {
+		t.Errorf("table b should be rolled back")
+	}
+	if _, err = m.Down(ctx, 0); err == nil {
+		t.Errorf("Down() without down script should return error")
+	}
+}
+
+type testLocker struct {
+	locked   bool
+	released bool
+	renewErr error
+	renewals int
+}
+
+func (l *testLocker) Acquire(expire int) (bool, error) {
+	return !l.locked, nil
+}
+
+func (l *testLocker) Release() (bool, error) {
+	l.released = true
+	return true, nil
+}
+
+func (l *testLocker) Renewal(expire int) error {
+	l.renewals++
+	return l.renewErr
+}
+
+func (l *testLocker) AcquireCtx(ctx context.Context, expire int) (int64, error) {
+	if l.locked {
+		return 0, ErrLocked
+	}
+	return 1, nil
+}
+
+func (l *testLocker) Token() int64 {
+	return 1
+}
+
+var _ dlocker.DLocker = (*testLocker)(nil)
+
+func TestMigrator_Locker(t *testing.T) {
+	ctx := context.Background()
+	dbobj := newTestDB(t)
+
+	locker := &testLocker{locked: true}
+	m, err := New(dbobj, testFS, "migrations", WithLocker(locker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Up(ctx, 0); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Up() err = %v, want ErrLocked", err)
+	}
+
+	locker.locked = false
+	if _, err = m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !locker.released {
+		t.Errorf("locker should be released")
+	}
+}
+
+func TestMigrator_KeepLock(t *testing.T) {
+	tick := make(chan time.Time)
+	old := newRenewTicker
+	newRenewTicker = func(time.Duration) (<-chan time.Time, func()) { return tick, func() {} }
+	t.Cleanup(func() { newRenewTicker = old })
+
+	locker := &testLocker{}
+	m := NewWithMigrations(nil, nil, WithLocker(locker), WithLockExpire(1))
+	ctx, stop := m.keepLock(context.Background())
+	//无缓冲通道,第二次发送成功时第一次续期已完成
+	tick <- time.Now()
+	tick <- time.Now()
+	if err := stop(); err != nil || locker.renewals != 2 {
+		t.Fatalf("stop() = %v, renewals = %d", err, locker.renewals)
+	}
+	if ctx.Err() == nil {
+		t.Errorf("ctx should be canceled after stop")
+	}
+
+	//续期失败时取消ctx
+	locker.renewErr = errors.New("lock lost")
+	ctx, stop = m.keepLock(context.Background())
+	tick <- time.Now()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("ctx should be canceled when renewal fails")
+	}
+	if err := stop(); !errors.Is(err, locker.renewErr) {
+		t.Fatalf("stop() = %v", err)
+	}
+}
+
+func TestMigrator_Canceled(t *testing.T) {
+	dbobj := newTestDB(t)
+	m, err := New(dbobj, testFS, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if migrations, err := m.Up(ctx, 0); !errors.Is(err, context.Canceled) || len(migrations) != 0 {
+		t.Fatalf("Up() canceled = %v,%v", versions(migrations), err)
+	}
+	list, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range list {
+		if item.Applied {
+			t.Errorf("version %d should not be applied", item.Version)
+		}
+	}
+}
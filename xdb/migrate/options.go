@@ -0,0 +1,42 @@
+package migrate
+
+import "github.com/zhiyunliu/glue/dlocker"
+
+const (
+	//DefaultTable 默认的版本记录表
+	DefaultTable = "schema_migrations"
+	//默认锁过期时间(秒)
+	defaultLockExpire = 300
+)
+
+type Options struct {
+	//Table 记录已执行版本的表名
+	Table string
+	//Locker 分布式锁,保证同一时刻只有一个实例执行迁移
+	Locker dlocker.DLocker
+	//LockExpire 锁过期时间(秒),迁移期间每隔LockExpire/3续期
+	LockExpire int
+}
+
+type Option func(opts *Options)
+
+// WithTable 设置版本记录表名
+func WithTable(table string) Option {
+	return func(opts *Options) {
+		opts.Table = table
+	}
+}
+
+// WithLocker 设置分布式锁
+func WithLocker(locker dlocker.DLocker) Option {
+	return func(opts *Options) {
+		opts.Locker = locker
+	}
+}
+
+// WithLockExpire 设置锁过期时间(秒)
+func WithLockExpire(expire int) Option {
+	return func(opts *Options) {
+		opts.LockExpire = expire
+	}
+}
@@ -0,0 +1,111 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 迁移脚本文件名: 版本号_名称.up.sql / 版本号_名称.down.sql
+var fileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration 一个版本的迁移脚本
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load 从fsys的dir目录中加载迁移脚本,按版本号升序返回;fsys可以是embed.FS或os.DirFS
+func Load(fsys fs.FS, dir string) ([]*Migration, error) {
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: 读取迁移目录[%s]出错:%w", dir, err)
+	}
+	migrationMap := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := fileRegexp.FindStringSubmatch(entry.Name())
+		if len(matches) != 4 {
+			continue
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: 迁移文件[%s]版本号错误:%w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("migrate: 读取迁移文件[%s]出错:%w", entry.Name(), err)
+		}
+
+		item, ok := migrationMap[version]
+		if !ok {
+			item = &Migration{Version: version, Name: matches[2]}
+			migrationMap[version] = item
+		}
+		if item.Name != matches[2] {
+			return nil, fmt.Errorf("migrate: 版本号[%d]重复:%s,%s", version, item.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			item.Up = string(content)
+		} else {
+			item.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(migrationMap))
+	for _, item := range migrationMap {
+		if strings.TrimSpace(item.Up) == "" {
+			return nil, fmt.Errorf("migrate: 版本[%d_%s]缺少up脚本", item.Version, item.Name)
+		}
+		migrations = append(migrations, item)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// splitStatements 按行尾的分号拆分多条语句,并去掉语句结尾的分号(部分驱动不支持一次执行多条语句)
+func splitStatements(script string) []string {
+	statements := make([]string, 0, 1)
+	builder := strings.Builder{}
+	flush := func() {
+		stmt := strings.TrimSpace(builder.String())
+		builder.Reset()
+		stmt = strings.TrimSpace(strings.TrimSuffix(stmt, ";"))
+		if !isComment(stmt) {
+			statements = append(statements, stmt)
+		}
+	}
+	for _, line := range strings.Split(script, "\n") {
+		builder.WriteString(line)
+		builder.WriteString("\n")
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			flush()
+		}
+	}
+	flush()
+	return statements
+}
+
+// isComment 语句为空或只包含注释
+func isComment(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
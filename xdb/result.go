@@ -4,8 +4,11 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"reflect"
+	"strconv"
 
 	"github.com/zhiyunliu/golibs/bytesconv"
+	"github.com/zhiyunliu/golibs/xreflect"
 	"github.com/zhiyunliu/golibs/xtypes"
 )
 
@@ -27,6 +30,30 @@ func NewRows() Rows {
 	return make([]xtypes.XMap, 0)
 }
 
+// ToInt64 转换numeric字段的值,不同驱动可能返回整数、浮点数、字符串、字节数组或其指针
+func ToInt64(val interface{}) (int64, error) {
+	for rv := reflect.ValueOf(val); rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface); rv = rv.Elem() {
+		if rv.IsNil() {
+			return 0, nil
+		}
+		val = rv.Elem().Interface()
+	}
+	switch v := val.(type) {
+	case float64:
+		return int64(v), nil
+	case []byte:
+		val = string(v)
+	}
+	if v, ok := val.(string); ok {
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		return int64(f), err
+	}
+	return xreflect.GetInt64(val)
+}
+
 type RawMessage []byte
 
 // MarshalJSON returns m as the JSON encoding of m.
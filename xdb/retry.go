@@ -0,0 +1,68 @@
+package xdb
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 20
+	defaultRetryMaxBackoff = 1000
+)
+
+// RetryPolicy 事务重试策略,事务返回死锁、序列化失败等可重试错误时重新执行整个事务
+type RetryPolicy struct {
+	MaxAttempts int     `json:"max_attempts" label:"最大执行次数(含首次)"`
+	Backoff     int     `json:"backoff" label:"首次重试等待时长(毫秒)"`
+	MaxBackoff  int     `json:"max_backoff" label:"最大重试等待时长(毫秒)"`
+	Jitter      float64 `json:"jitter" label:"等待时长随机抖动比例(0-1)"`
+	Metrics     string  `json:"metrics" label:"重试指标提供程序"`
+}
+
+// Delay 第attempt次重试前的等待时长,按指数退避并加入随机抖动
+func (p *RetryPolicy) Delay(attempt int) time.Duration {
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	delay := float64(backoff)
+	for i := 1; i < attempt && delay < float64(maxBackoff); i++ {
+		delay *= 2
+	}
+	if delay > float64(maxBackoff) {
+		delay = float64(maxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay = delay * (1 - jitter + 2*jitter*rand.Float64())
+	}
+	return time.Duration(delay * float64(time.Millisecond))
+}
+
+// Do 执行fn,返回可重试错误时按退避策略重新执行,onRetry在每次重试前调用
+func (p *RetryPolicy) Do(ctx context.Context, retryable func(error) bool, onRetry func(attempt int, err error), fn func() error) (err error) {
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= p.MaxAttempts || retryable == nil || !retryable(err) {
+			return
+		}
+		if onRetry != nil {
+			onRetry(attempt, err)
+		}
+		timer := time.NewTimer(p.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
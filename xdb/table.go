@@ -0,0 +1,28 @@
+package xdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// TableExists 表是否存在,在主库上查询,避免只读副本未同步时误判
+func TableExists(ctx context.Context, db Executer, table string) bool {
+	_, err := db.Query(WithReadPrimary(ctx), fmt.Sprintf("select 1 from %s where 1=0", table), nil)
+	return err == nil
+}
+
+// EnsureTable 表不存在时依次执行ddl创建;执行出错时表已存在(其他实例并发创建)视为成功
+func EnsureTable(ctx context.Context, db Executer, table string, ddl ...string) error {
+	if TableExists(ctx, db, table) {
+		return nil
+	}
+	for _, stmt := range ddl {
+		if _, err := db.Exec(ctx, stmt, nil); err != nil {
+			if TableExists(ctx, db, table) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
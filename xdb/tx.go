@@ -0,0 +1,107 @@
+package xdb
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// Propagation 事务传播方式
+type Propagation int
+
+const (
+	//PropagationRequired 上下文中存在当前连接的事务时加入该事务,否则开启新事务
+	PropagationRequired Propagation = iota
+	//PropagationRequiresNew 总是开启新事务,与上下文中的事务相互独立
+	PropagationRequiresNew
+	//PropagationNested 上下文中存在当前连接的事务时创建保存点,失败时只回滚到保存点,否则开启新事务
+	PropagationNested
+)
+
+// TxCallback 上下文事务回调,ctx中已绑定tx
+type TxCallback func(ctx context.Context, tx Executer) error
+
+// TxOptions 上下文事务选项
+type TxOptions struct {
+	Propagation Propagation
+}
+
+// TxOption 上下文事务选项
+type TxOption func(*TxOptions)
+
+// WithPropagation 设置事务传播方式,默认为PropagationRequired
+func WithPropagation(propagation Propagation) TxOption {
+	return func(o *TxOptions) {
+		o.Propagation = propagation
+	}
+}
+
+type txKey struct {
+	connName string
+}
+
+// WithTx 将事务绑定到上下文,使用同一连接名称的DB执行操作时自动加入该事务
+func WithTx(ctx context.Context, tx ITrans) context.Context {
+	return context.WithValue(ctx, txKey{connName: tx.ConnName()}, tx)
+}
+
+// GetTx 获取上下文中绑定的指定连接的事务
+func GetTx(ctx context.Context, connName string) (ITrans, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tx, ok := ctx.Value(txKey{connName: connName}).(ITrans)
+	return tx, ok
+}
+
+// BeginsNewTx 按传播方式判断执行上下文事务时是否开启新事务,加入已有事务或创建保存点时返回false
+func BeginsNewTx(ctx context.Context, connName string, opts ...TxOption) bool {
+	txOpts := &TxOptions{}
+	for i := range opts {
+		opts[i](txOpts)
+	}
+	_, ok := GetTx(ctx, connName)
+	return !ok || txOpts.Propagation == PropagationRequiresNew
+}
+
+// RunTransaction 按传播方式执行上下文事务,begin为开启新事务的方法,由各数据库实现调用
+func RunTransaction(ctx context.Context, connName string, begin func() (ITrans, error), callback TxCallback, opts ...TxOption) (err error) {
+	txOpts := &TxOptions{}
+	for i := range opts {
+		opts[i](txOpts)
+	}
+
+	current, ok := GetTx(ctx, connName)
+	var tx ITrans
+	switch {
+	case ok && txOpts.Propagation == PropagationRequired:
+		return callback(ctx, current)
+	case ok && txOpts.Propagation == PropagationNested:
+		tx, err = current.Savepoint()
+	default:
+		tx, err = begin()
+	}
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if robj := recover(); robj != nil {
+			tx.Rollback()
+			rerr, ok := robj.(error)
+			if !ok {
+				rerr = fmt.Errorf("%+v", robj)
+			}
+			buf := make([]byte, 64<<10) //nolint:gomnd
+			n := runtime.Stack(buf, false)
+			buf = buf[:n]
+			err = NewPanicError(rerr, string(buf))
+		}
+	}()
+	err = callback(WithTx(ctx, tx), tx)
+	if err != nil {
+		tx.Rollback()
+		return
+	}
+	return tx.Commit()
+}
@@ -16,6 +16,8 @@ type IDB interface {
 	Close() error
 	GetImpl() interface{}
 	Transaction(TransactionCallback) error
+	//TransactionContext 按传播方式执行事务,回调中的ctx已绑定当前事务
+	TransactionContext(ctx context.Context, callback TxCallback, opts ...TxOption) error
 }
 
 // ITrans 数据库事务接口
@@ -23,6 +25,10 @@ type ITrans interface {
 	Executer
 	Rollback() error
 	Commit() error
+	//ConnName 事务所属的数据库连接名称
+	ConnName() string
+	//Savepoint 在当前事务中创建保存点,返回的事务对象提交时释放保存点,回滚时回滚到保存点
+	Savepoint() (ITrans, error)
 }
 
 // Executer 数据库操作对象集合
@@ -35,6 +41,39 @@ type Executer interface {
 
 	QueryAs(ctx context.Context, sql string, input any, result any) (err error)
 	FirstAs(ctx context.Context, sql string, input any, result any) (err error)
+
+	//QueryStream 流式查询,逐行读取结果,使用完成后必须调用Close
+	QueryStream(ctx context.Context, sql string, input any) (cursor Cursor, err error)
+
+	//BatchExec 批量执行,inputs中每个元素作为一行数据的参数;
+	//insert ... values(...) 语句会按数据库参数上限合并为多行values分片执行
+	BatchExec(ctx context.Context, sql string, inputs []any, opts ...BatchOption) (r Result, err error)
+
+	//Insert 按struct的db标签插入一行数据,返回自增列的值(无自增列时为0)并回写到obj
+	Insert(ctx context.Context, obj any) (id int64, err error)
+	//Update 按主键(db:"id,pk")更新除主键、自增列以外的所有列
+	Update(ctx context.Context, obj any) (r Result, err error)
+	//Upsert 按主键存在时更新,否则插入
+	Upsert(ctx context.Context, obj any) (r Result, err error)
+	//DeleteByKey 按主键删除
+	DeleteByKey(ctx context.Context, obj any) (r Result, err error)
+	//GetByKey 按obj中的主键值查询并填充obj,未找到时返回false
+	GetByKey(ctx context.Context, obj any) (found bool, err error)
+}
+
+// Cursor 结果集游标
+type Cursor interface {
+	Columns() ([]string, error)
+	Next() bool
+	NextResultSet() bool
+	//Scan 将当前行读取到dest中,与sql.Rows.Scan一致
+	Scan(dest ...any) error
+	//ScanRow 将当前行读取为Row
+	ScanRow() (Row, error)
+	//ScanAs 将当前行读取到struct或map指针中
+	ScanAs(result any) error
+	Err() error
+	Close() error
 }
 
 // dbResover 定义配置文件转换方法
@@ -0,0 +1,72 @@
+package xmqc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/zhiyunliu/glue/queue"
+)
+
+// BatchOptions 批量消费配置,handler的请求体为本批消息体组成的JSON数组
+type BatchOptions struct {
+	//MaxSize 每批最大消息数
+	MaxSize int `json:"max_size"`
+	//MaxWait 凑批的最长等待时间(毫秒),默认1000
+	MaxWait int `json:"max_wait,omitempty"`
+}
+
+// Batch 批量消费时的一批消息,通过 GetBatch(ctx) 获取;
+// 未单独确认的消息按handler的处理结果(整批)确认,单独确认的消息按各自的状态确认或重试
+type Batch struct {
+	messages []queue.IMQCMessage
+	status   []int
+}
+
+// NewBatch 构建批次
+func NewBatch(messages []queue.IMQCMessage) *Batch {
+	return &Batch{
+		messages: messages,
+		status:   make([]int, len(messages)),
+	}
+}
+
+// Len 消息数
+func (b *Batch) Len() int {
+	return len(b.messages)
+}
+
+// Messages 本批消息,顺序与请求体中的数组一致
+func (b *Batch) Messages() []queue.IMQCMessage {
+	return b.messages
+}
+
+// Ack 第i条消息处理成功
+func (b *Batch) Ack(i int) {
+	b.status[i] = http.StatusOK
+}
+
+// Nack 第i条消息处理失败,status为处理状态,按任务的重试策略重试或写入死信
+func (b *Batch) Nack(i int, status int) {
+	b.status[i] = status
+}
+
+// Status 第i条消息的处理状态,未单独确认时为整批的状态
+func (b *Batch) Status(i int, batchStatus int) int {
+	if b.status[i] != 0 {
+		return b.status[i]
+	}
+	return batchStatus
+}
+
+type batchKey struct{}
+
+// WithBatch 将批次绑定到上下文
+func WithBatch(ctx context.Context, b *Batch) context.Context {
+	return context.WithValue(ctx, batchKey{}, b)
+}
+
+// GetBatch 获取上下文中的批次,非批量消费时返回false
+func GetBatch(ctx context.Context) (*Batch, bool) {
+	b, ok := ctx.Value(batchKey{}).(*Batch)
+	return b, ok
+}
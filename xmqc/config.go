@@ -1,8 +1,11 @@
 package xmqc
 
 import (
+	"time"
+
 	"github.com/zhiyunliu/glue/engine"
 	"github.com/zhiyunliu/glue/metadata"
+	"github.com/zhiyunliu/glue/queue"
 )
 
 type Config struct {
@@ -18,6 +21,11 @@ type Task struct {
 	Concurrency       int               `json:"concurrency,omitempty"`
 	BufferSize        int               `json:"buffersize,omitempty"`
 	VisibilityTimeout int               `json:"visibility_timeout"`
+	LeaderOnly        bool              `json:"leader_only"`
+	Retry             *RetryPolicy      `json:"retry,omitempty"`
+	DeadLetter        string            `json:"deadletter,omitempty"`
+	Idempotent        *Idempotent       `json:"idempotent,omitempty"`
+	Batch             *BatchOptions     `json:"batch,omitempty"`
 	Meta              metadata.Metadata `json:"meta,omitempty"`
 }
 
@@ -27,6 +35,32 @@ func (t Task) GetQueue() string {
 	return t.Queue
 }
 
+// 是否仅在leader节点消费
+func (t Task) IsLeaderOnly() bool {
+	return t.LeaderOnly
+}
+
+// 是否批量消费
+func (t Task) IsBatch() bool {
+	return t.Batch != nil && t.Batch.MaxSize > 0
+}
+
+// GetBatchSize 每批最大消息数
+func (t Task) GetBatchSize() int {
+	if t.Batch == nil {
+		return 0
+	}
+	return t.Batch.MaxSize
+}
+
+// GetBatchWait 凑批的最长等待时间
+func (t Task) GetBatchWait() time.Duration {
+	if t.Batch == nil || t.Batch.MaxWait <= 0 {
+		return queue.DefaultBatchWait
+	}
+	return time.Duration(t.Batch.MaxWait) * time.Millisecond
+}
+
 func (t Task) GetConcurrency() int {
 	return t.Concurrency
 }
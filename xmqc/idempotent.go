@@ -0,0 +1,40 @@
+package xmqc
+
+const (
+	//DefaultIdempotentTTL 已处理消息标识的默认保留时间(秒)
+	DefaultIdempotentTTL = 86400
+	//DefaultIdempotentTable 使用xdb保存已处理消息标识时的默认表名
+	DefaultIdempotentTable = "glue_mqc_idempotent"
+)
+
+// Idempotent 消息去重配置,由mqc服务统一执行:
+// 处理前按消息标识占用,已处理的重复消息直接确认,处理中的重复消息按失败重试,均不调用handler;
+// 处理成功后保留标识TTL秒,失败时释放标识,重新投递的消息可以再次处理
+type Idempotent struct {
+	//Store 标识存储,cache://<caches配置名> 或 xdb://<dbs配置名>
+	Store string `json:"store"`
+	//Key 作为消息标识的消息头,未设置或消息头为空时使用 IMQCMessage.MessageId(),此时队列需要实现 queue.IMessageIdMQC
+	Key string `json:"key,omitempty"`
+	//TTL 已处理消息标识的保留时间(秒),默认一天
+	TTL int `json:"ttl,omitempty"`
+	//Table 使用xdb存储时的表名,默认为glue_mqc_idempotent
+	Table string `json:"table,omitempty"`
+	//Metrics metrics组件名称,设置后记录跳过的重复消息数
+	Metrics string `json:"metrics,omitempty"`
+}
+
+// GetTTL 已处理消息标识的保留时间(秒)
+func (i *Idempotent) GetTTL() int {
+	if i.TTL <= 0 {
+		return DefaultIdempotentTTL
+	}
+	return i.TTL
+}
+
+// GetTable xdb存储的表名
+func (i *Idempotent) GetTable() string {
+	if i.Table == "" {
+		return DefaultIdempotentTable
+	}
+	return i.Table
+}
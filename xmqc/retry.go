@@ -0,0 +1,95 @@
+package xmqc
+
+import (
+	"github.com/zhiyunliu/glue/queue"
+)
+
+const (
+	//BackoffFixed 每次重试间隔相同
+	BackoffFixed = "fixed"
+	//BackoffExponential 重试间隔按2的指数增长
+	BackoffExponential = "exponential"
+
+	//DefaultRetryDelay 默认重试间隔(秒)
+	DefaultRetryDelay = 1
+	//DefaultRetryMaxDelay 默认最大重试间隔(秒)
+	DefaultRetryMaxDelay = 60
+)
+
+// RetryPolicy 消息处理失败后的重试策略,由mqc服务统一执行:
+// 可重试的失败通过延迟消息重新投递,超过最大次数或不可重试时写入死信队列(Task.DeadLetter)
+type RetryPolicy struct {
+	//MaxAttempts 最大处理次数(含首次),未设置时为 queue.MaxRetrtCount
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	//Backoff fixed/exponential,默认fixed
+	Backoff string `json:"backoff,omitempty"`
+	//Delay 首次重试间隔(秒),默认1秒
+	Delay int `json:"delay,omitempty"`
+	//MaxDelay 最大重试间隔(秒),默认60秒
+	MaxDelay int `json:"max_delay,omitempty"`
+	//RetriableCodes 可重试的错误码(errors.Error的Code),设置后仅这些错误码重试
+	RetriableCodes []int `json:"retriable_codes,omitempty"`
+	//NonRetriableCodes 不重试的错误码,未设置RetriableCodes时4xx默认不重试
+	NonRetriableCodes []int `json:"non_retriable_codes,omitempty"`
+}
+
+// GetMaxAttempts 最大处理次数
+func (p *RetryPolicy) GetMaxAttempts() int {
+	if p == nil || p.MaxAttempts <= 0 {
+		return int(queue.MaxRetrtCount)
+	}
+	return p.MaxAttempts
+}
+
+// Retriable 错误码是否可重试
+func (p *RetryPolicy) Retriable(code int) bool {
+	if p == nil {
+		return !isClientError(code)
+	}
+	for _, c := range p.NonRetriableCodes {
+		if c == code {
+			return false
+		}
+	}
+	if len(p.RetriableCodes) > 0 {
+		for _, c := range p.RetriableCodes {
+			if c == code {
+				return true
+			}
+		}
+		return false
+	}
+	return !isClientError(code)
+}
+
+// NextDelay 第attempt次处理失败后的重试间隔(秒),attempt从1开始
+func (p *RetryPolicy) NextDelay(attempt int) int64 {
+	delay, maxDelay, backoff := DefaultRetryDelay, DefaultRetryMaxDelay, BackoffFixed
+	if p != nil {
+		if p.Delay > 0 {
+			delay = p.Delay
+		}
+		if p.MaxDelay > 0 {
+			maxDelay = p.MaxDelay
+		}
+		if p.Backoff != "" {
+			backoff = p.Backoff
+		}
+	}
+	if maxDelay < delay {
+		maxDelay = delay
+	}
+	if backoff == BackoffExponential {
+		for i := 1; i < attempt && delay < maxDelay; i++ {
+			delay *= 2
+		}
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return int64(delay)
+}
+
+func isClientError(code int) bool {
+	return code >= 400 && code < 500
+}
@@ -0,0 +1,44 @@
+package xmqc
+
+import "testing"
+
+func TestRetryPolicyNextDelay(t *testing.T) {
+	var nilPolicy *RetryPolicy
+	exp := &RetryPolicy{Backoff: BackoffExponential, Delay: 2, MaxDelay: 10}
+	tests := []struct {
+		policy  *RetryPolicy
+		attempt int
+		want    int64
+	}{
+		{nilPolicy, 1, DefaultRetryDelay},
+		{nilPolicy, 5, DefaultRetryDelay},
+		{exp, 1, 2},
+		{exp, 2, 4},
+		{exp, 3, 8},
+		{exp, 4, 10},
+		{&RetryPolicy{Delay: 5, MaxDelay: 3}, 1, 5},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.NextDelay(tt.attempt); got != tt.want {
+			t.Errorf("NextDelay(%+v, %d) = %d, want %d", tt.policy, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyRetriable(t *testing.T) {
+	var nilPolicy *RetryPolicy
+	if nilPolicy.Retriable(400) || !nilPolicy.Retriable(500) || !nilPolicy.Retriable(909) {
+		t.Error("default policy should retry everything except 4xx")
+	}
+	p := &RetryPolicy{NonRetriableCodes: []int{503}}
+	if p.Retriable(503) || !p.Retriable(500) {
+		t.Error("NonRetriableCodes should not be retried")
+	}
+	p = &RetryPolicy{RetriableCodes: []int{409}}
+	if !p.Retriable(409) || p.Retriable(500) {
+		t.Error("only RetriableCodes should be retried when set")
+	}
+	if nilPolicy.GetMaxAttempts() != 3 || (&RetryPolicy{MaxAttempts: 5}).GetMaxAttempts() != 5 {
+		t.Error("GetMaxAttempts")
+	}
+}